@@ -0,0 +1,188 @@
+// Command calibrate grades a teacher-labelled dataset with the current
+// prompts and model, stores the run and prints how well the grades agree.
+//
+//	go run ./cmd/calibrate -dataset labelled.jsonl -name "rubric v2" [-prompts ./prompts] [-baseline <run id>]
+//
+// The dataset is a JSON array or JSON lines of objects with file_name,
+// file_type, content, teacher_grade and optional assignment_id and locale.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/database"
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+	"codegrader-backend/internal/redaction"
+	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/resilience"
+	"codegrader-backend/internal/services"
+)
+
+func main() {
+	datasetPath := flag.String("dataset", "", "labelled dataset (JSON array or JSON lines)")
+	name := flag.String("name", "", "name of the run")
+	promptsDir := flag.String("prompts", "", "directory with candidate prompt templates, overrides PROMPTS_DIR")
+	baselineID := flag.String("baseline", "", "ID of a stored run to compare with")
+	flag.Parse()
+
+	if *datasetPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	if *promptsDir != "" {
+		cfg.Prompts.Dir = *promptsDir
+	}
+	if *name == "" {
+		*name = *datasetPath
+	}
+
+	samples, err := readDataset(*datasetPath)
+	if err != nil {
+		log.Fatalf("Failed to read dataset: %v", err)
+	}
+
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	promptStore, err := prompts.Load(cfg.Prompts.Dir, cfg.Prompts.DefaultLocale)
+	if err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+
+	defaultScale, err := grading.Parse(cfg.Grading.DefaultScale)
+	if err != nil {
+		log.Fatalf("Failed to parse GRADING_SCALE: %v", err)
+	}
+
+	var redactor *redaction.Redactor
+	if cfg.Redaction.Enabled {
+		redactor, err = redaction.Load(cfg.Redaction.PatternsFile)
+		if err != nil {
+			log.Fatalf("Failed to load redaction patterns: %v", err)
+		}
+	}
+
+	analysisCacheRepo := repositories.NewAnalysisCacheRepository(db)
+	usageRepo := repositories.NewUsageRepository(db)
+	courseRepo := repositories.NewCourseRepository(db)
+	assignmentRepo := repositories.NewAssignmentRepository(db)
+	evaluationRepo := repositories.NewEvaluationRepository(db)
+	usageSvc := services.NewUsageService(usageRepo, courseRepo, cfg.Usage)
+	scaleSvc := services.NewScaleService(assignmentRepo, courseRepo, defaultScale)
+	openaiGuard := resilience.NewGuard("openai",
+		resilience.RetryPolicy{
+			MaxRetries: cfg.OpenAI.MaxRetries,
+			BaseDelay:  cfg.OpenAI.RetryBaseDelay,
+			MaxDelay:   cfg.OpenAI.RetryMaxDelay,
+		},
+		resilience.NewBreaker(cfg.OpenAI.BreakerThreshold, cfg.OpenAI.BreakerCooldown),
+	)
+	openaiSvc := services.NewOpenAIService(cfg, openaiGuard, usageSvc, promptStore, redactor)
+	analysisSvc := services.NewAnalysisService(openaiSvc, analysisCacheRepo, assignmentRepo, courseRepo, scaleSvc, promptStore, cfg.OpenAI)
+	calibrationSvc := services.NewCalibrationService(evaluationRepo, analysisSvc, openaiSvc)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	run, err := calibrationSvc.Evaluate(ctx, &models.CalibrationRequest{Name: *name, Samples: samples})
+	if err != nil {
+		if run != nil {
+			log.Fatalf("Evaluation run %s failed: %v", run.ID, err)
+		}
+		log.Fatalf("Evaluation failed: %v", err)
+	}
+
+	var baseline *models.EvaluationRun
+	if *baselineID != "" {
+		baseline, err = calibrationSvc.GetRun(ctx, *baselineID)
+		if err != nil {
+			log.Fatalf("Failed to load baseline run %s: %v", *baselineID, err)
+		}
+	}
+	printReport(run, baseline)
+}
+
+func readDataset(path string) ([]models.CalibrationSample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []models.CalibrationSample
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &samples); err != nil {
+			return nil, err
+		}
+		return samples, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var sample models.CalibrationSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+func printReport(run, baseline *models.EvaluationRun) {
+	fmt.Printf("Run %s (%s)\n", run.ID, run.Name)
+	fmt.Printf("Prompt version %s, model %s, %d samples\n\n", run.PromptVersion, run.Model, run.Size)
+
+	metrics := []struct {
+		name    string
+		value   float64
+		compare func(*models.EvaluationRun) float64
+	}{
+		{"exact match", run.ExactMatch, func(r *models.EvaluationRun) float64 { return r.ExactMatch }},
+		{"MAE", run.MAE, func(r *models.EvaluationRun) float64 { return r.MAE }},
+		{"Cohen's kappa", run.Kappa, func(r *models.EvaluationRun) float64 { return r.Kappa }},
+	}
+	for _, m := range metrics {
+		line := fmt.Sprintf("%-14s %6.3f", m.name, m.value)
+		if baseline != nil {
+			line += fmt.Sprintf("   baseline %6.3f (%+.3f)", m.compare(baseline), m.value-m.compare(baseline))
+		}
+		fmt.Println(line)
+	}
+	if baseline != nil {
+		fmt.Printf("\nBaseline %s (%s), prompt version %s, model %s\n", baseline.ID, baseline.Name, baseline.PromptVersion, baseline.Model)
+	}
+
+	fmt.Println("\nConfusion matrix (rows: teacher, columns: model)")
+	header := []string{"     "}
+	for _, label := range run.Labels {
+		header = append(header, fmt.Sprintf("%5d", label))
+	}
+	fmt.Println(strings.Join(header, ""))
+	for i, row := range run.Confusion {
+		cells := []string{fmt.Sprintf("%5d", run.Labels[i])}
+		for _, n := range row {
+			cells = append(cells, fmt.Sprintf("%5d", n))
+		}
+		fmt.Println(strings.Join(cells, ""))
+	}
+}
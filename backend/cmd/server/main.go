@@ -1,12 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"codegrader-backend/internal/config"
 	"codegrader-backend/internal/database"
+	"codegrader-backend/internal/embedding"
+	"codegrader-backend/internal/grading"
 	"codegrader-backend/internal/handlers"
+	"codegrader-backend/internal/lint"
+	"codegrader-backend/internal/prompts"
+	"codegrader-backend/internal/redaction"
 	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/resilience"
 	"codegrader-backend/internal/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -23,10 +34,80 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	embedder, err := embedding.NewEmbedder(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+
+	promptStore, err := prompts.Load(cfg.Prompts.Dir, cfg.Prompts.DefaultLocale)
+	if err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+
+	defaultScale, err := grading.Parse(cfg.Grading.DefaultScale)
+	if err != nil {
+		log.Fatalf("Failed to parse GRADING_SCALE: %v", err)
+	}
+
+	var redactor *redaction.Redactor
+	if cfg.Redaction.Enabled {
+		redactor, err = redaction.Load(cfg.Redaction.PatternsFile)
+		if err != nil {
+			log.Fatalf("Failed to load redaction patterns: %v", err)
+		}
+	}
+
+	var linter *lint.Runner
+	if cfg.Lint.Enabled {
+		linter = lint.New(lint.Builtin(), cfg.Lint.Tools, cfg.Lint.Timeout)
+		log.Printf("Linters available: %v", linter.Names())
+	}
+
 	submissionRepo := repositories.NewSubmissionRepository(db)
-	openaiSvc := services.NewOpenAIService(cfg)
-	submissionSvc := services.NewSubmissionService(submissionRepo, openaiSvc)
+	plagiarismRepo := repositories.NewPlagiarismRepository(db)
+	annotationRepo := repositories.NewAnnotationRepository(db)
+	embeddingRepo := repositories.NewEmbeddingRepository(db)
+	analysisCacheRepo := repositories.NewAnalysisCacheRepository(db)
+	usageRepo := repositories.NewUsageRepository(db)
+	courseRepo := repositories.NewCourseRepository(db)
+	assignmentRepo := repositories.NewAssignmentRepository(db)
+	evaluationRepo := repositories.NewEvaluationRepository(db)
+	styleProfileRepo := repositories.NewStyleProfileRepository(db)
+	practiceRepo := repositories.NewPracticeRepository(db)
+	conversationRepo := repositories.NewConversationRepository(db)
+	usageSvc := services.NewUsageService(usageRepo, courseRepo, cfg.Usage)
+	scaleSvc := services.NewScaleService(assignmentRepo, courseRepo, defaultScale)
+	courseSvc := services.NewCourseService(courseRepo, cfg.Usage)
+	assignmentSvc := services.NewAssignmentService(assignmentRepo, promptStore, defaultScale)
+	openaiGuard := resilience.NewGuard("openai",
+		resilience.RetryPolicy{
+			MaxRetries: cfg.OpenAI.MaxRetries,
+			BaseDelay:  cfg.OpenAI.RetryBaseDelay,
+			MaxDelay:   cfg.OpenAI.RetryMaxDelay,
+		},
+		resilience.NewBreaker(cfg.OpenAI.BreakerThreshold, cfg.OpenAI.BreakerCooldown),
+	)
+	openaiSvc := services.NewOpenAIService(cfg, openaiGuard, usageSvc, promptStore, redactor)
+	analysisSvc := services.NewAnalysisService(openaiSvc, analysisCacheRepo, assignmentRepo, courseRepo, scaleSvc, promptStore, cfg.OpenAI)
+	similaritySvc := services.NewSimilarityService(submissionRepo, embeddingRepo, embedder)
+	plagiarismSvc := services.NewPlagiarismService(submissionRepo, plagiarismRepo, openaiSvc, similaritySvc, scaleSvc, cfg.Plagiarism)
+	aiDetectionSvc := services.NewAIDetectionService(submissionRepo, openaiSvc, cfg.AIDetection)
+	styleSvc := services.NewStyleService(styleProfileRepo, submissionRepo, cfg.Style)
+	submissionSvc := services.NewSubmissionService(submissionRepo, annotationRepo, analysisSvc, usageSvc, plagiarismSvc, similaritySvc, aiDetectionSvc, styleSvc, scaleSvc, redactor, linter, practiceRepo, cfg.Practice, cfg.Redaction.RejectSecrets)
+	gradingQueue := services.NewGradingQueue(submissionRepo, annotationRepo, analysisSvc, usageSvc)
+	calibrationSvc := services.NewCalibrationService(evaluationRepo, analysisSvc, openaiSvc)
+	reportSvc := services.NewReportService(submissionRepo, annotationRepo, plagiarismRepo)
+	conversationSvc := services.NewConversationService(conversationRepo, submissionRepo, annotationRepo, assignmentRepo, openaiSvc, usageSvc, cfg.Conversation)
 	submissionHandler := handlers.NewSubmissionHandler(submissionSvc)
+	plagiarismHandler := handlers.NewPlagiarismHandler(plagiarismSvc)
+	similarityHandler := handlers.NewSimilarityHandler(similaritySvc)
+	metricsHandler := handlers.NewMetricsHandler(openaiGuard)
+	usageHandler := handlers.NewUsageHandler(usageSvc)
+	courseHandler := handlers.NewCourseHandler(courseSvc)
+	assignmentHandler := handlers.NewAssignmentHandler(assignmentSvc)
+	calibrationHandler := handlers.NewCalibrationHandler(calibrationSvc)
+	reportHandler := handlers.NewReportHandler(reportSvc)
+	conversationHandler := handlers.NewConversationHandler(conversationSvc)
 
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -40,30 +121,93 @@ func main() {
 		},
 	})
 
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	app.Use(recover.New())
 	app.Use(logger.New())
+	app.Use(requestContext(baseCtx, cfg.Server.RequestTimeout))
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
 		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
 	}))
 
-	setupRoutes(app, submissionHandler)
+	setupRoutes(app, submissionHandler, plagiarismHandler, similarityHandler, metricsHandler, usageHandler, courseHandler, assignmentHandler, calibrationHandler, reportHandler, conversationHandler)
+
+	go gradingQueue.Run(baseCtx, cfg.OpenAI.RegradeInterval)
+	go calibrationSvc.Run(baseCtx, cfg.Calibration.Interval)
+
+	shutdownCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Server.Port)
+		if err := app.Listen(":" + cfg.Server.Port); err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	<-shutdownCtx.Done()
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", cfg.Server.ShutdownTimeout)
+	if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+	cancelRequests()
+}
+
+// requestContext gives every request a context with a deadline that is also
+// cancelled when the server shuts down, so LLM and database calls made on
+// its behalf stop instead of running on.
+func requestContext(base context.Context, timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(base, timeout)
+		defer cancel()
 
-	log.Printf("Server starting on port %s", cfg.Server.Port)
-	log.Fatal(app.Listen(":" + cfg.Server.Port))
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
 }
 
-func setupRoutes(app *fiber.App, submissionHandler *handlers.SubmissionHandler) {
+func setupRoutes(app *fiber.App, submissionHandler *handlers.SubmissionHandler, plagiarismHandler *handlers.PlagiarismHandler, similarityHandler *handlers.SimilarityHandler, metricsHandler *handlers.MetricsHandler, usageHandler *handlers.UsageHandler, courseHandler *handlers.CourseHandler, assignmentHandler *handlers.AssignmentHandler, calibrationHandler *handlers.CalibrationHandler, reportHandler *handlers.ReportHandler, conversationHandler *handlers.ConversationHandler) {
 	app.Get("/health", submissionHandler.HealthCheck)
+	app.Get("/metrics", metricsHandler.GetMetrics)
 
 	api := app.Group("/api")
 
 	submissions := api.Group("/submissions")
 	submissions.Post("/", submissionHandler.CreateSubmission)
 	submissions.Get("/", submissionHandler.GetSubmissions)
+	submissions.Get("/review", submissionHandler.GetReviewQueue)
 	submissions.Get("/:id", submissionHandler.GetSubmission)
+	submissions.Get("/:id/review", submissionHandler.GetSubmissionReview)
 	submissions.Delete("/:id", submissionHandler.DeleteSubmission)
+	submissions.Get("/:id/annotations", submissionHandler.GetAnnotations)
+	submissions.Get("/:id/report", reportHandler.GetReport)
+	submissions.Get("/:id/plagiarism", plagiarismHandler.GetMatches)
+	submissions.Get("/:id/similar", similarityHandler.GetSimilar)
+	submissions.Post("/:id/conversation", conversationHandler.Ask)
+	submissions.Get("/:id/conversation", conversationHandler.GetThread)
+
+	api.Post("/plagiarism/recheck", plagiarismHandler.Recheck)
+
+	api.Get("/usage", usageHandler.GetUsage)
+
+	courses := api.Group("/courses")
+	courses.Get("/", courseHandler.GetCourses)
+	courses.Get("/:id", courseHandler.GetCourse)
+	courses.Put("/:id", courseHandler.UpdateCourse)
+
+	assignments := api.Group("/assignments")
+	assignments.Get("/:id", assignmentHandler.GetAssignment)
+	assignments.Put("/:id", assignmentHandler.UpdateAssignment)
+	assignments.Get("/:id/references", assignmentHandler.GetReferenceSolutions)
+	assignments.Put("/:id/references", assignmentHandler.UpdateReferenceSolutions)
+
+	calibration := api.Group("/calibration")
+	calibration.Post("/runs", calibrationHandler.CreateRun)
+	calibration.Get("/runs", calibrationHandler.GetRuns)
+	calibration.Get("/runs/:id", calibrationHandler.GetRun)
 
 	api.Post("/submit", submissionHandler.CreateSubmission)
 }
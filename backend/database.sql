@@ -1,13 +1,218 @@
+CREATE EXTENSION IF NOT EXISTS vector;
+
 CREATE TABLE IF NOT EXISTS code_submissions (
     id VARCHAR(64) PRIMARY KEY,
     file_name VARCHAR(255) NOT NULL,
     file_type VARCHAR(16) NOT NULL,
+    course_id VARCHAR(64),
+    assignment_id VARCHAR(64),
+    student_id VARCHAR(64),
     content TEXT NOT NULL,
+    content_hash VARCHAR(64),
+    locale VARCHAR(8),
     grade INTEGER,
+    grade_label VARCHAR(64),
+    passed BOOLEAN NOT NULL DEFAULT FALSE,
     feedback TEXT,
+    grade_confidence DOUBLE PRECISION,
+    grade_samples VARCHAR(64),
+    grading_status VARCHAR(16) NOT NULL DEFAULT 'graded',
+    needs_review BOOLEAN NOT NULL DEFAULT FALSE,
+    review_reasons VARCHAR(255),
+    redactions VARCHAR(512),
+    is_plagiarism BOOLEAN NOT NULL DEFAULT FALSE,
+    plagiarism_involved BOOLEAN NOT NULL DEFAULT FALSE,
+    plagiarism_corpus_size INTEGER NOT NULL DEFAULT 0,
+    metrics TEXT,
+    lint_findings TEXT,
+    ai_detection TEXT,
+    ai_likelihood DOUBLE PRECISION NOT NULL DEFAULT 0,
+    style_deviation TEXT,
+    reference_comparison TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX IF NOT EXISTS idx_code_submissions_created_at ON code_submissions(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_code_submissions_file_type ON code_submissions(file_type);
 CREATE INDEX IF NOT EXISTS idx_code_submissions_grade ON code_submissions(grade);
+CREATE INDEX IF NOT EXISTS idx_code_submissions_course_id ON code_submissions(course_id);
+CREATE INDEX IF NOT EXISTS idx_code_submissions_assignment_id ON code_submissions(assignment_id);
+CREATE INDEX IF NOT EXISTS idx_code_submissions_grading_status ON code_submissions(grading_status);
+CREATE INDEX IF NOT EXISTS idx_code_submissions_needs_review ON code_submissions(needs_review);
+CREATE INDEX IF NOT EXISTS idx_code_submissions_student_id ON code_submissions(student_id);
+CREATE INDEX IF NOT EXISTS idx_code_submissions_content_hash ON code_submissions(content_hash);
+CREATE INDEX IF NOT EXISTS idx_code_submissions_ai_likelihood ON code_submissions(ai_likelihood);
+
+CREATE TABLE IF NOT EXISTS plagiarism_matches (
+    id BIGSERIAL PRIMARY KEY,
+    submission_id VARCHAR(64) NOT NULL,
+    matched_submission_id VARCHAR(64) NOT NULL,
+    token_similarity DOUBLE PRECISION,
+    structural_similarity DOUBLE PRECISION,
+    semantic_similarity DOUBLE PRECISION,
+    cross_language BOOLEAN NOT NULL DEFAULT FALSE,
+    explanation TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_plagiarism_matches_submission_id ON plagiarism_matches(submission_id);
+CREATE INDEX IF NOT EXISTS idx_plagiarism_matches_matched_submission_id ON plagiarism_matches(matched_submission_id);
+
+CREATE TABLE IF NOT EXISTS analysis_cache (
+    content_hash VARCHAR(64) NOT NULL,
+    file_type VARCHAR(16) NOT NULL,
+    assignment_id VARCHAR(64) NOT NULL,
+    prompt_version VARCHAR(32) NOT NULL,
+    model VARCHAR(128) NOT NULL,
+    grade INTEGER NOT NULL,
+    feedback TEXT,
+    annotations TEXT,
+    source_hash VARCHAR(64),
+    review_reasons VARCHAR(255),
+    confidence DOUBLE PRECISION NOT NULL DEFAULT 0,
+    samples VARCHAR(64),
+    comparison TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (content_hash, file_type, assignment_id, prompt_version, model)
+);
+
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id BIGSERIAL PRIMARY KEY,
+    submission_id VARCHAR(64),
+    course_id VARCHAR(64),
+    assignment_id VARCHAR(64),
+    student_id VARCHAR(64),
+    operation VARCHAR(32) NOT NULL,
+    model VARCHAR(128) NOT NULL,
+    prompt_tokens INTEGER,
+    completion_tokens INTEGER,
+    cost_usd DOUBLE PRECISION,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_submission_id ON llm_usage(submission_id);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_course_id ON llm_usage(course_id);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_assignment_id ON llm_usage(assignment_id);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_student_id ON llm_usage(student_id);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage(created_at);
+
+CREATE TABLE IF NOT EXISTS courses (
+    id VARCHAR(64) PRIMARY KEY,
+    name TEXT,
+    monthly_budget_usd DOUBLE PRECISION,
+    quota_action VARCHAR(16) NOT NULL DEFAULT 'queue',
+    locale VARCHAR(8),
+    grading_scale TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS assignments (
+    id VARCHAR(64) PRIMARY KEY,
+    course_id VARCHAR(64),
+    title TEXT,
+    task_statement TEXT,
+    rubric TEXT,
+    analysis_prompt TEXT,
+    grading_scale TEXT,
+    reference_solutions TEXT,
+    version INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignments_course_id ON assignments(course_id);
+
+CREATE TABLE IF NOT EXISTS evaluation_runs (
+    id VARCHAR(64) PRIMARY KEY,
+    name TEXT,
+    status VARCHAR(16) NOT NULL,
+    prompt_version VARCHAR(64),
+    model VARCHAR(128),
+    size BIGINT,
+    exact_match DOUBLE PRECISION,
+    mae DOUBLE PRECISION,
+    kappa DOUBLE PRECISION,
+    labels TEXT,
+    confusion TEXT,
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_runs_status ON evaluation_runs(status);
+
+CREATE TABLE IF NOT EXISTS evaluation_items (
+    id BIGSERIAL PRIMARY KEY,
+    run_id VARCHAR(64) NOT NULL REFERENCES evaluation_runs(id) ON DELETE CASCADE,
+    position BIGINT,
+    file_name TEXT,
+    file_type VARCHAR(16) NOT NULL,
+    assignment_id VARCHAR(64),
+    locale VARCHAR(8),
+    content TEXT NOT NULL,
+    teacher_grade BIGINT,
+    grade BIGINT,
+    graded BOOLEAN NOT NULL DEFAULT FALSE,
+    prompt_version VARCHAR(64),
+    review_reasons VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_items_run_id ON evaluation_items(run_id);
+
+CREATE TABLE IF NOT EXISTS annotations (
+    id BIGSERIAL PRIMARY KEY,
+    submission_id VARCHAR(64) NOT NULL,
+    file VARCHAR(255),
+    start_line BIGINT NOT NULL,
+    end_line BIGINT NOT NULL,
+    severity VARCHAR(16) NOT NULL,
+    category VARCHAR(32) NOT NULL,
+    message TEXT NOT NULL,
+    suggestion TEXT,
+    source VARCHAR(32),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_annotations_submission_id ON annotations(submission_id);
+
+CREATE TABLE IF NOT EXISTS style_profiles (
+    id BIGSERIAL PRIMARY KEY,
+    student_id VARCHAR(64) NOT NULL,
+    file_type VARCHAR(16) NOT NULL,
+    profile TEXT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_style_profiles_student_file_type ON style_profiles(student_id, file_type);
+
+CREATE TABLE IF NOT EXISTS practice_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    course_id VARCHAR(64),
+    assignment_id VARCHAR(64),
+    student_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_practice_attempts_course_id ON practice_attempts(course_id);
+CREATE INDEX IF NOT EXISTS idx_practice_attempts_student_assignment ON practice_attempts(student_id, assignment_id, created_at);
+
+CREATE TABLE IF NOT EXISTS conversation_messages (
+    id BIGSERIAL PRIMARY KEY,
+    submission_id VARCHAR(64) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_submission_id ON conversation_messages(submission_id);
+
+CREATE TABLE IF NOT EXISTS submission_embeddings (
+    submission_id VARCHAR(64) PRIMARY KEY,
+    file_type VARCHAR(16) NOT NULL,
+    model VARCHAR(128) NOT NULL,
+    embedding vector(256) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_submission_embeddings_hnsw ON submission_embeddings USING hnsw (embedding vector_cosine_ops);
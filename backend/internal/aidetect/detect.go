@@ -0,0 +1,284 @@
+// Package aidetect estimates how likely a submission is to be written by an
+// AI assistant. The estimate combines stylometric features of the code, the
+// difference from the student's earlier submissions and, optionally, the
+// judgement of an LLM. None of these is proof: the result is advisory and
+// only meant to point teachers at submissions worth a conversation.
+package aidetect
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"codegrader-backend/internal/metrics"
+)
+
+// MinPrior is the number of earlier submissions needed to compare a
+// student's style with.
+const MinPrior = 2
+
+// minCodeLines is the size below which the style says too little and the
+// likelihood is capped at one half.
+const minCodeLines = 10
+
+// Signal names.
+const (
+	SignalComments      = "comments"
+	SignalIdentifiers   = "identifiers"
+	SignalNaming        = "naming"
+	SignalDocumentation = "documentation"
+	SignalFormatting    = "formatting"
+	SignalSpacing       = "spacing"
+	SignalHistory       = "history"
+	SignalLLM           = "llm"
+)
+
+// Signal is one piece of evidence. Value is how much it points to generated
+// code, from 0 to 1; Detail describes it in the result's language.
+type Signal struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+	Detail string  `json:"detail"`
+}
+
+// Judgement is an LLM's estimate for the same code.
+type Judgement struct {
+	Likelihood  float64 `json:"likelihood"`
+	Explanation string  `json:"explanation"`
+}
+
+// Result is the estimate stored with a submission. Advisory is always true
+// and travels with the data so that no client mistakes the likelihood for a
+// verdict.
+type Result struct {
+	Likelihood  float64    `json:"likelihood"`
+	Advisory    bool       `json:"advisory"`
+	Explanation string     `json:"explanation"`
+	Signals     []Signal   `json:"signals"`
+	Features    Features   `json:"features"`
+	Prior       int        `json:"prior_submissions"`
+	LLM         *Judgement `json:"llm,omitempty"`
+}
+
+// Input is what Detect works on. Prior holds the features of the student's
+// earlier submissions, newest first; LLM is nil when no model was asked.
+type Input struct {
+	Code     string
+	FileType string
+	Locale   string
+	Metrics  *metrics.Metrics
+	Prior    []Features
+	LLM      *Judgement
+}
+
+// Detect estimates the likelihood that input.Code was generated.
+func Detect(input Input) *Result {
+	m := input.Metrics
+	if m == nil {
+		m = metrics.Compute(input.Code, input.FileType)
+	}
+	features := Extract(input.Code, input.FileType, m)
+	text := texts[input.Locale]
+	if text == nil {
+		text = texts["ru"]
+	}
+
+	signals := styleSignals(features, text)
+	style := weighted(signals)
+
+	result := &Result{Advisory: true, Features: features, Prior: len(input.Prior), Likelihood: style}
+	if len(input.Prior) >= MinPrior {
+		history := historySignal(features, input.Prior, text)
+		signals = append(signals, history)
+		result.Likelihood = 0.6*style + 0.4*history.Value
+	}
+	if input.LLM != nil {
+		llm := clamp(input.LLM.Likelihood)
+		signals = append(signals, Signal{
+			Name: SignalLLM, Value: llm, Weight: 0.5,
+			Detail: fmt.Sprintf(text[SignalLLM], percent(llm), strings.TrimSpace(input.LLM.Explanation)),
+		})
+		result.Likelihood = (result.Likelihood + llm) / 2
+		result.LLM = input.LLM
+	}
+	if m.CodeLines < minCodeLines {
+		result.Likelihood = math.Min(result.Likelihood, 0.5)
+	}
+	result.Likelihood = math.Round(result.Likelihood*100) / 100
+	result.Signals = signals
+	result.Explanation = explain(result, m.CodeLines < minCodeLines, text)
+	return result
+}
+
+// styleSignals turns features into evidence. Generated code tends to have
+// explanatory comments, documented functions, long descriptive names and
+// uniform formatting; the scales below are where that shows.
+func styleSignals(f Features, text map[string]string) []Signal {
+	signals := []Signal{
+		{Name: SignalComments, Weight: 0.2, Value: clamp(f.CommentDensity / 0.25),
+			Detail: fmt.Sprintf(text[SignalComments], percent(f.CommentDensity))},
+		{Name: SignalIdentifiers, Weight: 0.2, Value: clamp((f.IdentifierLength - 2) / 8),
+			Detail: fmt.Sprintf(text[SignalIdentifiers], f.IdentifierLength)},
+	}
+	optional := []struct {
+		name   string
+		weight float64
+		value  float64
+		scaled float64
+	}{
+		{SignalNaming, 0.1, f.NamingConsistency, f.NamingConsistency},
+		{SignalDocumentation, 0.2, f.DocumentedFunctions, f.DocumentedFunctions},
+		{SignalFormatting, 0.1, f.FormattingRegularity, clamp((f.FormattingRegularity - 0.8) / 0.2)},
+		{SignalSpacing, 0.2, f.OperatorSpacing, f.OperatorSpacing},
+	}
+	for _, o := range optional {
+		if o.value == unknown {
+			continue
+		}
+		signals = append(signals, Signal{
+			Name: o.name, Weight: o.weight, Value: clamp(o.scaled),
+			Detail: fmt.Sprintf(text[o.name], percent(o.value)),
+		})
+	}
+	return signals
+}
+
+// historySignal compares f with the average of the student's earlier
+// submissions. Only a change towards the style of generated code counts
+// fully: students do get better, but rarely all at once.
+func historySignal(f Features, prior []Features, text map[string]string) Signal {
+	var average Features
+	counts := make([]int, 6)
+	for _, p := range prior {
+		for i, v := range vector(p) {
+			if v != unknown {
+				*field(&average, i) += v
+				counts[i]++
+			}
+		}
+	}
+	for i, n := range counts {
+		if n > 0 {
+			*field(&average, i) /= float64(n)
+		} else {
+			*field(&average, i) = unknown
+		}
+	}
+
+	current, previous := vector(f), vector(average)
+	deviation, compared := 0.0, 0
+	for i := range current {
+		if current[i] == unknown || previous[i] == unknown {
+			continue
+		}
+		a, b := current[i], previous[i]
+		if i == 1 {
+			// Identifier length is not a share; compare it on the same scale.
+			a, b = clamp((a-2)/8), clamp((b-2)/8)
+		}
+		deviation += math.Abs(a - b)
+		compared++
+	}
+	if compared > 0 {
+		deviation /= float64(compared)
+	}
+
+	value := clamp(deviation / 0.3)
+	if weighted(styleSignals(f, text)) <= weighted(styleSignals(average, text)) {
+		value *= 0.3
+	}
+	return Signal{
+		Name: SignalHistory, Value: value, Weight: 0.4,
+		Detail: fmt.Sprintf(text[SignalHistory], len(prior), percent(deviation)),
+	}
+}
+
+func vector(f Features) []float64 {
+	return []float64{f.CommentDensity, f.IdentifierLength, f.NamingConsistency, f.DocumentedFunctions, f.FormattingRegularity, f.OperatorSpacing}
+}
+
+func field(f *Features, i int) *float64 {
+	return []*float64{&f.CommentDensity, &f.IdentifierLength, &f.NamingConsistency, &f.DocumentedFunctions, &f.FormattingRegularity, &f.OperatorSpacing}[i]
+}
+
+func weighted(signals []Signal) float64 {
+	sum, weights := 0.0, 0.0
+	for _, s := range signals {
+		sum += s.Value * s.Weight
+		weights += s.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
+
+// explain lists the strongest signals, most telling first.
+func explain(r *Result, short bool, text map[string]string) string {
+	signals := append([]Signal(nil), r.Signals...)
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].Value > signals[j].Value })
+
+	var details []string
+	for _, s := range signals {
+		if s.Value >= 0.6 || s.Name == SignalLLM || s.Name == SignalHistory {
+			details = append(details, s.Detail)
+		}
+	}
+
+	parts := []string{fmt.Sprintf(text["header"], percent(r.Likelihood))}
+	if len(details) > 0 {
+		parts = append(parts, fmt.Sprintf(text["signals"], strings.Join(details, "; ")))
+	} else {
+		parts = append(parts, text["none"])
+	}
+	if short {
+		parts = append(parts, text["short"])
+	}
+	if r.Prior < MinPrior {
+		parts = append(parts, text["no_history"])
+	}
+	return strings.Join(parts, " ")
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func percent(v float64) int {
+	return int(math.Round(v * 100))
+}
+
+var texts = map[string]map[string]string{
+	"ru": {
+		"header":            "Рекомендательная оценка, не доказательство: вероятность генерации ИИ %d%%.",
+		"signals":           "Признаки: %s.",
+		"none":              "Выраженных признаков нет.",
+		"short":             "Решение слишком короткое для уверенной оценки.",
+		"no_history":        "Предыдущих решений студента для сравнения недостаточно.",
+		SignalComments:      "комментарии занимают %d%% строк",
+		SignalIdentifiers:   "средняя длина имён %.1f символа",
+		SignalNaming:        "единый стиль имён у %d%% составных имён",
+		SignalDocumentation: "документировано %d%% функций",
+		SignalFormatting:    "единообразно отформатировано %d%% строк",
+		SignalSpacing:       "пробелы вокруг %d%% операторов",
+		SignalHistory:       "стиль отличается от %d предыдущих решений студента на %d%%",
+		SignalLLM:           "оценка модели %d%%: %s",
+	},
+	"en": {
+		"header":            "Advisory estimate, not proof: AI generation likelihood %d%%.",
+		"signals":           "Signals: %s.",
+		"none":              "No pronounced signals.",
+		"short":             "The solution is too short for a confident estimate.",
+		"no_history":        "Not enough earlier submissions of the student to compare with.",
+		SignalComments:      "comments take %d%% of the lines",
+		SignalIdentifiers:   "average name length %.1f characters",
+		SignalNaming:        "%d%% of compound names follow one convention",
+		SignalDocumentation: "%d%% of functions are documented",
+		SignalFormatting:    "%d%% of lines are formatted uniformly",
+		SignalSpacing:       "spaces around %d%% of operators",
+		SignalHistory:       "the style differs from the student's %d earlier submissions by %d%%",
+		SignalLLM:           "model estimate %d%%: %s",
+	},
+}
@@ -0,0 +1,119 @@
+package aidetect
+
+import (
+	"testing"
+
+	"codegrader-backend/internal/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const assistantStyle = `def calculate_average_score(student_scores):
+    """Return the average of the given scores, or zero for an empty list."""
+    if not student_scores:
+        return 0
+    # Sum all scores and divide by their count
+    total_score = sum(student_scores)
+    return total_score / len(student_scores)
+
+
+def find_maximum_score(student_scores):
+    """Return the highest score in the list."""
+    maximum_score = student_scores[0]
+    for current_score in student_scores:
+        # Keep track of the largest value seen so far
+        if current_score > maximum_score:
+            maximum_score = current_score
+    return maximum_score
+
+
+def main():
+    """Read scores from input and print the statistics."""
+    raw_input_values = input().split()
+    student_scores = [int(value) for value in raw_input_values]
+    print(calculate_average_score(student_scores))
+    print(find_maximum_score(student_scores))
+`
+
+const studentStyle = `def avg(a):
+  s=0
+  for x in a:
+      s+=x
+  return s/len(a)
+
+def mx(a):
+  m=a[0]
+  for x in a:
+    if x>m: m=x
+  return m
+
+a=list(map(int,input().split()))
+print(avg(a))
+print(mx(a))
+`
+
+func TestDetect_StyleOfGeneratedCodeScoresHigher(t *testing.T) {
+	generated := Detect(Input{Code: assistantStyle, FileType: ".py", Locale: "ru"})
+	written := Detect(Input{Code: studentStyle, FileType: ".py", Locale: "ru"})
+
+	assert.True(t, generated.Advisory)
+	assert.Greater(t, generated.Likelihood, 0.7)
+	assert.Less(t, written.Likelihood, 0.4)
+	assert.Equal(t, 1.0, generated.Features.DocumentedFunctions)
+	assert.Equal(t, 0.0, written.Features.DocumentedFunctions)
+	assert.Contains(t, generated.Explanation, "Рекомендательная оценка, не доказательство")
+	assert.Contains(t, generated.Explanation, "документировано 100% функций")
+	assert.Contains(t, written.Explanation, "Предыдущих решений студента для сравнения недостаточно.")
+}
+
+func TestDetect_ComparesWithPriorSubmissions(t *testing.T) {
+	own := Extract(studentStyle, ".py", metrics.Compute(studentStyle, ".py"))
+	prior := []Features{own, own}
+
+	changed := Detect(Input{Code: assistantStyle, FileType: ".py", Locale: "en", Prior: prior})
+	usual := Detect(Input{Code: studentStyle, FileType: ".py", Locale: "en", Prior: prior})
+
+	require.Equal(t, SignalHistory, changed.Signals[len(changed.Signals)-1].Name)
+	assert.Equal(t, 1.0, changed.Signals[len(changed.Signals)-1].Value)
+	assert.Equal(t, 0.0, usual.Signals[len(usual.Signals)-1].Value)
+	assert.Greater(t, changed.Likelihood, usual.Likelihood)
+	assert.Contains(t, changed.Explanation, "differs from the student's 2 earlier submissions")
+	assert.Equal(t, 2, changed.Prior)
+}
+
+func TestDetect_AveragesLLMJudgement(t *testing.T) {
+	without := Detect(Input{Code: studentStyle, FileType: ".py"})
+	with := Detect(Input{Code: studentStyle, FileType: ".py", LLM: &Judgement{Likelihood: 0.9, Explanation: "слишком аккуратно"}})
+
+	assert.InDelta(t, (without.Likelihood+0.9)/2, with.Likelihood, 0.01)
+	assert.Contains(t, with.Explanation, "оценка модели 90%: слишком аккуратно")
+}
+
+func TestDetect_ShortCodeIsCapped(t *testing.T) {
+	code := "def add_two_numbers(first_number, second_number):\n    \"\"\"Return the sum.\"\"\"\n    return first_number + second_number\n"
+
+	result := Detect(Input{Code: code, FileType: ".py"})
+
+	assert.LessOrEqual(t, result.Likelihood, 0.5)
+	assert.Contains(t, result.Explanation, "слишком короткое")
+}
+
+func TestExtract_OperatorSpacingAndNaming(t *testing.T) {
+	code := "int totalSum = a+b;\nint maxValue = x == y ? 1 : 2;\nint min_value = 0;\nList<Integer> itemList = f(a<b);\n"
+
+	f := Extract(code, ".java", metrics.Compute(code, ".java"))
+
+	// "=" four times and "==" are spaced, "+" is not.
+	assert.InDelta(t, 5.0/6, f.OperatorSpacing, 1e-9)
+	assert.InDelta(t, 3.0/4, f.NamingConsistency, 1e-9)
+	assert.Equal(t, float64(unknown), f.DocumentedFunctions)
+}
+
+func TestFormattingRegularity_FollowsIndentationStep(t *testing.T) {
+	// "e" goes in by six instead of two, "g " ends with a space and "h" goes
+	// in by three.
+	lines := []string{"a", "  b", "    c", "  d", "        e", "  f", "g ", "   h"}
+
+	assert.InDelta(t, 5.0/8, formattingRegularity(lines), 1e-9)
+}
@@ -0,0 +1,253 @@
+package aidetect
+
+import (
+	"strings"
+	"unicode"
+
+	"codegrader-backend/internal/metrics"
+	"codegrader-backend/internal/similarity"
+)
+
+// Features are the stylometric measurements of one file. All of them are
+// shares between 0 and 1 except IdentifierLength; a share is -1 when the
+// file has nothing to measure it on, for example no functions.
+type Features struct {
+	CommentDensity       float64 `json:"comment_density"`
+	IdentifierLength     float64 `json:"identifier_length"`
+	NamingConsistency    float64 `json:"naming_consistency"`
+	DocumentedFunctions  float64 `json:"documented_functions"`
+	FormattingRegularity float64 `json:"formatting_regularity"`
+	OperatorSpacing      float64 `json:"operator_spacing"`
+}
+
+// unknown marks a feature that could not be measured.
+const unknown = -1
+
+var keywords = set(
+	"and", "as", "assert", "async", "await", "auto", "bool", "boolean", "break", "byte", "case", "catch", "char",
+	"class", "const", "continue", "def", "default", "del", "delete", "do", "double", "elif", "else", "enum",
+	"except", "export", "extends", "false", "False", "final", "finally", "float", "for", "from", "fun",
+	"function", "global", "if", "implements", "import", "in", "include", "int", "interface", "is", "lambda",
+	"let", "long", "namespace", "new", "None", "nonlocal", "not", "null", "object", "or", "override",
+	"package", "pass", "private", "protected", "public", "raise", "return", "self", "short", "signed", "sizeof",
+	"static", "std", "string", "struct", "super", "switch", "this", "throw", "throws", "true", "True", "try",
+	"typedef", "typeof", "unsigned", "using", "val", "var", "void", "when", "while", "with", "yield",
+)
+
+// binaryOperators are checked for surrounding spaces. Operators that also
+// appear in other roles, such as "*" in pointers, "-" in negative numbers or
+// "<" in generics, are left out.
+var binaryOperators = set("=", "==", "!=", "<=", ">=", "+=", "-=", "*=", "/=", "%=", "+", "/", "%", "&&", "||", "===", "!==")
+
+const operatorChars = "=!<>+-*/%&|"
+
+// Extract measures code written in the language of fileType. m are the
+// metrics of the same code.
+func Extract(code, fileType string, m *metrics.Metrics) Features {
+	tokens := similarity.Scan(code, fileType)
+	lines := strings.Split(code, "\n")
+
+	f := Features{
+		CommentDensity:       m.CommentRatio,
+		IdentifierLength:     identifierLength(tokens),
+		NamingConsistency:    namingConsistency(tokens),
+		DocumentedFunctions:  documentedFunctions(lines, tokens, m.Functions),
+		FormattingRegularity: formattingRegularity(lines),
+		OperatorSpacing:      operatorSpacing(tokens),
+	}
+	return f
+}
+
+func identifiers(tokens []similarity.Token) map[string]bool {
+	names := make(map[string]bool)
+	for _, t := range tokens {
+		if isIdentifier(t.Text) && !keywords[t.Text] {
+			names[t.Text] = true
+		}
+	}
+	return names
+}
+
+func identifierLength(tokens []similarity.Token) float64 {
+	names := identifiers(tokens)
+	if len(names) == 0 {
+		return 0
+	}
+	total := 0
+	for name := range names {
+		total += len([]rune(name))
+	}
+	return float64(total) / float64(len(names))
+}
+
+// namingConsistency is the share of multi-word variable and function names
+// written in the more common of snake_case and camelCase.
+func namingConsistency(tokens []similarity.Token) float64 {
+	snake, camel := 0, 0
+	for name := range identifiers(tokens) {
+		trimmed := strings.Trim(name, "_")
+		switch {
+		case trimmed == "" || strings.ToUpper(trimmed) == trimmed || unicode.IsUpper([]rune(trimmed)[0]):
+			// Constants and type names follow conventions of their own.
+		case strings.Contains(trimmed, "_"):
+			snake++
+		case strings.ToLower(trimmed) != trimmed:
+			camel++
+		}
+	}
+	if snake+camel < 3 {
+		return unknown
+	}
+	return float64(max(snake, camel)) / float64(snake+camel)
+}
+
+// documentedFunctions is the share of functions with a comment on the line
+// before them or a docstring on their first body line.
+func documentedFunctions(lines []string, tokens []similarity.Token, functions []metrics.Function) float64 {
+	if len(functions) == 0 {
+		return unknown
+	}
+	codeLines := make(map[int]bool)
+	docstrings := make(map[int]bool)
+	for i, t := range tokens {
+		codeLines[t.Line] = true
+		if isString(t.Text) && (i == 0 || tokens[i-1].Line < t.Line) {
+			docstrings[t.Line] = true
+		}
+	}
+	comment := func(line int) bool {
+		return line >= 1 && line <= len(lines) && !codeLines[line] && strings.TrimSpace(lines[line-1]) != ""
+	}
+
+	documented := 0
+	for _, fn := range functions {
+		start := fn.StartLine
+		// Annotations and decorators stand between a function and its comment.
+		for start > 1 && strings.HasPrefix(strings.TrimSpace(lines[start-2]), "@") {
+			start--
+		}
+		if comment(start-1) || docstrings[fn.StartLine+1] || comment(fn.StartLine+1) {
+			documented++
+		}
+	}
+	return float64(documented) / float64(len(functions))
+}
+
+// formattingRegularity is the share of non-blank lines free of trailing
+// whitespace whose indentation is regular: a block goes in by the most
+// common indentation step and comes back out to a level used before.
+func formattingRegularity(lines []string) float64 {
+	type line struct {
+		indent   int
+		trailing bool
+	}
+	var measured []line
+	increases := make(map[int]int)
+	previous := 0
+	for _, text := range lines {
+		text = strings.TrimRight(text, "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " \t"))
+		indent += 3 * strings.Count(text[:indent], "\t")
+		measured = append(measured, line{indent: indent, trailing: strings.TrimRight(text, " \t") != text})
+		if indent > previous {
+			increases[indent-previous]++
+		}
+		previous = indent
+	}
+	if len(measured) == 0 {
+		return unknown
+	}
+
+	step, best := 0, 0
+	for increase, n := range increases {
+		if n > best || n == best && increase < step {
+			step, best = increase, n
+		}
+	}
+	regular := 0
+	levels := []int{0}
+	for _, l := range measured {
+		top := levels[len(levels)-1]
+		ok := !l.trailing
+		switch {
+		case l.indent > top:
+			ok = ok && l.indent-top == step
+			levels = append(levels, l.indent)
+		case l.indent < top:
+			for len(levels) > 1 && levels[len(levels)-1] > l.indent {
+				levels = levels[:len(levels)-1]
+			}
+			if levels[len(levels)-1] != l.indent {
+				ok = false
+				levels = append(levels, l.indent)
+			}
+		}
+		if ok {
+			regular++
+		}
+	}
+	return float64(regular) / float64(len(measured))
+}
+
+// operatorSpacing is the share of binary operators with a space on both
+// sides. The scanner splits operators into characters, so adjacent ones
+// are joined first.
+func operatorSpacing(tokens []similarity.Token) float64 {
+	spaced, total := 0, 0
+	for i := 1; i < len(tokens); i++ {
+		if !strings.Contains(operatorChars, tokens[i].Text) {
+			continue
+		}
+		op := tokens[i]
+		j := i + 1
+		for j < len(tokens) && strings.Contains(operatorChars, tokens[j].Text) &&
+			tokens[j].Line == op.Line && tokens[j].Column == op.Column+len(op.Text) {
+			op.Text += tokens[j].Text
+			j++
+		}
+		prev := tokens[i-1]
+		i = j - 1
+		if !binaryOperators[op.Text] || j == len(tokens) {
+			continue
+		}
+		next := tokens[j]
+		if prev.Line != op.Line || next.Line != op.Line || strings.Contains(prev.Text, "\n") {
+			continue
+		}
+		total++
+		if prev.Column+len(prev.Text) < op.Column && op.Column+len(op.Text) < next.Column {
+			spaced++
+		}
+	}
+	if total == 0 {
+		return unknown
+	}
+	return float64(spaced) / float64(total)
+}
+
+func isIdentifier(token string) bool {
+	if token == "" {
+		return false
+	}
+	for i, r := range token {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isString(text string) bool {
+	return strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'")
+}
+
+func set(items ...string) map[string]bool {
+	m := make(map[string]bool, len(items))
+	for _, item := range items {
+		m[item] = true
+	}
+	return m
+}
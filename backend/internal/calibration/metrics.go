@@ -0,0 +1,86 @@
+// Package calibration measures how well automatic grades agree with the
+// grades teachers gave to the same submissions.
+package calibration
+
+import "sort"
+
+// Metrics summarises agreement between teacher and model grades.
+// Confusion[i][j] counts submissions graded Labels[i] by the teacher and
+// Labels[j] by the model.
+type Metrics struct {
+	Count      int     `json:"count"`
+	ExactMatch float64 `json:"exact_match"`
+	MAE        float64 `json:"mae"`
+	Kappa      float64 `json:"kappa"`
+	Labels     []int   `json:"labels"`
+	Confusion  [][]int `json:"confusion"`
+}
+
+// Agreement compares expected (teacher) and actual (model) grades pairwise;
+// both slices must have the same length.
+func Agreement(expected, actual []int) Metrics {
+	m := Metrics{Count: len(expected)}
+	if m.Count == 0 {
+		return m
+	}
+
+	seen := make(map[int]bool)
+	for i := range expected {
+		seen[expected[i]] = true
+		seen[actual[i]] = true
+	}
+	for label := range seen {
+		m.Labels = append(m.Labels, label)
+	}
+	sort.Ints(m.Labels)
+	index := make(map[int]int, len(m.Labels))
+	for i, label := range m.Labels {
+		index[label] = i
+	}
+
+	m.Confusion = make([][]int, len(m.Labels))
+	for i := range m.Confusion {
+		m.Confusion[i] = make([]int, len(m.Labels))
+	}
+
+	matches, absErr := 0, 0
+	for i := range expected {
+		m.Confusion[index[expected[i]]][index[actual[i]]]++
+		if expected[i] == actual[i] {
+			matches++
+		}
+		absErr += abs(expected[i] - actual[i])
+	}
+
+	n := float64(m.Count)
+	m.ExactMatch = float64(matches) / n
+	m.MAE = float64(absErr) / n
+	m.Kappa = cohensKappa(m.Confusion, n, m.ExactMatch)
+	return m
+}
+
+// cohensKappa corrects observed agreement for the agreement expected by
+// chance given how often each side uses each grade.
+func cohensKappa(confusion [][]int, n, observed float64) float64 {
+	chance := 0.0
+	for i := range confusion {
+		row, col := 0, 0
+		for j := range confusion {
+			row += confusion[i][j]
+			col += confusion[j][i]
+		}
+		chance += float64(row) / n * float64(col) / n
+	}
+	if chance == 1 {
+		// Both sides always gave the same single grade.
+		return 1
+	}
+	return (observed - chance) / (1 - chance)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,44 @@
+package calibration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgreement_PerfectMatch(t *testing.T) {
+	m := Agreement([]int{3, 4, 5, 5}, []int{3, 4, 5, 5})
+
+	assert.Equal(t, 4, m.Count)
+	assert.Equal(t, 1.0, m.ExactMatch)
+	assert.Equal(t, 0.0, m.MAE)
+	assert.InDelta(t, 1.0, m.Kappa, 1e-9)
+	assert.Equal(t, []int{3, 4, 5}, m.Labels)
+	assert.Equal(t, [][]int{{1, 0, 0}, {0, 1, 0}, {0, 0, 2}}, m.Confusion)
+}
+
+func TestAgreement_ComputesKappaAndMAE(t *testing.T) {
+	// Teacher: 3 3 4 4 5 5, model: 3 4 4 5 5 5.
+	m := Agreement([]int{3, 3, 4, 4, 5, 5}, []int{3, 4, 4, 5, 5, 5})
+
+	assert.InDelta(t, 4.0/6, m.ExactMatch, 1e-9)
+	assert.InDelta(t, 2.0/6, m.MAE, 1e-9)
+	// Chance agreement: (2*1 + 2*2 + 2*3) / 36 = 1/3.
+	assert.InDelta(t, (4.0/6-1.0/3)/(1-1.0/3), m.Kappa, 1e-9)
+	assert.Equal(t, [][]int{{1, 1, 0}, {0, 1, 1}, {0, 0, 2}}, m.Confusion)
+}
+
+func TestAgreement_ConstantGradesNoAgreement(t *testing.T) {
+	m := Agreement([]int{5, 5, 5}, []int{3, 3, 3})
+
+	assert.Equal(t, 0.0, m.ExactMatch)
+	assert.Equal(t, 2.0, m.MAE)
+	assert.Equal(t, 0.0, m.Kappa)
+}
+
+func TestAgreement_Empty(t *testing.T) {
+	m := Agreement(nil, nil)
+
+	assert.Equal(t, 0, m.Count)
+	assert.Nil(t, m.Confusion)
+}
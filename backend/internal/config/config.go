@@ -1,45 +1,273 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	OpenAI   OpenAIConfig
-	Server   ServerConfig
+	Database     DatabaseConfig
+	OpenAI       OpenAIConfig
+	Server       ServerConfig
+	Embedding    EmbeddingConfig
+	Plagiarism   PlagiarismConfig
+	Usage        UsageConfig
+	Prompts      PromptsConfig
+	Redaction    RedactionConfig
+	Calibration  CalibrationConfig
+	Grading      GradingConfig
+	Lint         LintConfig
+	AIDetection  AIDetectionConfig
+	Style        StyleConfig
+	Practice     PracticeConfig
+	Conversation ConversationConfig
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
+	Host         string
+	Port         string
+	User         string
+	Password     string
+	Name         string
+	QueryTimeout time.Duration
 }
 
+// OpenAIConfig configures the grading model. ChunkTokens bounds a whole
+// analysis request, prompt and code together, as estimated by package
+// tokens; a larger request is split into chunks.
 type OpenAIConfig struct {
-	APIKey string
+	APIKey           string
+	Timeout          time.Duration
+	MaxRetries       int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	RegradeInterval  time.Duration
+	CacheResults     bool
+	MaxInputTokens   int
+	ChunkTokens      int
+	MaxOutputTokens  int
+	Ensemble         EnsembleConfig
+}
+
+// EnsembleConfig enables self-consistency grading: Size analyses per
+// submission, spread round-robin over Models, combined by Aggregation
+// ("median" or "majority"). Results where fewer than MinConfidence of the
+// samples agree with the final grade go to manual review. Failed analyses
+// are dropped while at least MinSamples succeed (0 means a majority of
+// Size). All models are called through the same OpenAI API and key; other
+// providers are not supported.
+type EnsembleConfig struct {
+	Size          int
+	Models        []string
+	Aggregation   string
+	MinConfidence float64
+	MinSamples    int
 }
 
 type ServerConfig struct {
-	Port string
+	Port            string
+	RequestTimeout  time.Duration
+	ShutdownTimeout time.Duration
+}
+
+type EmbeddingConfig struct {
+	Provider   string
+	Model      string
+	BaseURL    string
+	APIKey     string
+	Dimensions int
+	Timeout    time.Duration
+}
+
+type PlagiarismConfig struct {
+	ANNCandidates           int
+	TopK                    int
+	MinSimilarity           float64
+	CrossLanguage           bool
+	CrossLanguageThreshold  float64
+	CrossLanguageCandidates int
+}
+
+// UsageConfig sets the defaults for courses without their own settings.
+// Prices are per million tokens; zero means the built-in price of the model.
+type UsageConfig struct {
+	DefaultMonthlyBudget float64
+	DefaultQuotaAction   string
+	PromptPricePer1M     float64
+	CompletionPricePer1M float64
+}
+
+// PromptsConfig points at a directory with prompt templates that replace
+// the built-in ones (<locale>/<name>.tmpl).
+type PromptsConfig struct {
+	Dir           string
+	DefaultLocale string
+}
+
+// RedactionConfig controls masking of credentials and personal data before
+// code is sent to the LLM provider. PatternsFile is a JSON array of extra
+// rules; RejectSecrets refuses submissions with live-looking credentials.
+type RedactionConfig struct {
+	Enabled       bool
+	PatternsFile  string
+	RejectSecrets bool
+}
+
+// CalibrationConfig sets how often the server looks for evaluation runs
+// queued through the API.
+type CalibrationConfig struct {
+	Interval time.Duration
+}
+
+// GradingConfig holds the scale for courses and assignments without their
+// own: a preset name or a JSON scale definition.
+type GradingConfig struct {
+	DefaultScale string
+}
+
+// LintConfig controls the external style checkers. Only installed tools
+// run; Tools restricts them further by name, Timeout limits every run.
+type LintConfig struct {
+	Enabled bool
+	Tools   []string
+	Timeout time.Duration
+}
+
+// AIDetectionConfig controls the advisory estimate of AI-generated code.
+// UseLLM adds the model's judgement to the local signals; submissions at or
+// above ReviewThreshold appear in the review queue. History is the number of
+// the student's earlier submissions the style is compared with.
+type AIDetectionConfig struct {
+	Enabled         bool
+	UseLLM          bool
+	ReviewThreshold float64
+	History         int
+}
+
+// StyleConfig controls the per-student style profiles. History is the
+// number of earlier submissions a profile is built from when a student has
+// none yet.
+type StyleConfig struct {
+	Enabled bool
+	History int
+}
+
+// PracticeConfig controls practice submissions, which get hints instead of
+// a grade. A student may send Limit of them per assignment within Window.
+type PracticeConfig struct {
+	Enabled bool
+	Limit   int
+	Window  time.Duration
+}
+
+// ConversationConfig controls follow-up questions about feedback.
+// MaxQuestions limits the questions per submission.
+type ConversationConfig struct {
+	Enabled      bool
+	MaxQuestions int
 }
 
 func LoadConfig() *Config {
 	return &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			Name:     getEnv("DB_NAME", "codegrader"),
+			Host:         getEnv("DB_HOST", "localhost"),
+			Port:         getEnv("DB_PORT", "5432"),
+			User:         getEnv("DB_USER", "postgres"),
+			Password:     getEnv("DB_PASSWORD", "postgres"),
+			Name:         getEnv("DB_NAME", "codegrader"),
+			QueryTimeout: getEnvDuration("DB_QUERY_TIMEOUT", 10*time.Second),
 		},
 		OpenAI: OpenAIConfig{
-			APIKey: getEnv("OPENAI_API_KEY", ""),
+			APIKey:           getEnv("OPENAI_API_KEY", ""),
+			Timeout:          getEnvDuration("OPENAI_TIMEOUT", 60*time.Second),
+			MaxRetries:       getEnvInt("OPENAI_MAX_RETRIES", 3),
+			RetryBaseDelay:   getEnvDuration("OPENAI_RETRY_BASE_DELAY", time.Second),
+			RetryMaxDelay:    getEnvDuration("OPENAI_RETRY_MAX_DELAY", 30*time.Second),
+			BreakerThreshold: getEnvInt("OPENAI_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getEnvDuration("OPENAI_BREAKER_COOLDOWN", time.Minute),
+			RegradeInterval:  getEnvDuration("OPENAI_REGRADE_INTERVAL", 2*time.Minute),
+			CacheResults:     getEnvBool("OPENAI_CACHE_RESULTS", true),
+			MaxInputTokens:   getEnvInt("OPENAI_MAX_INPUT_TOKENS", 32000),
+			ChunkTokens:      getEnvInt("OPENAI_CHUNK_TOKENS", 6000),
+			MaxOutputTokens:  getEnvInt("OPENAI_MAX_OUTPUT_TOKENS", 800),
+			Ensemble: EnsembleConfig{
+				Size:          getEnvInt("OPENAI_ENSEMBLE_SIZE", 1),
+				Models:        getEnvList("OPENAI_ENSEMBLE_MODELS"),
+				Aggregation:   getEnv("OPENAI_ENSEMBLE_AGGREGATION", "median"),
+				MinConfidence: getEnvFloat("OPENAI_ENSEMBLE_MIN_CONFIDENCE", 0.6),
+				MinSamples:    getEnvInt("OPENAI_ENSEMBLE_MIN_SAMPLES", 0),
+			},
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:            getEnv("SERVER_PORT", "8080"),
+			RequestTimeout:  getEnvDuration("REQUEST_TIMEOUT", 3*time.Minute),
+			ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		},
+		Embedding: EmbeddingConfig{
+			Provider:   getEnv("EMBEDDING_PROVIDER", "hash"),
+			Model:      getEnv("EMBEDDING_MODEL", "nomic-embed-text"),
+			BaseURL:    getEnv("EMBEDDING_BASE_URL", "http://localhost:11434/v1"),
+			APIKey:     getEnv("EMBEDDING_API_KEY", ""),
+			Dimensions: getEnvInt("EMBEDDING_DIMENSIONS", 256),
+			Timeout:    getEnvDuration("EMBEDDING_TIMEOUT", 15*time.Second),
+		},
+		Plagiarism: PlagiarismConfig{
+			ANNCandidates:           getEnvInt("PLAGIARISM_ANN_CANDIDATES", 50),
+			TopK:                    getEnvInt("PLAGIARISM_TOP_K", 3),
+			MinSimilarity:           getEnvFloat("PLAGIARISM_MIN_SIMILARITY", 0.5),
+			CrossLanguage:           getEnvBool("PLAGIARISM_CROSS_LANGUAGE", false),
+			CrossLanguageThreshold:  getEnvFloat("PLAGIARISM_CROSS_LANGUAGE_THRESHOLD", 0.6),
+			CrossLanguageCandidates: getEnvInt("PLAGIARISM_CROSS_LANGUAGE_CANDIDATES", 3),
+		},
+		Usage: UsageConfig{
+			DefaultMonthlyBudget: getEnvFloat("COURSE_MONTHLY_BUDGET_USD", 0),
+			DefaultQuotaAction:   getEnv("COURSE_QUOTA_ACTION", "queue"),
+			PromptPricePer1M:     getEnvFloat("OPENAI_PROMPT_PRICE_PER_1M", 0),
+			CompletionPricePer1M: getEnvFloat("OPENAI_COMPLETION_PRICE_PER_1M", 0),
+		},
+		Prompts: PromptsConfig{
+			Dir:           getEnv("PROMPTS_DIR", ""),
+			DefaultLocale: getEnv("DEFAULT_LOCALE", "ru"),
+		},
+		Redaction: RedactionConfig{
+			Enabled:       getEnvBool("REDACTION_ENABLED", true),
+			PatternsFile:  getEnv("REDACTION_PATTERNS_FILE", ""),
+			RejectSecrets: getEnvBool("REDACTION_REJECT_SECRETS", false),
+		},
+		Calibration: CalibrationConfig{
+			Interval: getEnvDuration("CALIBRATION_INTERVAL", 15*time.Second),
+		},
+		Grading: GradingConfig{
+			DefaultScale: getEnv("GRADING_SCALE", "three_to_five"),
+		},
+		Lint: LintConfig{
+			Enabled: getEnvBool("LINT_ENABLED", true),
+			Tools:   getEnvList("LINT_TOOLS"),
+			Timeout: getEnvDuration("LINT_TIMEOUT", 20*time.Second),
+		},
+		AIDetection: AIDetectionConfig{
+			Enabled:         getEnvBool("AI_DETECTION_ENABLED", true),
+			UseLLM:          getEnvBool("AI_DETECTION_USE_LLM", false),
+			ReviewThreshold: getEnvFloat("AI_DETECTION_REVIEW_THRESHOLD", 0.8),
+			History:         getEnvInt("AI_DETECTION_HISTORY", 10),
+		},
+		Style: StyleConfig{
+			Enabled: getEnvBool("STYLE_PROFILE_ENABLED", true),
+			History: getEnvInt("STYLE_PROFILE_HISTORY", 50),
+		},
+		Practice: PracticeConfig{
+			Enabled: getEnvBool("PRACTICE_ENABLED", true),
+			Limit:   getEnvInt("PRACTICE_LIMIT", 5),
+			Window:  getEnvDuration("PRACTICE_WINDOW", time.Hour),
+		},
+		Conversation: ConversationConfig{
+			Enabled:      getEnvBool("CONVERSATION_ENABLED", true),
+			MaxQuestions: getEnvInt("CONVERSATION_MAX_QUESTIONS", 20),
 		},
 	}
 }
@@ -50,3 +278,65 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("WARNING: invalid integer in %s=%q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("WARNING: invalid number in %s=%q, using %g", key, value, defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("WARNING: invalid boolean in %s=%q, using %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("WARNING: invalid duration in %s=%q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
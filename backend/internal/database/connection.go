@@ -13,12 +13,13 @@ import (
 
 func NewConnection(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable statement_timeout=%d",
 		cfg.Database.Host,
 		cfg.Database.User,
 		cfg.Database.Password,
 		cfg.Database.Name,
 		cfg.Database.Port,
+		cfg.Database.QueryTimeout.Milliseconds(),
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
@@ -28,9 +29,49 @@ func NewConnection(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if err := db.AutoMigrate(&models.CodeSubmission{}); err != nil {
+	if err := db.AutoMigrate(
+		&models.CodeSubmission{},
+		&models.PlagiarismMatch{},
+		&models.AnalysisCacheEntry{},
+		&models.LLMUsage{},
+		&models.Course{},
+		&models.Assignment{},
+		&models.EvaluationRun{},
+		&models.EvaluationItem{},
+		&models.Annotation{},
+		&models.StyleProfile{},
+		&models.PracticeAttempt{},
+		&models.ConversationMessage{},
+	); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if err := migrateEmbeddings(db, cfg.Embedding.Dimensions); err != nil {
+		return nil, fmt.Errorf("failed to migrate embeddings: %w", err)
+	}
+
 	return db, nil
 }
+
+// The vector column width depends on the configured embedding model, so this
+// table is created by hand instead of through AutoMigrate.
+func migrateEmbeddings(db *gorm.DB, dimensions int) error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS submission_embeddings (
+			submission_id VARCHAR(64) PRIMARY KEY,
+			file_type VARCHAR(16) NOT NULL,
+			model VARCHAR(128) NOT NULL,
+			embedding vector(%d) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`, dimensions),
+		"CREATE INDEX IF NOT EXISTS idx_submission_embeddings_hnsw ON submission_embeddings USING hnsw (embedding vector_cosine_ops)",
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"codegrader-backend/internal/config"
+)
+
+// Embedder turns source code into a fixed-size vector whose cosine distance
+// reflects how close two solutions are in approach.
+type Embedder interface {
+	Embed(ctx context.Context, code, fileType string) ([]float32, error)
+	Dimensions() int
+	Model() string
+}
+
+func NewEmbedder(cfg *config.Config) (Embedder, error) {
+	switch strings.ToLower(cfg.Embedding.Provider) {
+	case "", "hash":
+		return NewHashEmbedder(cfg.Embedding.Dimensions), nil
+	case "openai":
+		return NewOpenAIEmbedder(cfg.Embedding), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s", cfg.Embedding.Provider)
+	}
+}
+
+func Cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] = float32(float64(v[i]) / norm)
+	}
+	return v
+}
@@ -0,0 +1,88 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"unicode"
+
+	"codegrader-backend/internal/similarity"
+)
+
+const hashEmbedderModel = "hash-v1"
+
+// HashEmbedder is a deterministic, dependency-free embedder based on feature
+// hashing of token n-grams. It is used in tests and when no model is
+// available; identifiers are reduced to their shape so that renaming alone
+// does not move the vector much.
+type HashEmbedder struct {
+	dimensions int
+}
+
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+func (e *HashEmbedder) Embed(ctx context.Context, code, fileType string) ([]float32, error) {
+	vector := make([]float32, e.dimensions)
+
+	tokens := similarity.Tokenize(code, fileType)
+	shapes := make([]string, len(tokens))
+	for i, t := range tokens {
+		shapes[i] = tokenShape(t)
+	}
+
+	for i := range shapes {
+		e.add(vector, "1:"+shapes[i], 1)
+		if i+1 < len(shapes) {
+			e.add(vector, "2:"+shapes[i]+" "+shapes[i+1], 1)
+		}
+		if i+2 < len(shapes) {
+			e.add(vector, "3:"+shapes[i]+" "+shapes[i+1]+" "+shapes[i+2], 0.5)
+		}
+	}
+
+	return normalize(vector), nil
+}
+
+func (e *HashEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+func (e *HashEmbedder) Model() string {
+	return hashEmbedderModel
+}
+
+func (e *HashEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	idx := int(sum % uint64(e.dimensions))
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	vector[idx] += weight
+}
+
+func tokenShape(token string) string {
+	if token == "" {
+		return token
+	}
+
+	first := []rune(token)[0]
+	switch {
+	case first == '"' || first == '\'' || first == '`':
+		return "STR"
+	case unicode.IsDigit(first):
+		return "NUM"
+	case isKeyword(token):
+		return token
+	case unicode.IsLetter(first) || first == '_' || first == '$':
+		return "ID"
+	default:
+		return token
+	}
+}
@@ -0,0 +1,37 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashEmbedder_Deterministic(t *testing.T) {
+	embedder := NewHashEmbedder(64)
+	code := "def add(a, b):\n    return a + b\n"
+
+	first, err := embedder.Embed(context.Background(), code, ".py")
+	require.NoError(t, err)
+	second, err := embedder.Embed(context.Background(), code, ".py")
+	require.NoError(t, err)
+
+	assert.Len(t, first, 64)
+	assert.Equal(t, first, second)
+	assert.InDelta(t, 1.0, Cosine(first, second), 1e-6)
+}
+
+func TestHashEmbedder_RenamedIdentifiersStayClose(t *testing.T) {
+	embedder := NewHashEmbedder(256)
+	original := "int total = 0;\nfor (int i = 0; i < n; i++) {\n    total += values[i];\n}\n"
+	renamed := "int acc = 0;\nfor (int k = 0; k < size; k++) {\n    acc += data[k];\n}\n"
+	unrelated := "#include <iostream>\nint main() {\n    std::cout << \"hello\" << std::endl;\n}\n"
+
+	a, _ := embedder.Embed(context.Background(), original, ".cpp")
+	b, _ := embedder.Embed(context.Background(), renamed, ".cpp")
+	c, _ := embedder.Embed(context.Background(), unrelated, ".cpp")
+
+	assert.Greater(t, Cosine(a, b), 0.95)
+	assert.Less(t, Cosine(a, c), Cosine(a, b))
+}
@@ -0,0 +1,23 @@
+package embedding
+
+var keywords = map[string]struct{}{}
+
+func init() {
+	for _, kw := range []string{
+		"if", "else", "elif", "for", "while", "do", "switch", "case", "default", "when",
+		"break", "continue", "return", "yield", "try", "catch", "except", "finally", "throw", "raise",
+		"class", "struct", "interface", "enum", "object", "fun", "def", "function", "lambda",
+		"new", "delete", "import", "from", "package", "include", "using", "namespace",
+		"in", "is", "not", "and", "or", "null", "nil", "None", "true", "false", "True", "False",
+		"const", "let", "var", "val", "static", "public", "private", "protected",
+		"int", "long", "double", "float", "char", "bool", "boolean", "void", "string", "String", "auto",
+		"vector", "map", "set", "list", "dict", "range", "len", "size", "print", "println", "printf",
+	} {
+		keywords[kw] = struct{}{}
+	}
+}
+
+func isKeyword(token string) bool {
+	_, ok := keywords[token]
+	return ok
+}
@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"codegrader-backend/internal/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAIEmbedder calls any OpenAI-compatible embeddings endpoint, including
+// locally hosted models (Ollama, text-embeddings-inference) via BaseURL.
+type OpenAIEmbedder struct {
+	client     *openai.Client
+	model      string
+	dimensions int
+	timeout    time.Duration
+}
+
+func NewOpenAIEmbedder(cfg config.EmbeddingConfig) *OpenAIEmbedder {
+	clientCfg := openai.DefaultConfig(cfg.APIKey)
+	if cfg.BaseURL != "" {
+		clientCfg.BaseURL = cfg.BaseURL
+	}
+	return &OpenAIEmbedder{
+		client:     openai.NewClientWithConfig(clientCfg),
+		model:      cfg.Model,
+		dimensions: cfg.Dimensions,
+		timeout:    cfg.Timeout,
+	}
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, code, fileType string) ([]float32, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	resp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{code},
+		Model: openai.EmbeddingModel(e.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no embedding returned by %s", e.model)
+	}
+
+	vector := resp.Data[0].Embedding
+	if len(vector) != e.dimensions {
+		return nil, fmt.Errorf("embedding model %s returned %d dimensions, expected %d", e.model, len(vector), e.dimensions)
+	}
+
+	return normalize(vector), nil
+}
+
+func (e *OpenAIEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+func (e *OpenAIEmbedder) Model() string {
+	return e.model
+}
@@ -0,0 +1,242 @@
+// Package grading describes the grading scales a course or assignment can
+// use. Grades are stored as integers; a scale gives them their range, labels
+// and the passing threshold.
+package grading
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Band is one grade of a scale with named grades. Value orders the bands and
+// is what gets stored; Aliases are other spellings accepted from the model.
+type Band struct {
+	Label       string   `json:"label"`
+	Value       int      `json:"value"`
+	Passing     bool     `json:"passing"`
+	Description string   `json:"description,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// Scale is either a numeric range Min..Max with a passing threshold Pass, or
+// a list of Bands (letter grades, pass/fail, custom bands). Name refers to a
+// preset; a scale with only a Name is expanded to that preset.
+type Scale struct {
+	Name  string `json:"name,omitempty"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max"`
+	Pass  int    `json:"pass"`
+	Bands []Band `json:"bands,omitempty"`
+}
+
+var ErrInvalidScale = errors.New("invalid grading scale")
+
+// DefaultPreset keeps the 3–5 range used before scales were configurable.
+const DefaultPreset = "three_to_five"
+
+var presets = map[string]Scale{
+	"three_to_five": {Min: 3, Max: 5, Pass: 3},
+	"five_point":    {Min: 2, Max: 5, Pass: 3},
+	"hundred":       {Min: 0, Max: 100, Pass: 60},
+	"letter": {Bands: []Band{
+		{Label: "F", Value: 0},
+		{Label: "D", Value: 1, Passing: true},
+		{Label: "C", Value: 2, Passing: true},
+		{Label: "B", Value: 3, Passing: true},
+		{Label: "A", Value: 4, Passing: true},
+	}},
+	"pass_fail": {Bands: []Band{
+		{Label: "не зачтено", Value: 0, Aliases: []string{"незачтено", "fail", "failed"}},
+		{Label: "зачтено", Value: 1, Passing: true, Aliases: []string{"pass", "passed"}},
+	}},
+}
+
+// Presets lists the preset names.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the scale used when neither the course nor the assignment
+// sets one.
+func Default() *Scale {
+	scale, _ := Parse(DefaultPreset)
+	return scale
+}
+
+// Parse reads a preset name or a JSON scale definition.
+func Parse(spec string) (*Scale, error) {
+	spec = strings.TrimSpace(spec)
+	scale := &Scale{Name: spec}
+	if strings.HasPrefix(spec, "{") {
+		scale = &Scale{}
+		if err := json.Unmarshal([]byte(spec), scale); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidScale, err)
+		}
+	}
+	if err := scale.Normalize(); err != nil {
+		return nil, err
+	}
+	return scale, nil
+}
+
+// Normalize expands a preset reference, sorts the bands by value and checks
+// that the scale is usable.
+func (s *Scale) Normalize() error {
+	if s.Name != "" && s.Min == 0 && s.Max == 0 && len(s.Bands) == 0 {
+		preset, ok := presets[s.Name]
+		if !ok {
+			return fmt.Errorf("%w: unknown preset %q, expected one of %s", ErrInvalidScale, s.Name, strings.Join(Presets(), ", "))
+		}
+		name := s.Name
+		*s = preset
+		s.Name = name
+		s.Bands = append([]Band(nil), preset.Bands...)
+	}
+
+	if len(s.Bands) == 0 {
+		if s.Min >= s.Max {
+			return fmt.Errorf("%w: min must be below max", ErrInvalidScale)
+		}
+		if s.Pass < s.Min || s.Pass > s.Max {
+			return fmt.Errorf("%w: pass must be between min and max", ErrInvalidScale)
+		}
+		return nil
+	}
+
+	if len(s.Bands) < 2 {
+		return fmt.Errorf("%w: at least two bands are required", ErrInvalidScale)
+	}
+	sort.SliceStable(s.Bands, func(i, j int) bool { return s.Bands[i].Value < s.Bands[j].Value })
+	labels := make(map[string]bool)
+	for i, band := range s.Bands {
+		if strings.TrimSpace(band.Label) == "" {
+			return fmt.Errorf("%w: band %d has no label", ErrInvalidScale, i+1)
+		}
+		if i > 0 && band.Value == s.Bands[i-1].Value {
+			return fmt.Errorf("%w: bands %q and %q share value %d", ErrInvalidScale, s.Bands[i-1].Label, band.Label, band.Value)
+		}
+		for _, label := range append([]string{band.Label}, band.Aliases...) {
+			key := strings.ToLower(label)
+			if labels[key] {
+				return fmt.Errorf("%w: label %q is used twice", ErrInvalidScale, label)
+			}
+			labels[key] = true
+		}
+	}
+	s.Min, s.Max, s.Pass = s.Bands[0].Value, s.Bands[len(s.Bands)-1].Value, 0
+	return nil
+}
+
+// Numeric reports whether grades are plain numbers rather than labels.
+func (s *Scale) Numeric() bool {
+	return len(s.Bands) == 0
+}
+
+// Lowest is the worst grade, given for confirmed plagiarism.
+func (s *Scale) Lowest() int {
+	return s.Min
+}
+
+// Fallback is the grade given when no usable grade could be obtained: the
+// lowest passing grade, so that a provider failure never fails a student.
+func (s *Scale) Fallback() int {
+	if s.Numeric() {
+		return s.Pass
+	}
+	for _, band := range s.Bands {
+		if band.Passing {
+			return band.Value
+		}
+	}
+	return s.Min
+}
+
+func (s *Scale) Contains(grade int) bool {
+	if s.Numeric() {
+		return grade >= s.Min && grade <= s.Max
+	}
+	return s.band(grade) != nil
+}
+
+func (s *Scale) Passed(grade int) bool {
+	if s.Numeric() {
+		return grade >= s.Pass
+	}
+	band := s.band(grade)
+	return band != nil && band.Passing
+}
+
+// Label is how grade is shown: the number itself or the band label.
+func (s *Scale) Label(grade int) string {
+	if band := s.band(grade); band != nil {
+		return band.Label
+	}
+	return strconv.Itoa(grade)
+}
+
+// ParseGrade reads a grade from the start of text, the part of a response
+// line after "Grade:". The second result is false when text does not start
+// with a grade of this scale.
+func (s *Scale) ParseGrade(text string) (int, bool) {
+	text = strings.TrimLeft(text, " *_[«\"'`")
+	if s.Numeric() {
+		end := 0
+		if strings.HasPrefix(text, "-") {
+			end = 1
+		}
+		for end < len(text) && text[end] >= '0' && text[end] <= '9' {
+			end++
+		}
+		grade, err := strconv.Atoi(text[:end])
+		if err != nil || !s.Contains(grade) {
+			return 0, false
+		}
+		return grade, true
+	}
+
+	lower := strings.ToLower(text)
+	best, bestLen := 0, 0
+	for _, band := range s.Bands {
+		for _, label := range append([]string{band.Label}, band.Aliases...) {
+			label = strings.ToLower(label)
+			if len(label) > bestLen && strings.HasPrefix(lower, label) && endsWord(lower[len(label):]) {
+				best, bestLen = band.Value, len(label)
+			}
+		}
+	}
+	return best, bestLen > 0
+}
+
+// Version identifies the scale for cache keys.
+func (s *Scale) Version() string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func (s *Scale) band(grade int) *Band {
+	for i := range s.Bands {
+		if s.Bands[i].Value == grade {
+			return &s.Bands[i]
+		}
+	}
+	return nil
+}
+
+// endsWord reports whether rest does not continue the label just matched,
+// so that "A" does not match "A+" or "Above".
+func endsWord(rest string) bool {
+	for _, r := range rest {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '-'
+	}
+	return true
+}
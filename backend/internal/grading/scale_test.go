@@ -0,0 +1,121 @@
+package grading
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault_KeepsThreeToFive(t *testing.T) {
+	scale := Default()
+
+	assert.True(t, scale.Numeric())
+	assert.Equal(t, 3, scale.Min)
+	assert.Equal(t, 5, scale.Max)
+	assert.Equal(t, 3, scale.Fallback())
+	assert.Equal(t, 3, scale.Lowest())
+}
+
+func TestParse_PresetsAndJSON(t *testing.T) {
+	for _, name := range Presets() {
+		scale, err := Parse(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, name, scale.Name)
+	}
+
+	scale, err := Parse(`{"bands": [{"label": "хорошо", "value": 2, "passing": true}, {"label": "плохо", "value": 1}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, scale.Min)
+	assert.Equal(t, 2, scale.Max)
+	assert.Equal(t, "плохо", scale.Bands[0].Label)
+	assert.Equal(t, 2, scale.Fallback())
+	assert.Equal(t, 1, scale.Lowest())
+}
+
+func TestParse_RejectsInvalidScales(t *testing.T) {
+	tests := map[string]string{
+		"unknown preset":  "ten",
+		"empty range":     `{"min": 5, "max": 5}`,
+		"pass outside":    `{"min": 0, "max": 10, "pass": 11}`,
+		"single band":     `{"bands": [{"label": "ok", "value": 1}]}`,
+		"duplicate value": `{"bands": [{"label": "a", "value": 1}, {"label": "b", "value": 1}]}`,
+		"duplicate label": `{"bands": [{"label": "a", "value": 1}, {"label": "b", "value": 2, "aliases": ["A"]}]}`,
+		"malformed":       `{"min": }`,
+	}
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.ErrorIs(t, err, ErrInvalidScale)
+		})
+	}
+}
+
+func TestParseGrade_Numeric(t *testing.T) {
+	scale, err := Parse("hundred")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		grade int
+		ok    bool
+	}{
+		"87/100":     {87, true},
+		"**0**":      {0, true},
+		"[100]":      {100, true},
+		"101":        {0, false},
+		"-5":         {0, false},
+		"отлично":    {0, false},
+		"  42 балла": {42, true},
+	}
+	for text, want := range tests {
+		grade, ok := scale.ParseGrade(text)
+		assert.Equal(t, want.ok, ok, text)
+		assert.Equal(t, want.grade, grade, text)
+	}
+}
+
+func TestParseGrade_Bands(t *testing.T) {
+	letter, err := Parse("letter")
+	require.NoError(t, err)
+
+	grade, ok := letter.ParseGrade("B, хорошая работа")
+	assert.True(t, ok)
+	assert.Equal(t, 3, grade)
+
+	_, ok = letter.ParseGrade("A+")
+	assert.False(t, ok)
+	_, ok = letter.ParseGrade("Above average")
+	assert.False(t, ok)
+
+	passFail, err := Parse("pass_fail")
+	require.NoError(t, err)
+
+	grade, ok = passFail.ParseGrade("Не зачтено")
+	assert.True(t, ok)
+	assert.Equal(t, 0, grade)
+	grade, ok = passFail.ParseGrade("«зачтено»")
+	assert.True(t, ok)
+	assert.Equal(t, 1, grade)
+	grade, ok = passFail.ParseGrade("passed")
+	assert.True(t, ok)
+	assert.Equal(t, 1, grade)
+}
+
+func TestScale_LabelsAndPassing(t *testing.T) {
+	passFail, err := Parse("pass_fail")
+	require.NoError(t, err)
+
+	assert.Equal(t, "зачтено", passFail.Label(1))
+	assert.True(t, passFail.Passed(1))
+	assert.False(t, passFail.Passed(0))
+	assert.False(t, passFail.Contains(2))
+	assert.Equal(t, 1, passFail.Fallback())
+
+	five, err := Parse("five_point")
+	require.NoError(t, err)
+
+	assert.Equal(t, "2", five.Label(2))
+	assert.False(t, five.Passed(2))
+	assert.True(t, five.Passed(3))
+	assert.Equal(t, 2, five.Lowest())
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AssignmentHandler struct {
+	assignmentSvc services.AssignmentService
+}
+
+func NewAssignmentHandler(assignmentSvc services.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{assignmentSvc: assignmentSvc}
+}
+
+func (h *AssignmentHandler) GetAssignment(c *fiber.Ctx) error {
+	assignment, err := h.assignmentSvc.GetAssignment(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Assignment not found",
+		})
+	}
+
+	return c.JSON(assignment)
+}
+
+func (h *AssignmentHandler) UpdateAssignment(c *fiber.Ctx) error {
+	var req models.AssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	assignment, err := h.assignmentSvc.UpdateAssignment(c.UserContext(), c.Params("id"), &req)
+	if errors.Is(err, services.ErrInvalidAssignment) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update assignment",
+		})
+	}
+
+	return c.JSON(assignment)
+}
+
+// GetReferenceSolutions returns the teacher's reference solutions, which the
+// assignment itself does not include.
+func (h *AssignmentHandler) GetReferenceSolutions(c *fiber.Ctx) error {
+	solutions, err := h.assignmentSvc.GetReferenceSolutions(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Assignment not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": solutions,
+	})
+}
+
+func (h *AssignmentHandler) UpdateReferenceSolutions(c *fiber.Ctx) error {
+	var req models.ReferenceSolutionsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	solutions, err := h.assignmentSvc.UpdateReferenceSolutions(c.UserContext(), c.Params("id"), req.ReferenceSolutions)
+	if errors.Is(err, services.ErrInvalidAssignment) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update reference solutions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": solutions,
+	})
+}
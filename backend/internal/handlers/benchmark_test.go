@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http/httptest"
 	"testing"
@@ -13,7 +14,7 @@ import (
 
 type SimpleMockService struct{}
 
-func (m *SimpleMockService) CreateSubmission(req *models.SubmissionRequest) (*models.SubmissionResponse, error) {
+func (m *SimpleMockService) CreateSubmission(ctx context.Context, req *models.SubmissionRequest) (*models.SubmissionResponse, error) {
 	return &models.SubmissionResponse{
 		ID:       "bench-id",
 		Grade:    88,
@@ -21,7 +22,7 @@ func (m *SimpleMockService) CreateSubmission(req *models.SubmissionRequest) (*mo
 	}, nil
 }
 
-func (m *SimpleMockService) GetAllSubmissions() ([]models.SubmissionListResponse, error) {
+func (m *SimpleMockService) GetAllSubmissions(ctx context.Context) ([]models.SubmissionListResponse, error) {
 	submissions := make([]models.SubmissionListResponse, 100)
 	for i := 0; i < 100; i++ {
 		submissions[i] = models.SubmissionListResponse{
@@ -33,7 +34,7 @@ func (m *SimpleMockService) GetAllSubmissions() ([]models.SubmissionListResponse
 	return submissions, nil
 }
 
-func (m *SimpleMockService) GetSubmission(id string) (*models.CodeSubmission, error) {
+func (m *SimpleMockService) GetSubmission(ctx context.Context, id string) (*models.CodeSubmission, error) {
 	return &models.CodeSubmission{
 		ID:       id,
 		FileName: "test.go",
@@ -41,7 +42,19 @@ func (m *SimpleMockService) GetSubmission(id string) (*models.CodeSubmission, er
 	}, nil
 }
 
-func (m *SimpleMockService) DeleteSubmission(id string) error {
+func (m *SimpleMockService) GetReviewQueue(ctx context.Context) ([]models.SubmissionListResponse, error) {
+	return nil, nil
+}
+
+func (m *SimpleMockService) Practice(ctx context.Context, req *models.SubmissionRequest) (*models.PracticeResponse, error) {
+	return &models.PracticeResponse{Mode: models.SubmissionModePractice}, nil
+}
+
+func (m *SimpleMockService) GetAnnotations(ctx context.Context, id string) ([]models.Annotation, error) {
+	return nil, nil
+}
+
+func (m *SimpleMockService) DeleteSubmission(ctx context.Context, id string) error {
 	return nil
 }
 
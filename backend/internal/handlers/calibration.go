@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalibrationHandler struct {
+	calibrationSvc services.CalibrationService
+}
+
+func NewCalibrationHandler(calibrationSvc services.CalibrationService) *CalibrationHandler {
+	return &CalibrationHandler{calibrationSvc: calibrationSvc}
+}
+
+// CreateRun queues a labelled dataset for grading; the run is evaluated in
+// the background and can be polled with GetRun.
+func (h *CalibrationHandler) CreateRun(c *fiber.Ctx) error {
+	var req models.CalibrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	run, err := h.calibrationSvc.CreateRun(c.UserContext(), &req)
+	if errors.Is(err, services.ErrInvalidDataset) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create evaluation run",
+		})
+	}
+
+	return c.Status(http.StatusAccepted).JSON(run)
+}
+
+func (h *CalibrationHandler) GetRuns(c *fiber.Ctx) error {
+	runs, err := h.calibrationSvc.GetRuns(c.UserContext())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch evaluation runs",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": runs,
+	})
+}
+
+func (h *CalibrationHandler) GetRun(c *fiber.Ctx) error {
+	run, err := h.calibrationSvc.GetRun(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Evaluation run not found",
+		})
+	}
+
+	return c.JSON(run)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/resilience"
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ConversationHandler struct {
+	conversationSvc services.ConversationService
+}
+
+func NewConversationHandler(conversationSvc services.ConversationService) *ConversationHandler {
+	return &ConversationHandler{conversationSvc: conversationSvc}
+}
+
+// Ask answers a follow-up question about a submission's feedback.
+func (h *ConversationHandler) Ask(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing submission ID",
+		})
+	}
+
+	var req models.ConversationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	reply, err := h.conversationSvc.Ask(c.UserContext(), id, req.Message)
+	if errors.Is(err, services.ErrInvalidQuestion) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrSubmissionNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Submission not found",
+		})
+	}
+	if errors.Is(err, services.ErrConversationLimit) || errors.Is(err, services.ErrQuotaExceeded) {
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if resilience.Unavailable(err) {
+		return c.Status(http.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "The assistant is unavailable, please try again later",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to answer the question",
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(reply)
+}
+
+// GetThread returns the whole conversation about a submission, for the
+// student and for the teacher.
+func (h *ConversationHandler) GetThread(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing submission ID",
+		})
+	}
+
+	thread, err := h.conversationSvc.GetThread(c.UserContext(), id)
+	if errors.Is(err, services.ErrSubmissionNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Submission not found",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch conversation",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": thread,
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CourseHandler struct {
+	courseSvc services.CourseService
+}
+
+func NewCourseHandler(courseSvc services.CourseService) *CourseHandler {
+	return &CourseHandler{courseSvc: courseSvc}
+}
+
+func (h *CourseHandler) GetCourses(c *fiber.Ctx) error {
+	courses, err := h.courseSvc.GetCourses(c.UserContext())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch courses",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": courses,
+	})
+}
+
+func (h *CourseHandler) GetCourse(c *fiber.Ctx) error {
+	course, err := h.courseSvc.GetCourse(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch course",
+		})
+	}
+
+	return c.JSON(course)
+}
+
+func (h *CourseHandler) UpdateCourse(c *fiber.Ctx) error {
+	var req models.CourseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	course, err := h.courseSvc.UpdateCourse(c.UserContext(), c.Params("id"), &req)
+	if errors.Is(err, services.ErrInvalidCourse) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update course",
+		})
+	}
+
+	return c.JSON(course)
+}
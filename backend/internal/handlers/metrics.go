@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"codegrader-backend/internal/resilience"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MetricsHandler struct {
+	guards []*resilience.Guard
+}
+
+func NewMetricsHandler(guards ...*resilience.Guard) *MetricsHandler {
+	return &MetricsHandler{guards: guards}
+}
+
+// GetMetrics reports retry and circuit breaker counters of external providers.
+func (h *MetricsHandler) GetMetrics(c *fiber.Ctx) error {
+	providers := make([]resilience.Stats, len(h.guards))
+	for i, guard := range h.guards {
+		providers[i] = guard.Stats()
+	}
+
+	return c.JSON(fiber.Map{
+		"providers": providers,
+	})
+}
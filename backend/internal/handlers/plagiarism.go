@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PlagiarismHandler struct {
+	plagiarismSvc services.PlagiarismService
+}
+
+func NewPlagiarismHandler(plagiarismSvc services.PlagiarismService) *PlagiarismHandler {
+	return &PlagiarismHandler{plagiarismSvc: plagiarismSvc}
+}
+
+func (h *PlagiarismHandler) GetMatches(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing submission ID",
+		})
+	}
+
+	matches, err := h.plagiarismSvc.GetMatches(c.UserContext(), id)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch plagiarism matches",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": matches,
+	})
+}
+
+func (h *PlagiarismHandler) Recheck(c *fiber.Ctx) error {
+	result, err := h.plagiarismSvc.Recheck(c.UserContext())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"codegrader-backend/internal/report"
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportHandler struct {
+	reportSvc services.ReportService
+}
+
+func NewReportHandler(reportSvc services.ReportService) *ReportHandler {
+	return &ReportHandler{reportSvc: reportSvc}
+}
+
+// GetReport renders a submission as SARIF, JUnit XML or Markdown, chosen by
+// the format query parameter.
+func (h *ReportHandler) GetReport(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing submission ID",
+		})
+	}
+
+	body, contentType, err := h.reportSvc.Render(c.UserContext(), id, c.Query("format"))
+	if errors.Is(err, report.ErrUnsupportedFormat) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrSubmissionNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Submission not found",
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to render report",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(body)
+}
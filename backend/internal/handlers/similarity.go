@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 100
+)
+
+type SimilarityHandler struct {
+	similaritySvc services.SimilarityService
+}
+
+func NewSimilarityHandler(similaritySvc services.SimilarityService) *SimilarityHandler {
+	return &SimilarityHandler{similaritySvc: similaritySvc}
+}
+
+func (h *SimilarityHandler) GetSimilar(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing submission ID",
+		})
+	}
+
+	limit := c.QueryInt("limit", defaultSimilarLimit)
+	if limit <= 0 || limit > maxSimilarLimit {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid limit",
+		})
+	}
+
+	similar, err := h.similaritySvc.FindSimilar(c.UserContext(), id, limit)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch similar submissions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": similar,
+	})
+}
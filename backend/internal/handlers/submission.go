@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"codegrader-backend/internal/models"
 	"codegrader-backend/internal/services"
@@ -31,18 +35,80 @@ func (h *SubmissionHandler) CreateSubmission(c *fiber.Ctx) error {
 		})
 	}
 
-	resp, err := h.submissionSvc.CreateSubmission(&req)
+	req.AcceptLanguage = c.Get(fiber.HeaderAcceptLanguage)
+
+	switch req.Mode {
+	case "", models.SubmissionModeGraded:
+	case models.SubmissionModePractice:
+		return h.practice(c, &req)
+	default:
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown submission mode",
+		})
+	}
+
+	resp, err := h.submissionSvc.CreateSubmission(c.UserContext(), &req)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		return submissionError(c, err)
+	}
+
+	return c.Status(http.StatusCreated).JSON(resp)
+}
+
+// practice answers a practice submission. Nothing is created, so the status
+// is 200 rather than 201.
+func (h *SubmissionHandler) practice(c *fiber.Ctx, req *models.SubmissionRequest) error {
+	resp, err := h.submissionSvc.Practice(c.UserContext(), req)
+	var limit *services.PracticeLimitError
+	if errors.As(err, &limit) {
+		retryAfter := int(math.Ceil(limit.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error":       err.Error(),
+			"retry_after": retryAfter,
+		})
+	}
+	if errors.Is(err, services.ErrInvalidPractice) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	if err != nil {
+		return submissionError(c, err)
+	}
 
-	return c.Status(http.StatusCreated).JSON(resp)
+	return c.JSON(resp)
+}
+
+// submissionError maps the errors of sending code for analysis to responses.
+func submissionError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrSubmissionTooLarge) {
+		return c.Status(http.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrSecretsDetected) {
+		return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return c.Status(http.StatusGatewayTimeout).JSON(fiber.Map{
+			"error": "Grading timed out, please try again",
+		})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
 }
 
 func (h *SubmissionHandler) GetSubmissions(c *fiber.Ctx) error {
-	submissions, err := h.submissionSvc.GetAllSubmissions()
+	submissions, err := h.submissionSvc.GetAllSubmissions(c.UserContext())
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch submissions",
@@ -54,6 +120,21 @@ func (h *SubmissionHandler) GetSubmissions(c *fiber.Ctx) error {
 	})
 }
 
+// GetReviewQueue lists submissions waiting for a teacher, including those
+// with a high advisory AI likelihood.
+func (h *SubmissionHandler) GetReviewQueue(c *fiber.Ctx) error {
+	submissions, err := h.submissionSvc.GetReviewQueue(c.UserContext())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch review queue",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": submissions,
+	})
+}
+
 func (h *SubmissionHandler) GetSubmission(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
@@ -62,7 +143,7 @@ func (h *SubmissionHandler) GetSubmission(c *fiber.Ctx) error {
 		})
 	}
 
-	submission, err := h.submissionSvc.GetSubmission(id)
+	submission, err := h.submissionSvc.GetSubmission(c.UserContext(), id)
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{
 			"error": "Submission not found",
@@ -72,6 +153,58 @@ func (h *SubmissionHandler) GetSubmission(c *fiber.Ctx) error {
 	return c.JSON(submission)
 }
 
+// GetSubmissionReview returns a submission with the advisory signals that
+// only teachers see.
+func (h *SubmissionHandler) GetSubmissionReview(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing submission ID",
+		})
+	}
+
+	submission, err := h.submissionSvc.GetSubmission(c.UserContext(), id)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Submission not found",
+		})
+	}
+
+	return c.JSON(&models.SubmissionReview{
+		CodeSubmission:      submission,
+		AIDetection:         submission.AIDetection,
+		StyleDeviation:      submission.StyleDeviation,
+		ReferenceComparison: submission.ReferenceComparison,
+	})
+}
+
+// GetAnnotations returns the line remarks of a submission for inline display.
+func (h *SubmissionHandler) GetAnnotations(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing submission ID",
+		})
+	}
+
+	if _, err := h.submissionSvc.GetSubmission(c.UserContext(), id); err != nil {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Submission not found",
+		})
+	}
+
+	annotations, err := h.submissionSvc.GetAnnotations(c.UserContext(), id)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch annotations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": annotations,
+	})
+}
+
 func (h *SubmissionHandler) DeleteSubmission(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
@@ -80,7 +213,7 @@ func (h *SubmissionHandler) DeleteSubmission(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.submissionSvc.DeleteSubmission(id); err != nil {
+	if err := h.submissionSvc.DeleteSubmission(c.UserContext(), id); err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete submission",
 		})
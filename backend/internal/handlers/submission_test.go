@@ -2,24 +2,30 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"codegrader-backend/internal/aidetect"
 	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/services"
+	"codegrader-backend/internal/style"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockSubmissionService struct {
 	mock.Mock
 }
 
-func (m *MockSubmissionService) CreateSubmission(req *models.SubmissionRequest) (*models.SubmissionResponse, error) {
+func (m *MockSubmissionService) CreateSubmission(ctx context.Context, req *models.SubmissionRequest) (*models.SubmissionResponse, error) {
 	args := m.Called(req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -27,12 +33,25 @@ func (m *MockSubmissionService) CreateSubmission(req *models.SubmissionRequest)
 	return args.Get(0).(*models.SubmissionResponse), args.Error(1)
 }
 
-func (m *MockSubmissionService) GetAllSubmissions() ([]models.SubmissionListResponse, error) {
+func (m *MockSubmissionService) GetAllSubmissions(ctx context.Context) ([]models.SubmissionListResponse, error) {
 	args := m.Called()
 	return args.Get(0).([]models.SubmissionListResponse), args.Error(1)
 }
 
-func (m *MockSubmissionService) GetSubmission(id string) (*models.CodeSubmission, error) {
+func (m *MockSubmissionService) GetReviewQueue(ctx context.Context) ([]models.SubmissionListResponse, error) {
+	args := m.Called()
+	return args.Get(0).([]models.SubmissionListResponse), args.Error(1)
+}
+
+func (m *MockSubmissionService) Practice(ctx context.Context, req *models.SubmissionRequest) (*models.PracticeResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PracticeResponse), args.Error(1)
+}
+
+func (m *MockSubmissionService) GetSubmission(ctx context.Context, id string) (*models.CodeSubmission, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -40,7 +59,12 @@ func (m *MockSubmissionService) GetSubmission(id string) (*models.CodeSubmission
 	return args.Get(0).(*models.CodeSubmission), args.Error(1)
 }
 
-func (m *MockSubmissionService) DeleteSubmission(id string) error {
+func (m *MockSubmissionService) GetAnnotations(ctx context.Context, id string) ([]models.Annotation, error) {
+	args := m.Called(id)
+	return args.Get(0).([]models.Annotation), args.Error(1)
+}
+
+func (m *MockSubmissionService) DeleteSubmission(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
@@ -176,6 +200,28 @@ func TestSubmissionHandler_GetSubmissions_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestSubmissionHandler_GetReviewQueue(t *testing.T) {
+	mockService := new(MockSubmissionService)
+	handler := NewSubmissionHandler(mockService)
+
+	app := fiber.New()
+	app.Get("/submissions/review", handler.GetReviewQueue)
+
+	queue := []models.SubmissionListResponse{{ID: "1", FileName: "main.py", AILikelihood: 0.9, AIFlagged: true}}
+	mockService.On("GetReviewQueue").Return(queue, nil)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/submissions/review", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Data []models.SubmissionListResponse `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, queue, body.Data)
+	mockService.AssertExpectations(t)
+}
+
 func TestSubmissionHandler_GetSubmission_Success(t *testing.T) {
 	mockService := new(MockSubmissionService)
 	handler := NewSubmissionHandler(mockService)
@@ -201,6 +247,41 @@ func TestSubmissionHandler_GetSubmission_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestSubmissionHandler_GetSubmission_HidesAdvisorySignals(t *testing.T) {
+	mockService := new(MockSubmissionService)
+	handler := NewSubmissionHandler(mockService)
+
+	app := fiber.New()
+	app.Get("/submissions/:id", handler.GetSubmission)
+	app.Get("/submissions/:id/review", handler.GetSubmissionReview)
+
+	submission := &models.CodeSubmission{
+		ID:                  "test-id",
+		AIDetection:         &aidetect.Result{Likelihood: 0.9, Advisory: true},
+		StyleDeviation:      &style.Deviation{Score: 0.7, Submissions: 5},
+		ReferenceComparison: &models.ReferenceComparison{Closest: "#1"},
+	}
+	mockService.On("GetSubmission", "test-id").Return(submission, nil)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/submissions/test-id", nil))
+	require.NoError(t, err)
+	var student map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&student))
+	assert.NotContains(t, student, "ai_detection")
+	assert.NotContains(t, student, "style_deviation")
+	assert.NotContains(t, student, "reference_comparison")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/submissions/test-id/review", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var teacher map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&teacher))
+	assert.Equal(t, "test-id", teacher["id"])
+	assert.Contains(t, teacher, "ai_detection")
+	assert.Contains(t, teacher, "style_deviation")
+	assert.Contains(t, teacher, "reference_comparison")
+}
+
 func TestSubmissionHandler_GetSubmission_NotFound(t *testing.T) {
 	mockService := new(MockSubmissionService)
 	handler := NewSubmissionHandler(mockService)
@@ -220,6 +301,54 @@ func TestSubmissionHandler_GetSubmission_NotFound(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestSubmissionHandler_GetAnnotations(t *testing.T) {
+	mockService := new(MockSubmissionService)
+	handler := NewSubmissionHandler(mockService)
+
+	app := fiber.New()
+	app.Get("/submissions/:id/annotations", handler.GetAnnotations)
+
+	annotations := []models.Annotation{
+		{SubmissionID: "sub-1", File: "main.py", StartLine: 3, EndLine: 4, Severity: models.SeverityWarning, Category: "style", Message: "Длинная строка"},
+	}
+	mockService.On("GetSubmission", "sub-1").Return(&models.CodeSubmission{ID: "sub-1"}, nil)
+	mockService.On("GetAnnotations", "sub-1").Return(annotations, nil)
+
+	req := httptest.NewRequest("GET", "/submissions/sub-1/annotations", nil)
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []models.Annotation `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, annotations, body.Data)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSubmissionHandler_GetAnnotations_NotFound(t *testing.T) {
+	mockService := new(MockSubmissionService)
+	handler := NewSubmissionHandler(mockService)
+
+	app := fiber.New()
+	app.Get("/submissions/:id/annotations", handler.GetAnnotations)
+
+	mockService.On("GetSubmission", "nonexistent").Return(nil, errors.New("not found"))
+
+	req := httptest.NewRequest("GET", "/submissions/nonexistent/annotations", nil)
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	mockService.AssertExpectations(t)
+}
+
 func TestSubmissionHandler_HealthCheck(t *testing.T) {
 	mockService := new(MockSubmissionService)
 	handler := NewSubmissionHandler(mockService)
@@ -234,3 +363,54 @@ func TestSubmissionHandler_HealthCheck(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
+
+func TestSubmissionHandler_CreateSubmission_Practice(t *testing.T) {
+	mockService := new(MockSubmissionService)
+	handler := NewSubmissionHandler(mockService)
+
+	app := fiber.New()
+	app.Post("/submissions", handler.CreateSubmission)
+
+	reqBody := models.SubmissionRequest{FileName: "main.py", FileType: ".py", StudentID: "s1", Content: "print(1)", Mode: models.SubmissionModePractice}
+	remaining := 4
+	expected := &models.PracticeResponse{Mode: models.SubmissionModePractice, Hints: []string{"Проверьте пустой список"}, RemainingAttempts: &remaining}
+	mockService.On("Practice", &reqBody).Return(expected, nil).Once()
+	mockService.On("Practice", &reqBody).Return(nil, &services.PracticeLimitError{Limit: 5, Window: time.Hour, RetryAfter: 90*time.Second + time.Millisecond})
+
+	jsonBody, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest("POST", "/submissions", bytes.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var response models.PracticeResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, *expected, response)
+
+	req = httptest.NewRequest("POST", "/submissions", bytes.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "91", resp.Header.Get(fiber.HeaderRetryAfter))
+	mockService.AssertNotCalled(t, "CreateSubmission", mock.Anything)
+}
+
+func TestSubmissionHandler_CreateSubmission_UnknownMode(t *testing.T) {
+	mockService := new(MockSubmissionService)
+	handler := NewSubmissionHandler(mockService)
+
+	app := fiber.New()
+	app.Post("/submissions", handler.CreateSubmission)
+
+	jsonBody, _ := json.Marshal(models.SubmissionRequest{FileName: "main.py", FileType: ".py", Content: "print(1)", Mode: "exam"})
+	req := httptest.NewRequest("POST", "/submissions", bytes.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	mockService.AssertExpectations(t)
+}
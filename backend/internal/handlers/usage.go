@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UsageHandler struct {
+	usageSvc services.UsageService
+}
+
+func NewUsageHandler(usageSvc services.UsageService) *UsageHandler {
+	return &UsageHandler{usageSvc: usageSvc}
+}
+
+// GetUsage aggregates LLM token usage and cost. group_by is one of day,
+// course, assignment, student, model or operation; from and to are dates
+// (YYYY-MM-DD), to being exclusive.
+func (h *UsageHandler) GetUsage(c *fiber.Ctx) error {
+	filter := models.UsageFilter{
+		CourseID:     c.Query("course_id"),
+		AssignmentID: c.Query("assignment_id"),
+		StudentID:    c.Query("student_id"),
+	}
+
+	for param, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid " + param + " date, expected YYYY-MM-DD",
+			})
+		}
+		*target = date
+	}
+
+	summary, err := h.usageSvc.Summary(c.UserContext(), filter, c.Query("group_by", "day"))
+	if errors.Is(err, repositories.ErrUnsupportedGrouping) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch usage",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": summary,
+	})
+}
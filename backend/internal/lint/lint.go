@@ -0,0 +1,200 @@
+// Package lint runs the style checkers installed on the server against a
+// submission and brings their reports to one format. Tools that are not
+// installed are skipped, so the service works without any of them; a tool
+// that fails or runs out of time only loses its own findings.
+package lint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severities match the ones of line annotations.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// MaxFindings caps the findings kept from one tool, so that a file failing
+// every check does not flood the prompt and the feedback.
+const MaxFindings = 50
+
+// Finding is one report of a tool. Line and Column are 1-based; Column is 0
+// when the tool does not report it.
+type Finding struct {
+	Tool     string `json:"tool"`
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Linter is one external tool. Command returns the command line checking
+// file, a name in the current directory; Parse reads what the command wrote.
+// Tools usually exit with a non-zero status when they find something, so
+// the status is not treated as a failure.
+type Linter interface {
+	Name() string
+	Supports(fileType string) bool
+	Command(file string) []string
+	Parse(stdout, stderr []byte) []Finding
+}
+
+// Runner runs the linters whose programs are installed.
+type Runner struct {
+	linters []Linter
+	timeout time.Duration
+}
+
+// New keeps the linters of linters that are installed and, when names is
+// not empty, listed in it. timeout limits every single run.
+func New(linters []Linter, names []string, timeout time.Duration) *Runner {
+	r := &Runner{timeout: timeout}
+	for _, l := range linters {
+		if len(names) > 0 && !contains(names, l.Name()) {
+			continue
+		}
+		if _, err := exec.LookPath(l.Command("x")[0]); err != nil {
+			continue
+		}
+		r.linters = append(r.linters, l)
+	}
+	return r
+}
+
+// Names lists the linters that will run.
+func (r *Runner) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, len(r.linters))
+	for i, l := range r.linters {
+		names[i] = l.Name()
+	}
+	return names
+}
+
+// Run checks code saved as fileName and returns the findings of all
+// linters supporting fileType ordered by line. A nil Runner finds nothing.
+func (r *Runner) Run(ctx context.Context, fileName, fileType, code string) []Finding {
+	if r == nil {
+		return nil
+	}
+	var linters []Linter
+	for _, l := range r.linters {
+		if l.Supports(fileType) {
+			linters = append(linters, l)
+		}
+	}
+	if len(linters) == 0 {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "lint-")
+	if err != nil {
+		log.Printf("Failed to create lint directory: %v", err)
+		return nil
+	}
+	defer os.RemoveAll(dir)
+
+	file := sourceName(fileName, fileType)
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(code), 0o600); err != nil {
+		log.Printf("Failed to write file for linting: %v", err)
+		return nil
+	}
+
+	lines := strings.Count(code, "\n") + 1
+	var findings []Finding
+	for _, l := range linters {
+		found, err := r.run(ctx, l, dir, file)
+		if err != nil {
+			log.Printf("Linter %s failed: %v", l.Name(), err)
+			continue
+		}
+		findings = append(findings, clean(found, l.Name(), lines)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
+
+func (r *Runner) run(ctx context.Context, l Linter, dir, file string) ([]Finding, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := l.Command(file)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	// Children of a killed tool may hold the output pipes open.
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	return l.Parse(stdout.Bytes(), stderr.Bytes()), nil
+}
+
+// clean drops findings outside the file, fills in the tool and severity and
+// keeps at most MaxFindings of them.
+func clean(found []Finding, tool string, lines int) []Finding {
+	var findings []Finding
+	for _, f := range found {
+		f.Message = strings.TrimSpace(f.Message)
+		if f.Line < 1 || f.Line > lines || f.Message == "" {
+			continue
+		}
+		f.Tool = tool
+		switch f.Severity {
+		case SeverityError, SeverityWarning, SeverityInfo:
+		default:
+			f.Severity = SeverityInfo
+		}
+		findings = append(findings, f)
+		if len(findings) == MaxFindings {
+			break
+		}
+	}
+	return findings
+}
+
+// sourceName is the name the code is saved under. Java needs the original
+// name, which has to match the public class; anything unusable falls back
+// to "main".
+func sourceName(fileName, fileType string) string {
+	name := filepath.Base(strings.ReplaceAll(fileName, `\`, "/"))
+	if strings.TrimSuffix(name, filepath.Ext(name)) == "" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "-") {
+		name = "main"
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + fileType
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
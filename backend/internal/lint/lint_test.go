@@ -0,0 +1,142 @@
+package lint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shellLinter runs script with sh; the file name is passed as $1.
+type shellLinter struct {
+	name   string
+	script string
+}
+
+func (l *shellLinter) Name() string                  { return l.name }
+func (l *shellLinter) Supports(fileType string) bool { return fileType == ".py" }
+func (l *shellLinter) Command(file string) []string {
+	return []string{"sh", "-c", l.script, "sh", file}
+}
+func (l *shellLinter) Parse(stdout, _ []byte) []Finding {
+	return parseFlake8(stdout)
+}
+
+func TestRunner_RunsInstalledLintersOnTheFile(t *testing.T) {
+	linters := []Linter{
+		// Prints the line count of the file it was given and fails, as
+		// linters do when they find something.
+		&shellLinter{name: "lines", script: `echo "$(wc -l < "$1"):1:L1:$1 has lines"; exit 1`},
+		&shellLinter{name: "outside", script: `echo "99:1:X:past the end"`},
+		&tool{name: "missing", fileTypes: []string{".py"}, args: []string{"definitely-not-installed-linter"}},
+	}
+	runner := New(linters, nil, 5*time.Second)
+	assert.Equal(t, []string{"lines", "outside"}, runner.Names())
+
+	findings := runner.Run(context.Background(), "../solution.py", ".py", "a = 1\nb = 2\n")
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, Finding{Tool: "lines", Rule: "L1", Severity: SeverityInfo, Line: 2, Column: 1, Message: "solution.py has lines"}, findings[0])
+	assert.Empty(t, runner.Run(context.Background(), "main.js", ".js", "x"))
+}
+
+func TestRunner_TimeoutSkipsLinter(t *testing.T) {
+	linters := []Linter{
+		&shellLinter{name: "slow", script: `sleep 5; echo "1:1:S:slow"`},
+		&shellLinter{name: "fast", script: `echo "1:1:F401:unused import"`},
+	}
+	runner := New(linters, nil, 200*time.Millisecond)
+
+	findings := runner.Run(context.Background(), "main.py", ".py", "import os")
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, "fast", findings[0].Tool)
+	assert.Equal(t, SeverityWarning, findings[0].Severity)
+}
+
+func TestNew_FiltersByName(t *testing.T) {
+	linters := []Linter{&shellLinter{name: "a", script: "true"}, &shellLinter{name: "b", script: "true"}}
+
+	assert.Equal(t, []string{"b"}, New(linters, []string{"b"}, time.Second).Names())
+
+	var runner *Runner
+	assert.Nil(t, runner.Run(context.Background(), "main.py", ".py", "x"))
+}
+
+func TestSourceName(t *testing.T) {
+	assert.Equal(t, "Main.java", sourceName("src/Main.java", ".java"))
+	assert.Equal(t, "main.py", sourceName("", ".py"))
+	assert.Equal(t, "main.py", sourceName("-rf.py", ".py"))
+	assert.Equal(t, "task.cpp", sourceName("task.txt", ".cpp"))
+}
+
+func TestParsers(t *testing.T) {
+	tests := map[string]struct {
+		parse  func() []Finding
+		expect Finding
+	}{
+		"pylint": {
+			func() []Finding {
+				return parsePylint([]byte(`[{"type": "convention", "line": 3, "column": 0, "symbol": "invalid-name", "message-id": "C0103", "message": "Constant name \"x\" doesn't conform"}]`))
+			},
+			Finding{Rule: "C0103 invalid-name", Severity: SeverityInfo, Line: 3, Column: 1, Message: `Constant name "x" doesn't conform`},
+		},
+		"flake8": {
+			func() []Finding { return parseFlake8([]byte("2:80:E501:line too long (88 > 79 characters)\n")) },
+			Finding{Rule: "E501", Severity: SeverityInfo, Line: 2, Column: 80, Message: "line too long (88 > 79 characters)"},
+		},
+		"eslint": {
+			func() []Finding {
+				return parseESLint([]byte(`[{"filePath": "/tmp/main.js", "messages": [{"ruleId": "no-unused-vars", "severity": 2, "line": 1, "column": 7, "message": "'x' is assigned a value but never used."}]}]`))
+			},
+			Finding{Rule: "no-unused-vars", Severity: SeverityError, Line: 1, Column: 7, Message: "'x' is assigned a value but never used."},
+		},
+		"ktlint": {
+			func() []Finding {
+				return parseKtlint([]byte(`[{"file": "main.kt", "errors": [{"line": 4, "column": 1, "message": "Unexpected blank line(s) before \"}\"", "rule": "standard:no-blank-line-before-rbrace"}]}]`))
+			},
+			Finding{Rule: "standard:no-blank-line-before-rbrace", Severity: SeverityWarning, Line: 4, Column: 1, Message: `Unexpected blank line(s) before "}"`},
+		},
+		"checkstyle": {
+			func() []Finding {
+				return parseCheckstyle([]byte("Starting audit...\n[WARN] /tmp/lint-1/Main.java:5:9: 'method def' child has incorrect indentation level 8, expected level should be 4. [Indentation]\nAudit done.\n"))
+			},
+			Finding{Rule: "Indentation", Severity: SeverityWarning, Line: 5, Column: 9, Message: "'method def' child has incorrect indentation level 8, expected level should be 4."},
+		},
+		"clang-tidy": {
+			func() []Finding {
+				return parseCompilerStyle([]byte("/tmp/lint-1/main.c:7:5: warning: Call to function 'strcpy' is insecure [clang-analyzer-security.insecureAPI.strcpy]\n    strcpy(a, b);\n    ^\n"))
+			},
+			Finding{Rule: "clang-analyzer-security.insecureAPI.strcpy", Severity: SeverityWarning, Line: 7, Column: 5, Message: "Call to function 'strcpy' is insecure"},
+		},
+		"cppcheck": {
+			func() []Finding {
+				return parseCppcheck([]byte("6:12:style:variableScope:The scope of the variable 'i' can be reduced.\n"))
+			},
+			Finding{Rule: "variableScope", Severity: SeverityInfo, Line: 6, Column: 12, Message: "The scope of the variable 'i' can be reduced."},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			findings := tt.parse()
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.expect, findings[0])
+		})
+	}
+}
+
+func TestParsers_IgnoreUnexpectedOutput(t *testing.T) {
+	assert.Empty(t, parsePylint([]byte("No config file found")))
+	assert.Empty(t, parseESLint([]byte("Oops! Something went wrong!")))
+	assert.Empty(t, parseFlake8([]byte("")))
+	assert.Empty(t, parseCompilerStyle([]byte("1 warning generated.")))
+}
+
+func TestBuiltin_CommandsIncludeFile(t *testing.T) {
+	for _, l := range Builtin() {
+		args := l.Command("main.c")
+		assert.Contains(t, args, "main.c", l.Name())
+	}
+}
@@ -0,0 +1,247 @@
+package lint
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Builtin returns the supported tools: pylint and flake8 for Python, eslint
+// for JavaScript and TypeScript, ktlint for Kotlin, checkstyle for Java and
+// clang-tidy and cppcheck for C and C++.
+func Builtin() []Linter {
+	return []Linter{
+		&tool{
+			name: "pylint", fileTypes: []string{".py"},
+			args:  []string{"pylint", "--output-format=json", "--score=n"},
+			parse: func(stdout, _ []byte) []Finding { return parsePylint(stdout) },
+		},
+		&tool{
+			name: "flake8", fileTypes: []string{".py"},
+			args:  []string{"flake8", "--format=%(row)d:%(col)d:%(code)s:%(text)s"},
+			parse: func(stdout, _ []byte) []Finding { return parseFlake8(stdout) },
+		},
+		&tool{
+			name: "eslint", fileTypes: []string{".js", ".ts"},
+			args:  []string{"eslint", "--format=json", "--no-ignore"},
+			parse: func(stdout, _ []byte) []Finding { return parseESLint(stdout) },
+		},
+		&tool{
+			name: "ktlint", fileTypes: []string{".kt"},
+			args:  []string{"ktlint", "--reporter=json"},
+			parse: func(stdout, _ []byte) []Finding { return parseKtlint(stdout) },
+		},
+		&tool{
+			name: "checkstyle", fileTypes: []string{".java"},
+			args:  []string{"checkstyle", "-c", "/google_checks.xml"},
+			parse: func(stdout, _ []byte) []Finding { return parseCheckstyle(stdout) },
+		},
+		&tool{
+			name: "clang-tidy", fileTypes: []string{".c", ".cpp"},
+			args:  []string{"clang-tidy", "--quiet"},
+			after: []string{"--"},
+			parse: func(stdout, _ []byte) []Finding { return parseCompilerStyle(stdout) },
+		},
+		&tool{
+			name: "cppcheck", fileTypes: []string{".c", ".cpp"},
+			args: []string{"cppcheck", "--quiet", "--enable=style,performance,portability",
+				"--template={line}:{column}:{severity}:{id}:{message}"},
+			parse: func(_, stderr []byte) []Finding { return parseCppcheck(stderr) },
+		},
+	}
+}
+
+// tool is a Linter run as args, the file name and after.
+type tool struct {
+	name      string
+	fileTypes []string
+	args      []string
+	after     []string
+	parse     func(stdout, stderr []byte) []Finding
+}
+
+func (t *tool) Name() string { return t.name }
+
+func (t *tool) Supports(fileType string) bool { return contains(t.fileTypes, fileType) }
+
+func (t *tool) Command(file string) []string {
+	args := append([]string{}, t.args...)
+	args = append(args, file)
+	return append(args, t.after...)
+}
+
+func (t *tool) Parse(stdout, stderr []byte) []Finding { return t.parse(stdout, stderr) }
+
+func parsePylint(out []byte) []Finding {
+	var messages []struct {
+		Type      string `json:"type"`
+		Line      int    `json:"line"`
+		Column    int    `json:"column"`
+		Symbol    string `json:"symbol"`
+		MessageID string `json:"message-id"`
+		Message   string `json:"message"`
+	}
+	if json.Unmarshal(out, &messages) != nil {
+		return nil
+	}
+	findings := make([]Finding, 0, len(messages))
+	for _, m := range messages {
+		severity := SeverityInfo
+		switch m.Type {
+		case "error", "fatal":
+			severity = SeverityError
+		case "warning":
+			severity = SeverityWarning
+		}
+		// pylint counts columns from 0.
+		findings = append(findings, Finding{
+			Rule: strings.TrimSpace(m.MessageID + " " + m.Symbol), Severity: severity,
+			Line: m.Line, Column: m.Column + 1, Message: m.Message,
+		})
+	}
+	return findings
+}
+
+func parseFlake8(out []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(out), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		severity := SeverityInfo
+		switch {
+		case strings.HasPrefix(parts[2], "E9"):
+			severity = SeverityError
+		case strings.HasPrefix(parts[2], "F"):
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Rule: parts[2], Severity: severity,
+			Line: atoi(parts[0]), Column: atoi(parts[1]), Message: parts[3],
+		})
+	}
+	return findings
+}
+
+func parseESLint(out []byte) []Finding {
+	var files []struct {
+		Messages []struct {
+			RuleID   string `json:"ruleId"`
+			Severity int    `json:"severity"`
+			Line     int    `json:"line"`
+			Column   int    `json:"column"`
+			Message  string `json:"message"`
+			Fatal    bool   `json:"fatal"`
+		} `json:"messages"`
+	}
+	if json.Unmarshal(out, &files) != nil {
+		return nil
+	}
+	var findings []Finding
+	for _, file := range files {
+		for _, m := range file.Messages {
+			severity := SeverityWarning
+			if m.Fatal || m.Severity == 2 {
+				severity = SeverityError
+			}
+			findings = append(findings, Finding{
+				Rule: m.RuleID, Severity: severity, Line: m.Line, Column: m.Column, Message: m.Message,
+			})
+		}
+	}
+	return findings
+}
+
+func parseKtlint(out []byte) []Finding {
+	var files []struct {
+		Errors []struct {
+			Line    int    `json:"line"`
+			Column  int    `json:"column"`
+			Message string `json:"message"`
+			Rule    string `json:"rule"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(out, &files) != nil {
+		return nil
+	}
+	var findings []Finding
+	for _, file := range files {
+		for _, e := range file.Errors {
+			findings = append(findings, Finding{
+				Rule: e.Rule, Severity: SeverityWarning, Line: e.Line, Column: e.Column, Message: e.Message,
+			})
+		}
+	}
+	return findings
+}
+
+// checkstyleLine matches "[WARN] /tmp/Main.java:3:5: Message. [RuleName]";
+// the column is optional.
+var checkstyleLine = regexp.MustCompile(`^\[(ERROR|WARN|INFO)\] .*?:(\d+):(?:(\d+):)? (.*?)(?: \[(\w+)\])?$`)
+
+func parseCheckstyle(out []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(out), "\n") {
+		m := checkstyleLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		severity := SeverityInfo
+		switch m[1] {
+		case "ERROR":
+			severity = SeverityError
+		case "WARN":
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Rule: m[5], Severity: severity, Line: atoi(m[2]), Column: atoi(m[3]), Message: m[4],
+		})
+	}
+	return findings
+}
+
+// compilerLine matches "main.c:3:5: warning: message [check-name]", the
+// format of clang-tidy and compilers.
+var compilerLine = regexp.MustCompile(`^.*?:(\d+):(\d+): (error|warning): (.*?)(?: \[([\w.,-]+)\])?$`)
+
+func parseCompilerStyle(out []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(out), "\n") {
+		m := compilerLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule: m[5], Severity: m[3], Line: atoi(m[1]), Column: atoi(m[2]), Message: m[4],
+		})
+	}
+	return findings
+}
+
+func parseCppcheck(out []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(out), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 5)
+		if len(parts) != 5 {
+			continue
+		}
+		severity := SeverityInfo
+		switch parts[2] {
+		case "error":
+			severity = SeverityError
+		case "warning":
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Rule: parts[3], Severity: severity, Line: atoi(parts[0]), Column: atoi(parts[1]), Message: parts[4],
+		})
+	}
+	return findings
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
@@ -0,0 +1,449 @@
+package metrics
+
+import (
+	"codegrader-backend/internal/similarity"
+)
+
+// unit accumulates the complexity of one function, or of the code outside
+// functions.
+type unit struct {
+	fn     Function
+	lastOp string
+}
+
+func newUnit(name string, line int) *unit {
+	return &unit{fn: Function{Name: name, StartLine: line, EndLine: line, Cyclomatic: 1}}
+}
+
+// branch records a decision point. Cognitive complexity follows the
+// SonarSource definition: structures add 1 plus their nesting level, else
+// branches and changes of boolean operator add 1.
+func (u *unit) branch(cyclomatic, cognitive int) {
+	u.fn.Cyclomatic += cyclomatic
+	u.fn.Cognitive += cognitive
+}
+
+// boolOp counts a boolean operator; a run of the same operator adds one to
+// cognitive complexity.
+func (u *unit) boolOp(op string) {
+	cognitive := 0
+	if op != u.lastOp {
+		cognitive = 1
+	}
+	u.lastOp = op
+	u.branch(1, cognitive)
+}
+
+const (
+	blockOther = iota
+	blockType
+	blockFunction
+	blockLambda
+	blockControl
+)
+
+type block struct {
+	kind  int
+	first string
+	unit  *unit
+}
+
+var (
+	controlKeywords = set("if", "else", "for", "while", "do", "switch", "catch", "when")
+	typeKeywords    = set("class", "struct", "interface", "enum", "namespace", "object", "record", "union")
+	notFunctions    = set("if", "for", "while", "switch", "catch", "when", "try", "return", "new", "sizeof", "typeof", "throw", "synchronized", "using", "with", "super", "this")
+	// Tokens after "?" that make it part of an optional or nullable type, a
+	// safe call or an elvis operator rather than a conditional expression.
+	notTernary = set(".", ":", "?", ")", ",", "=", ">", ";", "]", "{", "}")
+)
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// walkBraces measures C, C++, Java, JavaScript, TypeScript and Kotlin by
+// their braces. Control structures without braces add complexity but no
+// nesting.
+func walkBraces(tokens []similarity.Token) []*unit {
+	top := newUnit("", 1)
+	units := []*unit{top}
+	var stack []block
+	headerStart := 0
+	lastClosed := ""
+
+	current := func() *unit {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].kind == blockFunction {
+				return stack[i].unit
+			}
+		}
+		return top
+	}
+	nesting := func() int {
+		n := 0
+		for i := len(stack) - 1; i >= 0 && stack[i].kind != blockFunction; i-- {
+			if stack[i].kind == blockControl || stack[i].kind == blockLambda {
+				n++
+			}
+		}
+		return n
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i].Text
+		u := current()
+		switch t {
+		case "{":
+			header := texts(tokens[headerStart:i])
+			b := block{kind: classify(header, u != top)}
+			if len(header) > 0 {
+				b.first = header[0]
+			}
+			if b.kind == blockFunction {
+				b.unit = newUnit(functionName(header), functionLine(tokens[headerStart:i+1]))
+				units = append(units, b.unit)
+			}
+			stack = append(stack, b)
+			if b.kind == blockControl || b.kind == blockLambda {
+				u.fn.Nesting = max(u.fn.Nesting, nesting())
+			}
+			headerStart = i + 1
+			u.lastOp = ""
+		case "}":
+			if len(stack) > 0 {
+				b := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if b.kind == blockFunction {
+					b.unit.fn.EndLine = tokens[i].Line
+				}
+				lastClosed = b.first
+			}
+			headerStart = i + 1
+			u.lastOp = ""
+		case ";":
+			headerStart = i + 1
+			u.lastOp = ""
+		case "if":
+			if i > 0 && tokens[i-1].Text == "else" {
+				u.branch(1, 0)
+			} else {
+				u.branch(1, 1+nesting())
+			}
+		case "else":
+			// Kotlin's `else ->` is the default branch of a when.
+			if i+2 < len(tokens) && tokens[i+1].Text == "-" && tokens[i+2].Text == ">" {
+				continue
+			}
+			u.branch(0, 1)
+		case "while":
+			// The condition of a do-while loop was counted at "do".
+			if i > 0 && tokens[i-1].Text == "}" && lastClosed == "do" {
+				continue
+			}
+			u.branch(1, 1+nesting())
+		case "for", "do", "catch":
+			u.branch(1, 1+nesting())
+		case "switch":
+			u.branch(0, 1+nesting())
+		case "when":
+			u.branch(1, 1+nesting())
+		case "case":
+			u.branch(1, 0)
+		case "?":
+			if i+1 < len(tokens) && !notTernary[tokens[i+1].Text] {
+				u.branch(1, 1+nesting())
+			}
+		case "&", "|":
+			if adjacent(tokens, i) && tokens[i+1].Text == t {
+				u.boolOp(t)
+				i++
+			}
+		}
+	}
+
+	if len(tokens) > 0 {
+		for _, b := range stack {
+			if b.kind == blockFunction {
+				b.unit.fn.EndLine = tokens[len(tokens)-1].Line
+			}
+		}
+	}
+	return units
+}
+
+// classify decides what the block opened after header is. Lambdas,
+// anonymous functions and trailing lambdas inside a function nest like
+// control structures instead of counting as functions of their own.
+func classify(header []string, inFunction bool) int {
+	if len(header) == 0 {
+		return blockOther
+	}
+	// Type keywords count before the first parenthesis and outside generic
+	// parameters, so `template <class T> T max(T a, T b)` is a function.
+	depth := 0
+	for _, t := range header {
+		if t == "(" {
+			break
+		}
+		switch {
+		case t == "<":
+			depth++
+		case t == ">":
+			depth = max(depth-1, 0)
+		case depth == 0 && typeKeywords[t]:
+			return blockType
+		}
+	}
+
+	lambda := false
+	named := false
+	for i, t := range header {
+		switch {
+		case t == "fun":
+			named = true
+		case t == "function":
+			named = i+1 < len(header) && isIdentifier(header[i+1]) && header[i+1] != "("
+			lambda = !named
+		case t == ">" && i > 0 && (header[i-1] == "=" || header[i-1] == "-"):
+			lambda = true
+		case t == "(" && i > 0 && header[i-1] == "]":
+			lambda = true
+		}
+	}
+	switch {
+	case lambda && inFunction:
+		return blockLambda
+	case lambda || named:
+		return blockFunction
+	case controlKeywords[header[0]] || hasControl(header):
+		return blockControl
+	case declarationParen(header) >= 0 && inFunction:
+		// A call with a trailing lambda, such as Kotlin's `repeat(3) {`.
+		return blockLambda
+	case declarationParen(header) >= 0:
+		return blockFunction
+	default:
+		return blockOther
+	}
+}
+
+// hasControl finds a control keyword outside parentheses, as in Kotlin's
+// `val x = if (cond) {`.
+func hasControl(header []string) bool {
+	depth := 0
+	for _, t := range header {
+		switch {
+		case t == "(":
+			depth++
+		case t == ")":
+			depth--
+		case depth == 0 && controlKeywords[t]:
+			return true
+		}
+	}
+	return false
+}
+
+// declarationParen returns the index of the parameter list of a function
+// or method declaration header such as `static int sum(int a, int b)`, or
+// -1 when header is a call, an initializer or a control statement.
+func declarationParen(header []string) int {
+	for i, t := range header {
+		switch t {
+		case "=":
+			return -1
+		case "(":
+			if i == 0 || !isIdentifier(header[i-1]) || notFunctions[header[i-1]] {
+				return -1
+			}
+			if i >= 2 && header[i-2] == "new" {
+				return -1
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// functionLine is the line a function starts on: that of its name, so that
+// preprocessor lines and annotations before it are not counted. tokens end
+// with the opening brace.
+func functionLine(tokens []similarity.Token) int {
+	header := texts(tokens[:len(tokens)-1])
+	for i, t := range header {
+		if t == "function" || t == "fun" {
+			return tokens[i].Line
+		}
+	}
+	if i := declarationParen(header); i > 0 {
+		return tokens[i-1].Line
+	}
+	return tokens[len(tokens)-1].Line
+}
+
+func functionName(header []string) string {
+	for i, t := range header {
+		if t == "function" || t == "fun" {
+			if i+1 < len(header) && isIdentifier(header[i+1]) {
+				return header[i+1]
+			}
+		}
+	}
+	if i := declarationParen(header); i > 0 {
+		return header[i-1]
+	}
+	// const name = (...) => {
+	for i, t := range header {
+		if t == "=" && i > 0 && isIdentifier(header[i-1]) {
+			return header[i-1]
+		}
+	}
+	return "<anonymous>"
+}
+
+func isIdentifier(token string) bool {
+	if token == "" {
+		return false
+	}
+	for i, r := range token {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= 0x80 || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// adjacent reports whether tokens[i+1] directly follows tokens[i], as the
+// halves of "&&" do.
+func adjacent(tokens []similarity.Token, i int) bool {
+	return i+1 < len(tokens) && tokens[i+1].Line == tokens[i].Line && tokens[i+1].Column == tokens[i].Column+len([]rune(tokens[i].Text))
+}
+
+func texts(tokens []similarity.Token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Text
+	}
+	return out
+}
+
+type pythonEntry struct {
+	indent int
+	kind   int
+	unit   *unit
+}
+
+// walkPython measures Python by indentation.
+func walkPython(tokens []similarity.Token) []*unit {
+	top := newUnit("", 1)
+	units := []*unit{top}
+	var stack []pythonEntry
+
+	current := func() *unit {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].kind == blockFunction {
+				return stack[i].unit
+			}
+		}
+		return top
+	}
+	nesting := func() int {
+		n := 0
+		for i := len(stack) - 1; i >= 0 && stack[i].kind != blockFunction; i-- {
+			if stack[i].kind == blockControl {
+				n++
+			}
+		}
+		return n
+	}
+
+	lastLine := 0
+	for _, line := range similarity.PythonLogicalLines(tokens) {
+		if len(line) == 0 {
+			continue
+		}
+		indent := line[0].Column
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			if e := stack[len(stack)-1]; e.kind == blockFunction {
+				e.unit.fn.EndLine = lastLine
+			}
+			stack = stack[:len(stack)-1]
+		}
+
+		words := texts(line)
+		if words[0] == "async" && len(words) > 1 {
+			words = words[1:]
+		}
+		u := current()
+		u.lastOp = ""
+		level := nesting()
+
+		for i, w := range words {
+			switch w {
+			case "if", "while", "for":
+				if i == 0 {
+					u.branch(1, 1+level)
+				} else {
+					// Conditional expressions and comprehensions.
+					u.branch(1, 1)
+				}
+			case "elif":
+				u.branch(1, 1)
+			case "else":
+				if i == 0 {
+					u.branch(0, 1)
+				}
+			case "except":
+				u.branch(1, 1+level)
+			case "match":
+				if i == 0 {
+					u.branch(0, 1+level)
+				}
+			case "case":
+				if i == 0 {
+					u.branch(1, 0)
+				}
+			case "and", "or":
+				u.boolOp(w)
+			}
+		}
+
+		if words[len(words)-1] == ":" {
+			switch words[0] {
+			case "def":
+				name := "<anonymous>"
+				if len(words) > 1 {
+					name = words[1]
+				}
+				fn := newUnit(name, line[0].Line)
+				units = append(units, fn)
+				stack = append(stack, pythonEntry{indent: indent, kind: blockFunction, unit: fn})
+			case "class":
+				stack = append(stack, pythonEntry{indent: indent, kind: blockType})
+			case "if", "elif", "else", "for", "while", "except", "match", "case":
+				stack = append(stack, pythonEntry{indent: indent, kind: blockControl})
+				u.fn.Nesting = max(u.fn.Nesting, level+1)
+			default:
+				stack = append(stack, pythonEntry{indent: indent, kind: blockOther})
+			}
+		}
+		last := line[len(line)-1]
+		lastLine = last.Line
+		for _, r := range last.Text {
+			if r == '\n' {
+				lastLine++
+			}
+		}
+	}
+
+	for _, e := range stack {
+		if e.kind == blockFunction {
+			e.unit.fn.EndLine = lastLine
+		}
+	}
+	return units
+}
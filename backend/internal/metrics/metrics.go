@@ -0,0 +1,218 @@
+// Package metrics measures submitted code: size, comments, functions, their
+// cyclomatic and cognitive complexity, nesting depth and repeated code. The
+// numbers give the grading prompt an objective basis for the structure and
+// efficiency criteria. Like package structure, the analysis is best effort
+// and never fails on code it does not understand.
+package metrics
+
+import (
+	"strings"
+
+	"codegrader-backend/internal/similarity"
+)
+
+// Function describes one function or method. Lines are 1-based and
+// inclusive; Nesting is the deepest nesting of control structures inside.
+type Function struct {
+	Name       string `json:"name"`
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Length     int    `json:"length"`
+	Cyclomatic int    `json:"cyclomatic"`
+	Cognitive  int    `json:"cognitive"`
+	Nesting    int    `json:"nesting"`
+}
+
+// Metrics of one file. Cyclomatic, Cognitive and MaxNesting are maxima over
+// the functions and the code outside them. Duplication is the share of code
+// lines that belong to a block of at least DuplicateWindow lines repeated
+// elsewhere in the file.
+type Metrics struct {
+	Lines             int        `json:"lines"`
+	CodeLines         int        `json:"code_lines"`
+	CommentLines      int        `json:"comment_lines"`
+	CommentRatio      float64    `json:"comment_ratio"`
+	FunctionCount     int        `json:"function_count"`
+	AvgFunctionLength float64    `json:"avg_function_length"`
+	MaxFunctionLength int        `json:"max_function_length"`
+	Cyclomatic        int        `json:"cyclomatic"`
+	Cognitive         int        `json:"cognitive"`
+	MaxNesting        int        `json:"max_nesting"`
+	DuplicatedLines   int        `json:"duplicated_lines"`
+	Duplication       float64    `json:"duplication"`
+	Functions         []Function `json:"functions"`
+}
+
+// DuplicateWindow is the number of consecutive significant lines that have
+// to repeat to count as duplication.
+const DuplicateWindow = 4
+
+// Compute measures code written in the language of fileType.
+func Compute(code, fileType string) *Metrics {
+	tokens := similarity.Scan(code, fileType)
+	m := &Metrics{Lines: physicalLines(code)}
+	countLines(m, code, tokens, fileType)
+
+	var units []*unit
+	if fileType == ".py" {
+		units = walkPython(tokens)
+	} else {
+		units = walkBraces(tokens)
+	}
+	summarize(m, units)
+
+	m.DuplicatedLines = duplicatedLines(tokens)
+	if m.CodeLines > 0 {
+		m.Duplication = float64(m.DuplicatedLines) / float64(m.CodeLines)
+	}
+	return m
+}
+
+// CommentPercent is CommentRatio in whole percent, for prompt templates.
+func (m *Metrics) CommentPercent() int {
+	return int(m.CommentRatio*100 + 0.5)
+}
+
+// DuplicationPercent is Duplication in whole percent.
+func (m *Metrics) DuplicationPercent() int {
+	return int(m.Duplication*100 + 0.5)
+}
+
+// Longest returns the longest function, or nil without functions.
+func (m *Metrics) Longest() *Function {
+	return m.maxBy(func(f *Function) int { return f.Length })
+}
+
+// MostComplex returns the function with the highest cognitive complexity.
+func (m *Metrics) MostComplex() *Function {
+	return m.maxBy(func(f *Function) int { return f.Cognitive })
+}
+
+func (m *Metrics) maxBy(value func(*Function) int) *Function {
+	var best *Function
+	for i := range m.Functions {
+		if best == nil || value(&m.Functions[i]) > value(best) {
+			best = &m.Functions[i]
+		}
+	}
+	return best
+}
+
+func physicalLines(code string) int {
+	code = strings.TrimRight(code, "\n")
+	if strings.TrimSpace(code) == "" {
+		return 0
+	}
+	return strings.Count(code, "\n") + 1
+}
+
+// countLines splits the non-blank lines into code and comments. Python
+// docstrings, strings that stand alone on their lines, count as comments.
+func countLines(m *Metrics, code string, tokens []similarity.Token, fileType string) {
+	codeLines := make(map[int]bool)
+	for i, t := range tokens {
+		last := t.Line + strings.Count(t.Text, "\n")
+		if fileType == ".py" && isString(t.Text) &&
+			(i == 0 || tokens[i-1].Line < t.Line) &&
+			(i+1 == len(tokens) || tokens[i+1].Line > last) {
+			continue
+		}
+		for line := t.Line; line <= last; line++ {
+			codeLines[line] = true
+		}
+	}
+
+	for i, line := range strings.Split(code, "\n") {
+		if i >= m.Lines || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if codeLines[i+1] {
+			m.CodeLines++
+		} else {
+			m.CommentLines++
+		}
+	}
+	if total := m.CodeLines + m.CommentLines; total > 0 {
+		m.CommentRatio = float64(m.CommentLines) / float64(total)
+	}
+}
+
+func isString(text string) bool {
+	return strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") || strings.HasPrefix(text, "`")
+}
+
+func summarize(m *Metrics, units []*unit) {
+	total := 0
+	for i, u := range units {
+		m.Cyclomatic = max(m.Cyclomatic, u.fn.Cyclomatic)
+		m.Cognitive = max(m.Cognitive, u.fn.Cognitive)
+		m.MaxNesting = max(m.MaxNesting, u.fn.Nesting)
+		// The first unit is the code outside functions.
+		if i == 0 {
+			continue
+		}
+		u.fn.Length = u.fn.EndLine - u.fn.StartLine + 1
+		m.Functions = append(m.Functions, u.fn)
+		m.MaxFunctionLength = max(m.MaxFunctionLength, u.fn.Length)
+		total += u.fn.Length
+	}
+	m.FunctionCount = len(m.Functions)
+	if m.FunctionCount > 0 {
+		m.AvgFunctionLength = float64(total) / float64(m.FunctionCount)
+	}
+}
+
+// duplicatedLines counts the lines covered by windows of DuplicateWindow
+// significant lines that occur more than once. Lines are compared token by
+// token, so formatting and comments do not matter.
+func duplicatedLines(tokens []similarity.Token) int {
+	type line struct {
+		number int
+		text   string
+	}
+	var lines []line
+	for i := 0; i < len(tokens); {
+		j := i
+		var parts []string
+		for j < len(tokens) && tokens[j].Line == tokens[i].Line {
+			parts = append(parts, tokens[j].Text)
+			j++
+		}
+		if significant(parts) {
+			lines = append(lines, line{number: tokens[i].Line, text: strings.Join(parts, " ")})
+		}
+		i = j
+	}
+
+	seen := make(map[string]int)
+	duplicated := make(map[int]bool)
+	for i := 0; i+DuplicateWindow <= len(lines); i++ {
+		parts := make([]string, DuplicateWindow)
+		for k := range parts {
+			parts[k] = lines[i+k].text
+		}
+		key := strings.Join(parts, "\n")
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = i
+			continue
+		}
+		for k := 0; k < DuplicateWindow; k++ {
+			duplicated[lines[first+k].number] = true
+			duplicated[lines[i+k].number] = true
+		}
+	}
+	return len(duplicated)
+}
+
+// significant reports whether a line does more than close a block or a
+// statement.
+func significant(tokens []string) bool {
+	words := 0
+	for _, t := range tokens {
+		if !strings.ContainsAny(t, "{}()[];,:") {
+			words++
+		}
+	}
+	return words >= 2
+}
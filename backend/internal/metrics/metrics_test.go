@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pythonSample = `"""Сумма чётных чисел."""
+import sys
+
+
+def read():
+    # читаем ввод
+    return [int(x) for x in sys.stdin.read().split()]
+
+
+def even_sum(numbers):
+    total = 0
+    for n in numbers:
+        if n % 2 == 0 and n > 0:
+            total += n
+        elif n < 0 or n > 100:
+            continue
+        else:
+            pass
+    return total
+
+
+print(even_sum(read()))
+`
+
+func TestCompute_Python(t *testing.T) {
+	m := Compute(pythonSample, ".py")
+
+	assert.Equal(t, 22, m.Lines)
+	assert.Equal(t, 2, m.CommentLines)
+	assert.Equal(t, 14, m.CodeLines)
+	require.Equal(t, 2, m.FunctionCount)
+
+	read := m.Functions[0]
+	assert.Equal(t, "read", read.Name)
+	assert.Equal(t, 5, read.StartLine)
+	assert.Equal(t, 7, read.EndLine)
+	assert.Equal(t, 2, read.Cyclomatic)
+
+	even := m.Functions[1]
+	assert.Equal(t, "even_sum", even.Name)
+	assert.Equal(t, 10, even.StartLine)
+	assert.Equal(t, 19, even.EndLine)
+	// for, if, and, elif, or.
+	assert.Equal(t, 6, even.Cyclomatic)
+	// for +1, if +2, and +1, elif +1, or +1, else +1.
+	assert.Equal(t, 7, even.Cognitive)
+	assert.Equal(t, 2, even.Nesting)
+	assert.Equal(t, 7, m.Cognitive)
+	assert.Equal(t, 10, m.MaxFunctionLength)
+	assert.Equal(t, "even_sum", m.MostComplex().Name)
+}
+
+const javaSample = `import java.util.*;
+
+/**
+ * Решение задачи.
+ */
+public class Main {
+    @Override
+    public String toString() {
+        return "Main";
+    }
+
+    static int max(int[] a) {
+        int best = a[0];
+        for (int x : a) {
+            if (x > best) {
+                best = x;
+            } else if (x == best && x != 0) {
+                continue;
+            }
+        }
+        do {
+            best--;
+        } while (best > 100);
+        return best > 0 ? best : 0;
+    }
+}
+`
+
+func TestCompute_Java(t *testing.T) {
+	m := Compute(javaSample, ".java")
+
+	require.Equal(t, 2, m.FunctionCount)
+	assert.Equal(t, "toString", m.Functions[0].Name)
+	assert.Equal(t, 8, m.Functions[0].StartLine)
+	assert.Equal(t, 10, m.Functions[0].EndLine)
+
+	max := m.Functions[1]
+	assert.Equal(t, "max", max.Name)
+	assert.Equal(t, 12, max.StartLine)
+	assert.Equal(t, 25, max.EndLine)
+	// for, if, else if, &&, do, ?:.
+	assert.Equal(t, 7, max.Cyclomatic)
+	// for +1, if +2, else +1, && +1, do +1, ?: +1.
+	assert.Equal(t, 7, max.Cognitive)
+	assert.Equal(t, 2, max.Nesting)
+	assert.Equal(t, 3, m.CommentLines)
+}
+
+func TestCompute_JavaScriptArrowFunctions(t *testing.T) {
+	code := `const add = (a, b) => {
+  return a + b;
+};
+
+function main() {
+  [1, 2].forEach((x) => {
+    if (x) {
+      console.log(add(x, x));
+    }
+  });
+}
+`
+	m := Compute(code, ".js")
+
+	require.Equal(t, 2, m.FunctionCount)
+	assert.Equal(t, "add", m.Functions[0].Name)
+	assert.Equal(t, "main", m.Functions[1].Name)
+	// The callback nests the if instead of being a function of its own.
+	assert.Equal(t, 2, m.Functions[1].Cognitive)
+	assert.Equal(t, 2, m.Functions[1].Nesting)
+}
+
+func TestCompute_KotlinNullableTypesAreNotBranches(t *testing.T) {
+	code := `fun main() {
+    val s: String? = readLine()
+    val n = s?.length ?: 0
+    repeat(n) {
+        println(n)
+    }
+}
+`
+	m := Compute(code, ".kt")
+
+	require.Equal(t, 1, m.FunctionCount)
+	assert.Equal(t, 1, m.Functions[0].Cyclomatic)
+	assert.Equal(t, 0, m.Functions[0].Cognitive)
+}
+
+func TestCompute_CPreprocessorLinesAreNotPartOfFunction(t *testing.T) {
+	code := "#include <stdio.h>\n\nint main(void) {\n    printf(\"hi\");\n    return 0;\n}\n"
+
+	m := Compute(code, ".c")
+
+	require.Equal(t, 1, m.FunctionCount)
+	assert.Equal(t, 3, m.Functions[0].StartLine)
+	assert.Equal(t, 4, m.Functions[0].Length)
+}
+
+func TestCompute_Duplication(t *testing.T) {
+	code := `int a = read();
+int b = read();
+total += a * b;
+print(total);
+reset(a, b);
+int a = read();
+int b = read();
+total += a * b;
+print(total);
+`
+	m := Compute(code, ".c")
+
+	assert.Equal(t, 8, m.DuplicatedLines)
+	assert.InDelta(t, 8.0/9, m.Duplication, 1e-9)
+	assert.Equal(t, 89, m.DuplicationPercent())
+}
+
+func TestCompute_EmptyCode(t *testing.T) {
+	m := Compute("\n\n", ".py")
+
+	assert.Equal(t, 0, m.Lines)
+	assert.Zero(t, m.FunctionCount)
+	assert.Nil(t, m.Longest())
+}
+
+func TestCompute_PythonBareBackslash(t *testing.T) {
+	for _, code := range []string{"\\", "print(1)\n\\", "def f():\n    return 1 + \\\n        2\n\\"} {
+		assert.NotPanics(t, func() { Compute(code, ".py") }, "%q", code)
+	}
+
+	m := Compute("def f():\n    return 1 + \\\n        2\n", ".py")
+	assert.Equal(t, 1, m.FunctionCount)
+}
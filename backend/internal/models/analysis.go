@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// AnalysisCacheEntry stores a grading result for code that is identical up
+// to formatting, so resubmits get the same grade without a new LLM call.
+// Annotations point at lines of the exact text hashed in SourceHash and are
+// only reused for that text.
+type AnalysisCacheEntry struct {
+	ContentHash   string               `gorm:"primaryKey;size:64"`
+	FileType      string               `gorm:"primaryKey;size:16"`
+	AssignmentID  string               `gorm:"primaryKey;size:64"`
+	PromptVersion string               `gorm:"primaryKey;size:32"`
+	Model         string               `gorm:"primaryKey;size:128"`
+	Grade         int                  `gorm:"not null"`
+	Feedback      string               `gorm:"type:text"`
+	Annotations   []Annotation         `gorm:"type:text;serializer:json"`
+	SourceHash    string               `gorm:"size:64"`
+	ReviewReasons string               `gorm:"size:255"`
+	Confidence    float64              `gorm:"not null;default:0"`
+	Samples       string               `gorm:"size:64"`
+	Comparison    *ReferenceComparison `gorm:"type:text;serializer:json"`
+	CreatedAt     time.Time            `gorm:"autoCreateTime"`
+}
+
+func (AnalysisCacheEntry) TableName() string {
+	return "analysis_cache"
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Annotation is a review remark tied to a line range of a submission's file.
+// Lines are 1-based and inclusive. Source names the linter that reported
+// it and is empty for remarks of the model.
+type Annotation struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	SubmissionID string    `json:"submission_id" gorm:"index;size:64;not null"`
+	File         string    `json:"file" gorm:"size:255"`
+	StartLine    int       `json:"start_line" gorm:"not null"`
+	EndLine      int       `json:"end_line" gorm:"not null"`
+	Severity     string    `json:"severity" gorm:"size:16;not null"`
+	Category     string    `json:"category" gorm:"size:32;not null"`
+	Message      string    `json:"message" gorm:"type:text;not null"`
+	Suggestion   string    `json:"suggestion,omitempty" gorm:"type:text"`
+	Source       string    `json:"source,omitempty" gorm:"size:32"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// AnnotationCategories are the categories the model is asked to use; others
+// are stored as "other".
+var AnnotationCategories = []string{"correctness", "style", "readability", "performance", "security", "structure", "other"}
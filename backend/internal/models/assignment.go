@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"codegrader-backend/internal/grading"
+)
+
+// Assignment carries the task statement, rubric and an optional override of
+// the analysis prompt and grading scale. Version is bumped on every change so that cached
+// grades produced under the old settings are not reused. Reference solutions
+// are shown to the model only, never to students: they are not part of the
+// assignment's JSON and are managed through ReferenceSolutionsRequest.
+type Assignment struct {
+	ID                 string              `json:"id" gorm:"primaryKey;size:64"`
+	CourseID           string              `json:"course_id,omitempty" gorm:"index"`
+	Title              string              `json:"title"`
+	TaskStatement      string              `json:"task_statement" gorm:"type:text"`
+	Rubric             string              `json:"rubric" gorm:"type:text"`
+	AnalysisPrompt     string              `json:"analysis_prompt,omitempty" gorm:"type:text"`
+	GradingScale       *grading.Scale      `json:"grading_scale,omitempty" gorm:"type:text;serializer:json"`
+	ReferenceSolutions []ReferenceSolution `json:"-" gorm:"type:text;serializer:json"`
+	Version            int                 `json:"version" gorm:"not null;default:0"`
+	CreatedAt          time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type AssignmentRequest struct {
+	CourseID       string         `json:"course_id"`
+	Title          string         `json:"title"`
+	TaskStatement  string         `json:"task_statement"`
+	Rubric         string         `json:"rubric"`
+	AnalysisPrompt string         `json:"analysis_prompt"`
+	GradingScale   *grading.Scale `json:"grading_scale"`
+}
+
+type ReferenceSolutionsRequest struct {
+	ReferenceSolutions []ReferenceSolution `json:"reference_solutions"`
+}
+
+// ReferenceSolution is a teacher's solution of an assignment. FileType may
+// differ from the submission's language.
+type ReferenceSolution struct {
+	Name     string `json:"name,omitempty"`
+	FileType string `json:"file_type"`
+	Content  string `json:"content"`
+}
+
+// ReferenceComparison is the model's comparison of a submission with the
+// reference solutions of its assignment. It is meant for teachers only.
+type ReferenceComparison struct {
+	Closest    string `json:"closest,omitempty"`
+	Functional string `json:"functional,omitempty"`
+	Approach   string `json:"approach,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ConversationRoleStudent   = "student"
+	ConversationRoleAssistant = "assistant"
+)
+
+// ConversationMessage is one message of the follow-up conversation about a
+// submission's feedback.
+type ConversationMessage struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	SubmissionID string    `json:"submission_id" gorm:"size:64;not null;index"`
+	Role         string    `json:"role" gorm:"size:16;not null"`
+	Content      string    `json:"content" gorm:"type:text;not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ConversationRequest struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"codegrader-backend/internal/grading"
+)
+
+const (
+	QuotaActionQueue  = "queue"
+	QuotaActionReject = "reject"
+)
+
+// Course holds per-course settings. Submissions may reference a course that
+// has no row here; the configured defaults apply to it.
+type Course struct {
+	ID               string         `json:"id" gorm:"primaryKey;size:64"`
+	Name             string         `json:"name"`
+	MonthlyBudgetUSD float64        `json:"monthly_budget_usd"`
+	QuotaAction      string         `json:"quota_action" gorm:"size:16;not null;default:queue"`
+	Locale           string         `json:"locale,omitempty" gorm:"size:8"`
+	GradingScale     *grading.Scale `json:"grading_scale,omitempty" gorm:"type:text;serializer:json"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type CourseRequest struct {
+	Name             string         `json:"name"`
+	MonthlyBudgetUSD float64        `json:"monthly_budget_usd"`
+	QuotaAction      string         `json:"quota_action"`
+	Locale           string         `json:"locale"`
+	GradingScale     *grading.Scale `json:"grading_scale"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vector maps to the pgvector "vector" column type using its text format.
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
+
+func (v *Vector) Scan(src interface{}) error {
+	var s string
+	switch value := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		s = value
+	case []byte:
+		s = string(value)
+	default:
+		return fmt.Errorf("cannot scan %T into Vector", src)
+	}
+
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+	if s == "" {
+		*v = Vector{}
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	result := make(Vector, len(parts))
+	for i, part := range parts {
+		x, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return fmt.Errorf("invalid vector component %q: %w", part, err)
+		}
+		result[i] = float32(x)
+	}
+	*v = result
+	return nil
+}
+
+type SubmissionEmbedding struct {
+	SubmissionID string    `json:"submission_id" gorm:"primaryKey"`
+	FileType     string    `json:"file_type" gorm:"not null"`
+	Model        string    `json:"model" gorm:"not null"`
+	Embedding    Vector    `json:"-"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+type SimilarSubmission struct {
+	ID         string    `json:"id"`
+	FileName   string    `json:"file_name"`
+	FileType   string    `json:"file_type"`
+	Grade      int       `json:"grade"`
+	Similarity float64   `json:"similarity"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EvaluationStatusPending   = "pending"
+	EvaluationStatusRunning   = "running"
+	EvaluationStatusCompleted = "completed"
+	EvaluationStatusFailed    = "failed"
+)
+
+// EvaluationRun is one pass of the grading pipeline over a teacher-labelled
+// dataset. Runs created through the API stay pending until the background
+// worker has graded every item; runs started from the CLI are graded in
+// place and marked running meanwhile.
+type EvaluationRun struct {
+	ID            string     `json:"id" gorm:"primaryKey;size:64"`
+	Name          string     `json:"name"`
+	Status        string     `json:"status" gorm:"size:16;not null;index"`
+	PromptVersion string     `json:"prompt_version" gorm:"size:64"`
+	Model         string     `json:"model" gorm:"size:128"`
+	Size          int        `json:"size"`
+	ExactMatch    float64    `json:"exact_match"`
+	MAE           float64    `json:"mae"`
+	Kappa         float64    `json:"kappa"`
+	Labels        []int      `json:"labels,omitempty" gorm:"serializer:json"`
+	Confusion     [][]int    `json:"confusion,omitempty" gorm:"serializer:json"`
+	Error         string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+
+	Items []EvaluationItem `json:"items,omitempty" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE"`
+}
+
+// EvaluationItem is one labelled submission of a run. Grades are values on
+// the scale of the item's assignment or course.
+type EvaluationItem struct {
+	ID            uint   `json:"-" gorm:"primaryKey"`
+	RunID         string `json:"-" gorm:"size:64;not null;index"`
+	Position      int    `json:"position"`
+	FileName      string `json:"file_name"`
+	FileType      string `json:"file_type" gorm:"size:16;not null"`
+	AssignmentID  string `json:"assignment_id,omitempty" gorm:"size:64"`
+	Locale        string `json:"locale,omitempty" gorm:"size:8"`
+	Content       string `json:"-" gorm:"type:text;not null"`
+	TeacherGrade  int    `json:"teacher_grade"`
+	Grade         int    `json:"grade"`
+	Graded        bool   `json:"graded" gorm:"not null;default:false"`
+	PromptVersion string `json:"prompt_version,omitempty" gorm:"size:64"`
+	ReviewReasons string `json:"review_reasons,omitempty" gorm:"size:255"`
+}
+
+type CalibrationSample struct {
+	FileName     string `json:"file_name"`
+	FileType     string `json:"file_type"`
+	AssignmentID string `json:"assignment_id,omitempty"`
+	Locale       string `json:"locale,omitempty"`
+	Content      string `json:"content"`
+	TeacherGrade *int   `json:"teacher_grade"`
+}
+
+type CalibrationRequest struct {
+	Name    string              `json:"name"`
+	Samples []CalibrationSample `json:"samples"`
+}
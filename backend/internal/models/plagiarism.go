@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// PlagiarismMatch links a suspected copy (SubmissionID) to the earlier
+// submission it was matched against (MatchedSubmissionID).
+type PlagiarismMatch struct {
+	ID                   uint      `json:"id" gorm:"primaryKey"`
+	SubmissionID         string    `json:"submission_id" gorm:"index;not null"`
+	MatchedSubmissionID  string    `json:"matched_submission_id" gorm:"index;not null"`
+	TokenSimilarity      float64   `json:"token_similarity"`
+	StructuralSimilarity float64   `json:"structural_similarity"`
+	SemanticSimilarity   float64   `json:"semantic_similarity"`
+	CrossLanguage        bool      `json:"cross_language"`
+	Explanation          string    `json:"explanation" gorm:"type:text"`
+	CreatedAt            time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+type RecheckResponse struct {
+	Checked int `json:"checked"`
+	Matches int `json:"matches"`
+}
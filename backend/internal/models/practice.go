@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"codegrader-backend/internal/metrics"
+)
+
+const (
+	SubmissionModeGraded   = "graded"
+	SubmissionModePractice = "practice"
+)
+
+// PracticeAttempt records that a student asked for practice hints. The code
+// itself is not stored, so practice never reaches the plagiarism corpus.
+type PracticeAttempt struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CourseID     string    `json:"course_id,omitempty" gorm:"index"`
+	AssignmentID string    `json:"assignment_id,omitempty" gorm:"index:idx_practice_attempts_student_assignment,priority:2"`
+	StudentID    string    `json:"student_id" gorm:"not null;index:idx_practice_attempts_student_assignment,priority:1"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime;index:idx_practice_attempts_student_assignment,priority:3"`
+}
+
+// PracticeResponse answers a practice submission: hints and guiding
+// questions instead of a grade. RemainingAttempts is nil without a limit.
+type PracticeResponse struct {
+	Mode              string           `json:"mode"`
+	Hints             []string         `json:"hints"`
+	Questions         []string         `json:"questions"`
+	Metrics           *metrics.Metrics `json:"metrics,omitempty"`
+	RemainingAttempts *int             `json:"remaining_attempts,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"codegrader-backend/internal/style"
+)
+
+// StyleProfile is the running style profile of one student in one language.
+type StyleProfile struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	StudentID string        `json:"student_id" gorm:"size:64;not null;uniqueIndex:idx_style_profiles_student_file_type"`
+	FileType  string        `json:"file_type" gorm:"size:16;not null;uniqueIndex:idx_style_profiles_student_file_type"`
+	Profile   style.Profile `json:"profile" gorm:"type:text;serializer:json"`
+	UpdatedAt time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
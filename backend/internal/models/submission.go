@@ -2,34 +2,110 @@ package models
 
 import (
 	"time"
+
+	"codegrader-backend/internal/aidetect"
+	"codegrader-backend/internal/lint"
+	"codegrader-backend/internal/metrics"
+	"codegrader-backend/internal/style"
+)
+
+const (
+	GradingStatusGraded  = "graded"
+	GradingStatusPending = "pending"
 )
 
 type CodeSubmission struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	FileName  string    `json:"file_name" gorm:"not null"`
-	FileType  string    `json:"file_type" gorm:"not null"`
-	Content   string    `json:"content" gorm:"type:text;not null"`
-	Grade     int       `json:"grade"`
-	Feedback  string    `json:"feedback" gorm:"type:text"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID                   string               `json:"id" gorm:"primaryKey"`
+	FileName             string               `json:"file_name" gorm:"not null"`
+	FileType             string               `json:"file_type" gorm:"not null"`
+	CourseID             string               `json:"course_id,omitempty" gorm:"index"`
+	AssignmentID         string               `json:"assignment_id,omitempty" gorm:"index"`
+	StudentID            string               `json:"student_id,omitempty" gorm:"index"`
+	Content              string               `json:"content" gorm:"type:text;not null"`
+	ContentHash          string               `json:"-" gorm:"size:64;index"`
+	Locale               string               `json:"locale,omitempty" gorm:"size:8"`
+	Grade                int                  `json:"grade"`
+	GradeLabel           string               `json:"grade_label,omitempty" gorm:"size:64"`
+	Passed               bool                 `json:"passed" gorm:"not null;default:false"`
+	Feedback             string               `json:"feedback" gorm:"type:text"`
+	GradeConfidence      float64              `json:"grade_confidence,omitempty"`
+	GradeSamples         string               `json:"grade_samples,omitempty" gorm:"size:64"`
+	GradingStatus        string               `json:"grading_status" gorm:"not null;default:graded;index"`
+	NeedsReview          bool                 `json:"needs_review" gorm:"not null;default:false;index"`
+	ReviewReasons        string               `json:"review_reasons,omitempty" gorm:"size:255"`
+	Redactions           string               `json:"redactions,omitempty" gorm:"size:512"`
+	IsPlagiarism         bool                 `json:"is_plagiarism" gorm:"not null;default:false"`
+	PlagiarismInvolved   bool                 `json:"plagiarism_involved" gorm:"not null;default:false"`
+	PlagiarismCorpusSize int                  `json:"-" gorm:"not null;default:0"`
+	Metrics              *metrics.Metrics     `json:"metrics,omitempty" gorm:"type:text;serializer:json"`
+	LintFindings         []lint.Finding       `json:"lint_findings,omitempty" gorm:"type:text;serializer:json"`
+	AIDetection          *aidetect.Result     `json:"-" gorm:"type:text;serializer:json"`
+	AILikelihood         float64              `json:"-" gorm:"not null;default:0;index"`
+	StyleDeviation       *style.Deviation     `json:"-" gorm:"type:text;serializer:json"`
+	ReferenceComparison  *ReferenceComparison `json:"-" gorm:"type:text;serializer:json"`
+	CreatedAt            time.Time            `json:"created_at" gorm:"autoCreateTime"`
+
+	Embedding Vector `json:"-" gorm:"-"`
 }
 
 type SubmissionRequest struct {
-	FileName string `json:"file_name" validate:"required"`
-	FileType string `json:"file_type" validate:"required,oneof=.cpp .java .js .kt .py"`
-	Content  string `json:"content" validate:"required"`
+	FileName     string `json:"file_name" validate:"required"`
+	FileType     string `json:"file_type" validate:"required,oneof=.c .cpp .java .js .kt .py .ts"`
+	CourseID     string `json:"course_id,omitempty"`
+	AssignmentID string `json:"assignment_id,omitempty"`
+	StudentID    string `json:"student_id,omitempty"`
+	Content      string `json:"content" validate:"required"`
+	// Locale selects the feedback language; when empty the course setting
+	// and then the Accept-Language header of the request are used.
+	Locale         string `json:"locale,omitempty"`
+	AcceptLanguage string `json:"-"`
+	// Mode is SubmissionModeGraded (the default) or SubmissionModePractice.
+	Mode string `json:"mode,omitempty"`
 }
 
 type SubmissionResponse struct {
-	ID       string `json:"id"`
-	Grade    int    `json:"grade"`
-	Feedback string `json:"feedback"`
+	ID              string           `json:"id"`
+	Grade           int              `json:"grade"`
+	GradeLabel      string           `json:"grade_label,omitempty"`
+	Passed          bool             `json:"passed"`
+	Feedback        string           `json:"feedback"`
+	Annotations     []Annotation     `json:"annotations,omitempty"`
+	GradeConfidence float64          `json:"grade_confidence,omitempty"`
+	GradeSamples    string           `json:"grade_samples,omitempty"`
+	GradingStatus   string           `json:"grading_status"`
+	NeedsReview     bool             `json:"needs_review"`
+	ReviewReasons   string           `json:"review_reasons,omitempty"`
+	Redactions      string           `json:"redactions,omitempty"`
+	IsPlagiarism    bool             `json:"is_plagiarism"`
+	PlagiarismMatch *PlagiarismMatch `json:"plagiarism_match,omitempty"`
+	Metrics         *metrics.Metrics `json:"metrics,omitempty"`
 }
 
 type SubmissionListResponse struct {
-	ID        string    `json:"id"`
-	FileName  string    `json:"file_name"`
-	FileType  string    `json:"file_type"`
-	Grade     int       `json:"grade"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                 string `json:"id"`
+	FileName           string `json:"file_name"`
+	FileType           string `json:"file_type"`
+	CourseID           string `json:"course_id,omitempty"`
+	AssignmentID       string `json:"assignment_id,omitempty"`
+	StudentID          string `json:"student_id,omitempty"`
+	Grade              int    `json:"grade"`
+	GradeLabel         string `json:"grade_label,omitempty"`
+	Passed             bool   `json:"passed"`
+	GradingStatus      string `json:"grading_status"`
+	NeedsReview        bool   `json:"needs_review"`
+	PlagiarismInvolved bool   `json:"plagiarism_involved"`
+	// AILikelihood is advisory, see aidetect; AIFlagged marks it as high
+	// enough for the review queue.
+	AILikelihood float64   `json:"ai_likelihood,omitempty"`
+	AIFlagged    bool      `json:"ai_flagged,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SubmissionReview is the teacher's view of a submission: the submission and
+// the advisory signals that are never shown to the student.
+type SubmissionReview struct {
+	*CodeSubmission
+	AIDetection         *aidetect.Result     `json:"ai_detection,omitempty"`
+	StyleDeviation      *style.Deviation     `json:"style_deviation,omitempty"`
+	ReferenceComparison *ReferenceComparison `json:"reference_comparison,omitempty"`
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// LLMUsage is one completed LLM call with the submission it was made for.
+type LLMUsage struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	SubmissionID     string    `json:"submission_id,omitempty" gorm:"index"`
+	CourseID         string    `json:"course_id,omitempty" gorm:"index"`
+	AssignmentID     string    `json:"assignment_id,omitempty" gorm:"index"`
+	StudentID        string    `json:"student_id,omitempty" gorm:"index"`
+	Operation        string    `json:"operation" gorm:"size:32;not null"`
+	Model            string    `json:"model" gorm:"size:128;not null"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (LLMUsage) TableName() string {
+	return "llm_usage"
+}
+
+type UsageFilter struct {
+	From         time.Time
+	To           time.Time
+	CourseID     string
+	AssignmentID string
+	StudentID    string
+}
+
+type UsageSummary struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
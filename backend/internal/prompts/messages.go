@@ -0,0 +1,48 @@
+package prompts
+
+import (
+	"fmt"
+)
+
+// Feedback texts shown to students when the model did not write them.
+const (
+	MsgAnalysisUnavailable = "analysis_unavailable"
+	MsgGradingPending      = "grading_pending"
+	MsgQuotaPending        = "quota_pending"
+	MsgPlagiarismPenalty   = "plagiarism_penalty"
+	MsgExactDuplicate      = "exact_duplicate"
+)
+
+// defaultMessageLocale is the configured default locale, set by Load when
+// there are messages for it.
+var defaultMessageLocale = "ru"
+
+var messages = map[string]map[string]string{
+	"ru": {
+		MsgAnalysisUnavailable: "Автоматический анализ недоступен. Код загружен для ручной проверки.",
+		MsgGradingPending:      "Сервис автоматической проверки временно недоступен. Оценка будет выставлена автоматически после его восстановления.",
+		MsgQuotaPending:        "Лимит автоматических проверок курса на этот месяц исчерпан. Решение поставлено в очередь и будет оценено после пополнения бюджета.",
+		MsgPlagiarismPenalty:   "⚠️ ОБНАРУЖЕН ПЛАГИАТ: Данное решение очень похоже на уже существующее.\n\n%s\n\nСходство: текстовое %.0f%%, структурное %.0f%%.\n\nОригинальная оценка: %s",
+		MsgExactDuplicate:      "Решение полностью совпадает с ранее загруженным решением (без учёта форматирования и комментариев).",
+	},
+	"en": {
+		MsgAnalysisUnavailable: "Automatic analysis is unavailable. The code has been submitted for manual review.",
+		MsgGradingPending:      "The automatic grading service is temporarily unavailable. The grade will be assigned automatically once it recovers.",
+		MsgQuotaPending:        "The course has used up its automatic grading budget for this month. The submission is queued and will be graded once the budget is topped up.",
+		MsgPlagiarismPenalty:   "⚠️ PLAGIARISM DETECTED: This solution is very similar to an existing one.\n\n%s\n\nSimilarity: textual %.0f%%, structural %.0f%%.\n\nOriginal review: %s",
+		MsgExactDuplicate:      "The solution is identical to a previously submitted one (ignoring formatting and comments).",
+	},
+}
+
+// Message returns the text for key in locale, falling back to the default
+// locale given to Load, or to Russian when there are no messages for it.
+func Message(locale, key string, args ...any) string {
+	text, ok := messages[locale][key]
+	if !ok {
+		text = messages[defaultMessageLocale][key]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
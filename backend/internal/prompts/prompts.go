@@ -0,0 +1,204 @@
+// Package prompts loads the LLM prompt templates. Defaults are embedded in
+// the binary; a directory with the same layout (<locale>/<name>.tmpl) can
+// replace any of them without a rebuild.
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	Analysis        = "analysis"
+	AnalysisChunk   = "analysis_chunk"
+	AnalysisSummary = "analysis_summary"
+	Plagiarism      = "plagiarism"
+	AIDetection     = "ai_detection"
+	Practice        = "practice"
+	Conversation    = "conversation"
+	System          = "system"
+
+	partials = "partials"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+// Prompt is a parsed template. Version changes whenever the template text
+// or the partials it uses change, so it can key cached results.
+type Prompt struct {
+	Name    string
+	Locale  string
+	Version string
+	tmpl    *template.Template
+}
+
+func (p *Prompt) Render(data any) (string, error) {
+	var buf bytes.Buffer
+	if err := p.tmpl.ExecuteTemplate(&buf, p.Name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s/%s: %w", p.Locale, p.Name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+type Store struct {
+	defaultLocale string
+	sources       map[string]map[string]string
+	prompts       map[string]map[string]*Prompt
+}
+
+// Load reads the embedded templates and then the files in dir, if set.
+func Load(dir, defaultLocale string) (*Store, error) {
+	s := &Store{
+		defaultLocale: defaultLocale,
+		sources:       make(map[string]map[string]string),
+		prompts:       make(map[string]map[string]*Prompt),
+	}
+
+	sub, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.readSources(sub); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := s.readSources(os.DirFS(dir)); err != nil {
+			return nil, fmt.Errorf("failed to read prompts from %s: %w", dir, err)
+		}
+	}
+
+	for locale, files := range s.sources {
+		s.prompts[locale] = make(map[string]*Prompt)
+		for name, text := range files {
+			if name == partials {
+				continue
+			}
+			prompt, err := s.Parse(name, locale, text)
+			if err != nil {
+				return nil, err
+			}
+			s.prompts[locale][name] = prompt
+		}
+	}
+
+	if _, ok := s.prompts[defaultLocale][Analysis]; !ok {
+		return nil, fmt.Errorf("no %s prompt for default locale %q", Analysis, defaultLocale)
+	}
+	if _, ok := messages[defaultLocale]; ok {
+		defaultMessageLocale = defaultLocale
+	}
+	return s, nil
+}
+
+func (s *Store) readSources(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*/*.tmpl")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		text, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		locale := path.Dir(file)
+		if s.sources[locale] == nil {
+			s.sources[locale] = make(map[string]string)
+		}
+		s.sources[locale][strings.TrimSuffix(path.Base(file), ".tmpl")] = string(text)
+	}
+	return nil
+}
+
+// Parse compiles text as prompt name for locale together with the locale's
+// partials. It is also used for per-assignment overrides.
+func (s *Store) Parse(name, locale, text string) (*Prompt, error) {
+	locale = s.Locale(locale)
+	shared := s.sources[locale][partials]
+
+	tmpl := template.New(name).Option("missingkey=error")
+	if _, err := tmpl.Parse(text); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s/%s: %w", locale, name, err)
+	}
+	if shared != "" {
+		if _, err := tmpl.New(partials).Parse(shared); err != nil {
+			return nil, fmt.Errorf("failed to parse %s/%s: %w", locale, partials, err)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(text + "\x00" + shared))
+	return &Prompt{
+		Name:    name,
+		Locale:  locale,
+		Version: hex.EncodeToString(sum[:])[:12],
+		tmpl:    tmpl,
+	}, nil
+}
+
+// Get returns the prompt for locale, falling back to the default locale when
+// the prompt has not been translated.
+func (s *Store) Get(name, locale string) (*Prompt, error) {
+	if prompt, ok := s.prompts[s.Locale(locale)][name]; ok {
+		return prompt, nil
+	}
+	if prompt, ok := s.prompts[s.defaultLocale][name]; ok {
+		return prompt, nil
+	}
+	return nil, fmt.Errorf("unknown prompt %q", name)
+}
+
+// Locale returns locale if prompts exist for it, otherwise the default.
+func (s *Store) Locale(locale string) string {
+	if _, ok := s.sources[locale]; ok && locale != "" {
+		return locale
+	}
+	return s.defaultLocale
+}
+
+func (s *Store) Locales() []string {
+	locales := make([]string, 0, len(s.sources))
+	for locale := range s.sources {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// MatchLocale picks the best supported locale from an Accept-Language header,
+// returning "" when none of the listed languages is supported.
+func (s *Store) MatchLocale(acceptLanguage string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if _, err := fmt.Sscanf(value, "%g", &q); err != nil {
+				continue
+			}
+		}
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := s.sources[base]; ok && q > 0 {
+			candidates = append(candidates, candidate{locale: base, q: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0].locale
+}
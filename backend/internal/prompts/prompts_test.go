@@ -0,0 +1,166 @@
+package prompts
+
+import (
+	"testing"
+
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/lint"
+	"codegrader-backend/internal/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_RendersLocalizedPrompts(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+
+	data := map[string]any{"Language": "Go", "Code": "package main", "Rubric": "", "TaskStatement": "Sum two numbers", "Scale": grading.Default(), "Metrics": nil, "Lint": nil, "LintOmitted": 0, "References": nil}
+
+	ru, err := store.Get(Analysis, "ru")
+	require.NoError(t, err)
+	text, err := ru.Render(data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Оценка:")
+	assert.Contains(t, text, "Sum two numbers")
+	assert.Contains(t, text, "Замечания:")
+
+	en, err := store.Get(Analysis, "en")
+	require.NoError(t, err)
+	text, err = en.Render(data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Grade:")
+	assert.Contains(t, text, "Annotations:")
+	assert.NotEqual(t, ru.Version, en.Version)
+}
+
+func TestStore_RendersGradingScale(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+	ru, err := store.Get(Analysis, "ru")
+	require.NoError(t, err)
+
+	data := map[string]any{"Language": "Go", "Code": "package main", "Rubric": "", "TaskStatement": "", "Scale": grading.Default(), "Metrics": nil, "Lint": nil, "LintOmitted": 0, "References": nil}
+	text, err := ru.Render(data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Выставь оценку от 3 до 5 баллов и дай")
+	assert.Contains(t, text, "Оценка: [число от 3 до 5]")
+
+	data["Scale"], err = grading.Parse("pass_fail")
+	require.NoError(t, err)
+	text, err = ru.Render(data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Выставь одну из оценок: «не зачтено», «зачтено» и дай")
+	assert.Contains(t, text, "Оценка: [не зачтено | зачтено]")
+}
+
+func TestStore_RendersMetrics(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+	ru, err := store.Get(Analysis, "ru")
+	require.NoError(t, err)
+
+	code := "def f(x):\n    if x:\n        return 1\n    return 0\n"
+	data := map[string]any{"Language": "Python", "Code": code, "Rubric": "", "TaskStatement": "", "Scale": grading.Default(), "Metrics": metrics.Compute(code, ".py"), "Lint": nil, "LintOmitted": 0, "References": nil}
+	text, err := ru.Render(data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "- строк кода: 4, доля комментариев: 0%")
+	assert.Contains(t, text, "самая длинная f — 4 строк")
+	assert.Contains(t, text, "когнитивная: 1 (сложнее всего f)")
+	assert.Contains(t, text, "повторяющийся код: 0% строк\n\nВыставь оценку")
+}
+
+func TestStore_RendersLintFindings(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+	en, err := store.Get(Analysis, "en")
+	require.NoError(t, err)
+
+	findings := []lint.Finding{{Tool: "flake8", Rule: "E501", Line: 3, Message: "line too long"}}
+	data := map[string]any{"Language": "Python", "Code": "x = 1", "Rubric": "", "TaskStatement": "", "Scale": grading.Default(), "Metrics": nil, "Lint": findings, "LintOmitted": 2, "References": nil, "Fence": "F"}
+	text, err := en.Render(data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "<<<F\n- line 3: flake8 E501: line too long\n- and 2 more\nF>>>\n\nGive a grade")
+}
+
+func TestStore_MatchLocale(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+
+	assert.Equal(t, "en", store.MatchLocale("en-US,en;q=0.9,ru;q=0.8"))
+	assert.Equal(t, "ru", store.MatchLocale("de-DE, ru;q=0.5"))
+	assert.Equal(t, "", store.MatchLocale("de-DE"))
+	assert.Equal(t, "ru", store.Locale("de"))
+}
+
+func TestStore_RendersReferenceSolutions(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+	ru, err := store.Get(Analysis, "ru")
+	require.NoError(t, err)
+
+	data := map[string]any{"Language": "Python", "Code": "x = 1", "Rubric": "", "TaskStatement": "", "Scale": grading.Default(), "Metrics": nil, "Lint": nil, "LintOmitted": 0, "References": nil}
+	text, err := ru.Render(data)
+	require.NoError(t, err)
+	assert.NotContains(t, text, "Сравнение с эталоном:")
+
+	data["References"] = []map[string]string{{"Name": "#1", "Language": "Python", "Code": "x = 2"}}
+	text, err = ru.Render(data)
+	require.NoError(t, err)
+	assert.Contains(t, text, "студент их не видит.\n#1 (Python):\nx = 2\n\nОтвет должен быть в формате:")
+	assert.Contains(t, text, "Комментарии: [твои комментарии]\nСравнение с эталоном:\nБлижайшее эталонное решение: [название]")
+}
+
+func TestStore_RendersPractice(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+
+	data := map[string]any{"Language": "Python", "Code": "x = 1", "Rubric": "", "TaskStatement": "Sum two numbers", "Metrics": nil, "Lint": nil, "LintOmitted": 0}
+	for _, locale := range store.Locales() {
+		practice, err := store.Get(Practice, locale)
+		require.NoError(t, err)
+		text, err := practice.Render(data)
+		require.NoError(t, err)
+		assert.Contains(t, text, "Sum two numbers")
+		assert.NotContains(t, text, "Оценка:")
+		assert.NotContains(t, text, "Grade:")
+	}
+}
+
+func TestStore_RendersConversation(t *testing.T) {
+	store, err := Load("", "ru")
+	require.NoError(t, err)
+
+	data := map[string]any{
+		"Language":      "Python",
+		"TaskStatement": "",
+		"Code":          "x = 1",
+		"Feedback":      "Переменная не используется.",
+		"Annotations":   []map[string]any{{"StartLine": 1, "EndLine": 1, "Message": "Лишняя переменная"}},
+		"History":       []map[string]string{{"Role": "student", "Content": "Почему?"}, {"Role": "assistant", "Content": "Её никто не читает."}},
+		"Question":      "Как исправить?",
+	}
+	for _, locale := range store.Locales() {
+		conversation, err := store.Get(Conversation, locale)
+		require.NoError(t, err)
+		text, err := conversation.Render(data)
+		require.NoError(t, err)
+		assert.Contains(t, text, "Переменная не используется.")
+		assert.Contains(t, text, "Лишняя переменная")
+		assert.Contains(t, text, "Её никто не читает.")
+		assert.Contains(t, text, "Как исправить?")
+	}
+}
+
+func TestMessage_FallsBackToDefaultLocale(t *testing.T) {
+	t.Cleanup(func() { defaultMessageLocale = "ru" })
+
+	_, err := Load("", "en")
+	require.NoError(t, err)
+	assert.Equal(t, messages["en"][MsgGradingPending], Message("de", MsgGradingPending))
+	assert.Equal(t, messages["ru"][MsgGradingPending], Message("ru", MsgGradingPending))
+
+	_, err = Load("", "ru")
+	require.NoError(t, err)
+	assert.Equal(t, messages["ru"][MsgGradingPending], Message("de", MsgGradingPending))
+}
@@ -0,0 +1,195 @@
+// Package redaction masks credentials and personal data in submitted code
+// before it leaves the service.
+package redaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	KindSecret = "secret"
+	KindPII    = "pii"
+)
+
+// Rule describes one kind of sensitive data. When Pattern has a group named
+// "secret" only that group is masked, so `password = "..."` keeps its name.
+// Live marks provider-specific credential formats that are very likely real.
+type Rule struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Live    bool   `json:"live"`
+
+	re *regexp.Regexp
+}
+
+// Finding is one masked value. Line is 1-based.
+type Finding struct {
+	Rule string
+	Kind string
+	Live bool
+	Line int
+}
+
+type Result struct {
+	Text     string
+	Findings []Finding
+}
+
+// DefaultRules cover common credential formats and contact details.
+var DefaultRules = []Rule{
+	{Name: "private_key", Kind: KindSecret, Live: true, Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "aws_access_key", Kind: KindSecret, Live: true, Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "github_token", Kind: KindSecret, Live: true, Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`},
+	{Name: "openai_key", Kind: KindSecret, Live: true, Pattern: `\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`},
+	{Name: "slack_token", Kind: KindSecret, Live: true, Pattern: `\bxox[abprs]-[A-Za-z0-9-]{10,}`},
+	{Name: "google_api_key", Kind: KindSecret, Live: true, Pattern: `\bAIza[0-9A-Za-z_-]{35}`},
+	{Name: "jwt", Kind: KindSecret, Live: true, Pattern: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+	{Name: "connection_password", Kind: KindSecret, Pattern: `\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@"']+:(?P<secret>[^\s@/"']+)@`},
+	{Name: "assigned_secret", Kind: KindSecret, Pattern: `(?i)\b(?:password|passwd|pwd|secret|api_?key|access_?token|auth_?token|client_?secret)\w*["']?\s*[:=]\s*["'](?P<secret>[^"'\s]{4,})["']`},
+	{Name: "email", Kind: KindPII, Pattern: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`},
+	{Name: "phone", Kind: KindPII, Pattern: `(?:\+7|\b8)[\s(-]*\d{3}[\s)-]*\d{3}[\s-]*\d{2}[\s-]*\d{2}\b|\+\d{1,3}[\s-]?\(?\d{2,4}\)?[\s-]?\d{3}[\s-]?\d{2,4}\b`},
+	{Name: "snils", Kind: KindPII, Pattern: `\b\d{3}-\d{3}-\d{3}[ -]\d{2}\b`},
+}
+
+// placeholder matches values that are obviously not real secrets, such as
+// the ones in assignment templates.
+var placeholder = regexp.MustCompile(`(?i)^(x+|\*+|\.+|changeme|password|secret|test|dummy|example.*|your[_-].*|<.*>|\$\{.*\}|%s|\{\})$`)
+
+type Redactor struct {
+	rules []Rule
+}
+
+// New compiles rules.
+func New(rules []Rule) (*Redactor, error) {
+	r := &Redactor{rules: make([]Rule, 0, len(rules))}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.Pattern == "" {
+			return nil, fmt.Errorf("redaction rule needs a name and a pattern")
+		}
+		if rule.Kind != KindSecret && rule.Kind != KindPII {
+			return nil, fmt.Errorf("redaction rule %s: unknown kind %q", rule.Name, rule.Kind)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %s: %w", rule.Name, err)
+		}
+		rule.re = re
+		r.rules = append(r.rules, rule)
+	}
+	return r, nil
+}
+
+// Load returns a Redactor with DefaultRules followed by the rules from
+// patternsFile, a JSON array of Rule. A rule in the file replaces the
+// default rule of the same name.
+func Load(patternsFile string) (*Redactor, error) {
+	rules := append([]Rule(nil), DefaultRules...)
+	if patternsFile == "" {
+		return New(rules)
+	}
+
+	data, err := os.ReadFile(patternsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redaction patterns: %w", err)
+	}
+	var extra []Rule
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, fmt.Errorf("failed to parse redaction patterns: %w", err)
+	}
+
+	for _, rule := range extra {
+		replaced := false
+		for i := range rules {
+			if rules[i].Name == rule.Name {
+				rules[i] = rule
+				replaced = true
+			}
+		}
+		if !replaced {
+			rules = append(rules, rule)
+		}
+	}
+	return New(rules)
+}
+
+// Redact replaces every match with "[REDACTED:<rule>]". Line breaks inside a
+// match are kept, so line numbers of the rest of the code do not shift. A
+// nil Redactor returns text unchanged.
+func (r *Redactor) Redact(text string) Result {
+	result := Result{Text: text}
+	if r == nil {
+		return result
+	}
+
+	for _, rule := range r.rules {
+		group := rule.re.SubexpIndex("secret")
+		matches := rule.re.FindAllStringSubmatchIndex(result.Text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if group > 0 && m[2*group] >= 0 {
+				start, end = m[2*group], m[2*group+1]
+			}
+			value := result.Text[start:end]
+			if placeholder.MatchString(value) {
+				continue
+			}
+
+			b.WriteString(result.Text[last:start])
+			b.WriteString("[REDACTED:" + rule.Name + "]")
+			b.WriteString(strings.Repeat("\n", strings.Count(value, "\n")))
+			last = end
+
+			result.Findings = append(result.Findings, Finding{
+				Rule: rule.Name,
+				Kind: rule.Kind,
+				Live: rule.Live,
+				Line: strings.Count(result.Text[:start], "\n") + 1,
+			})
+		}
+		b.WriteString(result.Text[last:])
+		result.Text = b.String()
+	}
+
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return result.Findings[i].Line < result.Findings[j].Line
+	})
+	return result
+}
+
+// Rules lists the names of the rules that matched, without repeats.
+func (r Result) Rules() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range r.Findings {
+		if !seen[f.Rule] {
+			seen[f.Rule] = true
+			names = append(names, f.Rule)
+		}
+	}
+	return names
+}
+
+// Live lists the names of the matched rules that look like real credentials.
+func (r Result) Live() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range r.Findings {
+		if f.Live && !seen[f.Rule] {
+			seen[f.Rule] = true
+			names = append(names, f.Rule)
+		}
+	}
+	return names
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"codegrader-backend/internal/models"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// JUnit renders r as a JUnit XML report with one suite per submission.
+// The grade is a test case that fails below the passing grade and is skipped
+// while grading is pending; confirmed plagiarism and error or warning
+// annotations are failures, info annotations pass.
+func JUnit(r *Report) ([]byte, error) {
+	sub := r.Submission
+	suite := junitSuite{Name: sub.FileName}
+
+	gradeCase := junitCase{ClassName: "grading", Name: "grade", SystemOut: sub.Feedback}
+	switch {
+	case sub.GradingStatus == models.GradingStatusPending:
+		gradeCase.Skipped = &junitSkipped{Message: "grading is pending"}
+	case !sub.Passed:
+		gradeCase.Failure = &junitFailure{Message: "grade " + grade(sub) + " is below the passing grade", Type: "grade", Text: sub.Feedback}
+		gradeCase.SystemOut = ""
+	}
+	suite.Cases = append(suite.Cases, gradeCase)
+
+	plagiarismCase := junitCase{ClassName: "grading", Name: "plagiarism"}
+	for _, match := range r.Plagiarism {
+		if r.copied(match) {
+			plagiarismCase.Failure = &junitFailure{
+				Message: "copies submission " + match.MatchedSubmissionID,
+				Type:    "plagiarism",
+				Text:    match.Explanation,
+			}
+			break
+		}
+	}
+	suite.Cases = append(suite.Cases, plagiarismCase)
+
+	for _, a := range r.Annotations {
+		c := junitCase{
+			ClassName: "annotations." + a.Category,
+			Name:      fmt.Sprintf("%s:%s", fileOf(a, sub), lines(a)),
+		}
+		text := a.Message
+		if a.Suggestion != "" {
+			text += "\n\n" + a.Suggestion
+		}
+		if a.Severity == models.SeverityError || a.Severity == models.SeverityWarning {
+			c.Failure = &junitFailure{Message: a.Message, Type: a.Severity, Text: text}
+		} else {
+			c.SystemOut = text
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	for _, c := range suite.Cases {
+		suite.Tests++
+		if c.Failure != nil {
+			suite.Failures++
+		}
+		if c.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+	suites := junitSuites{
+		Name:     "SACAS",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitSuite{suite},
+	}
+
+	body, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
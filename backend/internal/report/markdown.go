@@ -0,0 +1,94 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"codegrader-backend/internal/models"
+)
+
+// markdownLabels are the headings of the Markdown report by locale.
+var markdownLabels = map[string]map[string]string{
+	"ru": {
+		"title":       "Результат проверки",
+		"grade":       "Оценка",
+		"pending":     "ожидает проверки",
+		"passed":      "зачтено",
+		"failed":      "не зачтено",
+		"review":      "Оценка будет подтверждена преподавателем.",
+		"feedback":    "Обратная связь",
+		"annotations": "Замечания к коду",
+		"lines":       "строки",
+		"suggestion":  "Исправление",
+		"plagiarism":  "Плагиат",
+		"copies":      "Решение совпадает с решением %s",
+		"copied":      "Решение %s совпадает с этим",
+		"similarity":  "сходство: текстовое %.0f%%, структурное %.0f%%",
+	},
+	"en": {
+		"title":       "Grading result",
+		"grade":       "Grade",
+		"pending":     "pending",
+		"passed":      "passed",
+		"failed":      "failed",
+		"review":      "The grade will be confirmed by a teacher.",
+		"feedback":    "Feedback",
+		"annotations": "Code remarks",
+		"lines":       "lines",
+		"suggestion":  "Suggested fix",
+		"plagiarism":  "Plagiarism",
+		"copies":      "The solution matches submission %s",
+		"copied":      "Submission %s matches this solution",
+		"similarity":  "similarity: textual %.0f%%, structural %.0f%%",
+	},
+}
+
+// Markdown renders r in the submission's locale.
+func Markdown(r *Report) []byte {
+	sub := r.Submission
+	labels, ok := markdownLabels[sub.Locale]
+	if !ok {
+		labels = markdownLabels["ru"]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s\n\n", labels["title"], sub.FileName)
+	switch {
+	case sub.GradingStatus == models.GradingStatusPending:
+		fmt.Fprintf(&b, "**%s:** %s\n", labels["grade"], labels["pending"])
+	case sub.Passed:
+		fmt.Fprintf(&b, "**%s:** %s (%s)\n", labels["grade"], grade(sub), labels["passed"])
+	default:
+		fmt.Fprintf(&b, "**%s:** %s (%s)\n", labels["grade"], grade(sub), labels["failed"])
+	}
+	if sub.NeedsReview {
+		fmt.Fprintf(&b, "\n> %s\n", labels["review"])
+	}
+
+	if sub.Feedback != "" {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", labels["feedback"], sub.Feedback)
+	}
+
+	if len(r.Annotations) > 0 {
+		fmt.Fprintf(&b, "\n## %s\n", labels["annotations"])
+		for _, a := range r.Annotations {
+			fmt.Fprintf(&b, "\n- **%s**, %s %s (%s, %s): %s\n", fileOf(a, sub), labels["lines"], lines(a), a.Severity, a.Category, a.Message)
+			if a.Suggestion != "" {
+				fmt.Fprintf(&b, "\n  %s:\n\n  ```\n  %s\n  ```\n", labels["suggestion"], strings.ReplaceAll(a.Suggestion, "\n", "\n  "))
+			}
+		}
+	}
+
+	if len(r.Plagiarism) > 0 {
+		fmt.Fprintf(&b, "\n## %s\n\n", labels["plagiarism"])
+		for _, match := range r.Plagiarism {
+			text := labels["copied"]
+			if r.copied(match) {
+				text = labels["copies"]
+			}
+			fmt.Fprintf(&b, "- "+text+", "+labels["similarity"]+"\n", r.other(match), match.TokenSimilarity*100, match.StructuralSimilarity*100)
+		}
+	}
+
+	return []byte(b.String())
+}
@@ -0,0 +1,81 @@
+// Package report renders a graded submission for external tools: SARIF for
+// IDE viewers and code quality widgets, JUnit XML for test dashboards and
+// Markdown for people.
+package report
+
+import (
+	"errors"
+	"fmt"
+
+	"codegrader-backend/internal/models"
+)
+
+const (
+	FormatSARIF    = "sarif"
+	FormatJUnit    = "junit"
+	FormatMarkdown = "markdown"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported report format")
+
+// Report is everything known about a submission's result. Plagiarism holds
+// matches in both directions, as returned for the submission.
+type Report struct {
+	Submission  *models.CodeSubmission
+	Annotations []models.Annotation
+	Plagiarism  []models.PlagiarismMatch
+}
+
+// Render returns r in format together with its content type. An empty format
+// means Markdown.
+func Render(format string, r *Report) ([]byte, string, error) {
+	switch format {
+	case FormatSARIF:
+		body, err := SARIF(r)
+		return body, "application/sarif+json", err
+	case FormatJUnit:
+		body, err := JUnit(r)
+		return body, "application/xml; charset=utf-8", err
+	case FormatMarkdown, "":
+		return Markdown(r), "text/markdown; charset=utf-8", nil
+	default:
+		return nil, "", fmt.Errorf("%w: %q, expected %s, %s or %s", ErrUnsupportedFormat, format, FormatSARIF, FormatJUnit, FormatMarkdown)
+	}
+}
+
+// copied reports whether match marks r's submission as the copy rather than
+// the original.
+func (r *Report) copied(match models.PlagiarismMatch) bool {
+	return match.SubmissionID == r.Submission.ID
+}
+
+// other is the submission on the other side of match.
+func (r *Report) other(match models.PlagiarismMatch) string {
+	if r.copied(match) {
+		return match.MatchedSubmissionID
+	}
+	return match.SubmissionID
+}
+
+// grade is how the submission's grade is shown.
+func grade(sub *models.CodeSubmission) string {
+	if sub.GradeLabel != "" {
+		return sub.GradeLabel
+	}
+	return fmt.Sprint(sub.Grade)
+}
+
+// fileOf is the file a refers to.
+func fileOf(a models.Annotation, sub *models.CodeSubmission) string {
+	if a.File != "" {
+		return a.File
+	}
+	return sub.FileName
+}
+
+func lines(a models.Annotation) string {
+	if a.StartLine == a.EndLine {
+		return fmt.Sprint(a.StartLine)
+	}
+	return fmt.Sprintf("%d-%d", a.StartLine, a.EndLine)
+}
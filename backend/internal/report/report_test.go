@@ -0,0 +1,114 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"codegrader-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleReport() *Report {
+	return &Report{
+		Submission: &models.CodeSubmission{
+			ID:            "sub-2",
+			FileName:      "main.py",
+			Locale:        "ru",
+			Grade:         3,
+			GradeLabel:    "3",
+			Passed:        true,
+			Feedback:      "Оценка: 3",
+			GradingStatus: models.GradingStatusGraded,
+			IsPlagiarism:  true,
+		},
+		Annotations: []models.Annotation{
+			{File: "main.py", StartLine: 2, EndLine: 4, Severity: models.SeverityError, Category: "correctness", Message: "Деление на ноль", Suggestion: "if n:\n    print(1 / n)"},
+			{File: "main.py", StartLine: 7, EndLine: 7, Severity: models.SeverityInfo, Category: "style", Message: "Длинная строка"},
+		},
+		Plagiarism: []models.PlagiarismMatch{
+			{SubmissionID: "sub-2", MatchedSubmissionID: "sub-1", TokenSimilarity: 0.92, StructuralSimilarity: 0.88, Explanation: "Совпадает логика"},
+		},
+	}
+}
+
+func TestRender_RejectsUnknownFormat(t *testing.T) {
+	_, _, err := Render("pdf", sampleReport())
+
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestSARIF_MapsAnnotationsAndPlagiarism(t *testing.T) {
+	body, contentType, err := Render(FormatSARIF, sampleReport())
+	require.NoError(t, err)
+	assert.Equal(t, "application/sarif+json", contentType)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(body, &log))
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, "correctness", run.Results[0].RuleID)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, &sarifRegion{StartLine: 2, EndLine: 4}, run.Results[0].Locations[0].PhysicalLocation.Region)
+	require.Len(t, run.Results[0].Fixes, 1)
+	assert.Equal(t, "note", run.Results[1].Level)
+	assert.Empty(t, run.Results[1].Fixes)
+	assert.Equal(t, "plagiarism", run.Results[2].RuleID)
+	assert.Equal(t, "error", run.Results[2].Level)
+
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.ID)
+	}
+	assert.Equal(t, []string{"correctness", "style", "plagiarism"}, rules)
+	assert.Equal(t, true, run.Properties["passed"])
+}
+
+func TestJUnit_CountsFailures(t *testing.T) {
+	body, _, err := Render(FormatJUnit, sampleReport())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "<?xml"))
+
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(body, &suites))
+
+	// grade, plagiarism and two annotations; plagiarism and the error fail.
+	assert.Equal(t, 4, suites.Tests)
+	assert.Equal(t, 2, suites.Failures)
+	cases := suites.Suites[0].Cases
+	assert.Nil(t, cases[0].Failure)
+	assert.Equal(t, "plagiarism", cases[1].Failure.Type)
+	assert.Equal(t, "main.py:2-4", cases[2].Name)
+	assert.Equal(t, "annotations.style", cases[3].ClassName)
+}
+
+func TestJUnit_SkipsPendingGrade(t *testing.T) {
+	r := &Report{Submission: &models.CodeSubmission{ID: "sub-3", FileName: "a.c", GradingStatus: models.GradingStatusPending}}
+
+	body, err := JUnit(r)
+	require.NoError(t, err)
+
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(body, &suites))
+	assert.Equal(t, 1, suites.Skipped)
+	assert.Equal(t, 0, suites.Failures)
+}
+
+func TestMarkdown_UsesLocale(t *testing.T) {
+	r := sampleReport()
+
+	text := string(Markdown(r))
+	assert.Contains(t, text, "# Результат проверки: main.py")
+	assert.Contains(t, text, "**Оценка:** 3 (зачтено)")
+	assert.Contains(t, text, "строки 2-4 (error, correctness): Деление на ноль")
+	assert.Contains(t, text, "    print(1 / n)")
+	assert.Contains(t, text, "Решение совпадает с решением sub-1, сходство: текстовое 92%, структурное 88%")
+
+	r.Submission.Locale = "en"
+	assert.Contains(t, string(Markdown(r)), "**Grade:** 3 (passed)")
+}
@@ -0,0 +1,188 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"codegrader-backend/internal/models"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// ruleDescriptions explain the rule IDs: annotation categories and
+// plagiarism.
+var ruleDescriptions = map[string]string{
+	"correctness": "Correctness of the solution",
+	"style":       "Adherence to style guides",
+	"readability": "Readability",
+	"performance": "Algorithm efficiency",
+	"security":    "Security",
+	"structure":   "Code structure",
+	"other":       "Other remarks",
+	"plagiarism":  "Similarity to another submission",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool      `json:"tool"`
+	Results    []sarifResult  `json:"results"`
+	Properties map[string]any `json:"properties"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Fixes      []sarifFix      `json:"fixes,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+	Replacements     []sarifReplacement `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// SARIF renders r as a SARIF 2.1.0 log with one run. Annotations become
+// results with their category as the rule, confirmed plagiarism an error on
+// the whole file; the grade goes into the run properties.
+func SARIF(r *Report) ([]byte, error) {
+	sub := r.Submission
+	results := make([]sarifResult, 0, len(r.Annotations)+len(r.Plagiarism))
+	used := make(map[string]bool)
+
+	for _, a := range r.Annotations {
+		file := fileOf(a, sub)
+		region := sarifRegion{StartLine: a.StartLine, EndLine: a.EndLine}
+		result := sarifResult{
+			RuleID:    a.Category,
+			Level:     sarifLevel(a.Severity),
+			Message:   sarifMessage{Text: a.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: file}, Region: &region}}},
+		}
+		if a.Suggestion != "" {
+			result.Fixes = []sarifFix{{
+				Description: sarifMessage{Text: a.Message},
+				ArtifactChanges: []sarifArtifactChange{{
+					ArtifactLocation: sarifArtifact{URI: file},
+					Replacements:     []sarifReplacement{{DeletedRegion: region, InsertedContent: sarifMessage{Text: a.Suggestion + "\n"}}},
+				}},
+			}}
+		}
+		results = append(results, result)
+		used[a.Category] = true
+	}
+
+	for _, match := range r.Plagiarism {
+		level, text := "note", fmt.Sprintf("Submission %s was found to copy this solution.", match.SubmissionID)
+		if r.copied(match) {
+			level, text = "error", fmt.Sprintf("This solution copies submission %s.", match.MatchedSubmissionID)
+		}
+		if match.Explanation != "" {
+			text += " " + match.Explanation
+		}
+		results = append(results, sarifResult{
+			RuleID:    "plagiarism",
+			Level:     level,
+			Message:   sarifMessage{Text: text},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: sub.FileName}}}},
+			Properties: map[string]any{
+				"matchedSubmissionId":  r.other(match),
+				"tokenSimilarity":      match.TokenSimilarity,
+				"structuralSimilarity": match.StructuralSimilarity,
+				"semanticSimilarity":   match.SemanticSimilarity,
+				"crossLanguage":        match.CrossLanguage,
+			},
+		})
+		used["plagiarism"] = true
+	}
+
+	rules := make([]sarifRule, 0, len(used))
+	for _, id := range append(append([]string(nil), models.AnnotationCategories...), "plagiarism") {
+		if used[id] {
+			rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: ruleDescriptions[id]}})
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "SACAS", Rules: rules}},
+			Results: results,
+			Properties: map[string]any{
+				"submissionId":  sub.ID,
+				"gradingStatus": sub.GradingStatus,
+				"grade":         sub.Grade,
+				"gradeLabel":    grade(sub),
+				"passed":        sub.Passed,
+				"needsReview":   sub.NeedsReview,
+				"isPlagiarism":  sub.IsPlagiarism,
+				"feedback":      sub.Feedback,
+			},
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case models.SeverityError:
+		return "error"
+	case models.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
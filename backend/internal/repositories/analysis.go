@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AnalysisCacheRepository interface {
+	Get(ctx context.Context, key *models.AnalysisCacheEntry) (*models.AnalysisCacheEntry, error)
+	Save(ctx context.Context, entry *models.AnalysisCacheEntry) error
+}
+
+type analysisCacheRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalysisCacheRepository(db *gorm.DB) AnalysisCacheRepository {
+	return &analysisCacheRepository{db: db}
+}
+
+// Get looks up the entry with the same key fields as key.
+func (r *analysisCacheRepository) Get(ctx context.Context, key *models.AnalysisCacheEntry) (*models.AnalysisCacheEntry, error) {
+	var entry models.AnalysisCacheEntry
+	err := r.db.WithContext(ctx).
+		Where("content_hash = ? AND file_type = ? AND assignment_id = ? AND prompt_version = ? AND model = ?",
+			key.ContentHash, key.FileType, key.AssignmentID, key.PromptVersion, key.Model).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *analysisCacheRepository) Save(ctx context.Context, entry *models.AnalysisCacheEntry) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error
+}
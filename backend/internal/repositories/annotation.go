@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AnnotationRepository interface {
+	Replace(ctx context.Context, submissionID string, annotations []models.Annotation) error
+	GetBySubmissionID(ctx context.Context, submissionID string) ([]models.Annotation, error)
+}
+
+type annotationRepository struct {
+	db *gorm.DB
+}
+
+func NewAnnotationRepository(db *gorm.DB) AnnotationRepository {
+	return &annotationRepository{db: db}
+}
+
+// Replace stores annotations as the only annotations of submissionID.
+func (r *annotationRepository) Replace(ctx context.Context, submissionID string, annotations []models.Annotation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Annotation{}, "submission_id = ?", submissionID).Error; err != nil {
+			return err
+		}
+		if len(annotations) == 0 {
+			return nil
+		}
+		return tx.Create(&annotations).Error
+	})
+}
+
+func (r *annotationRepository) GetBySubmissionID(ctx context.Context, submissionID string) ([]models.Annotation, error) {
+	var annotations []models.Annotation
+	err := r.db.WithContext(ctx).
+		Where("submission_id = ?", submissionID).
+		Order("start_line ASC, id ASC").
+		Find(&annotations).Error
+	return annotations, err
+}
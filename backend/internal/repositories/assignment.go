@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AssignmentRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Assignment, error)
+	Save(ctx context.Context, assignment *models.Assignment) error
+}
+
+type assignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAssignmentRepository(db *gorm.DB) AssignmentRepository {
+	return &assignmentRepository{db: db}
+}
+
+func (r *assignmentRepository) GetByID(ctx context.Context, id string) (*models.Assignment, error) {
+	var assignment models.Assignment
+	err := r.db.WithContext(ctx).First(&assignment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *assignmentRepository) Save(ctx context.Context, assignment *models.Assignment) error {
+	return r.db.WithContext(ctx).Save(assignment).Error
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ConversationRepository interface {
+	Append(ctx context.Context, messages ...*models.ConversationMessage) error
+	GetBySubmissionID(ctx context.Context, submissionID string) ([]models.ConversationMessage, error)
+}
+
+type conversationRepository struct {
+	db *gorm.DB
+}
+
+func NewConversationRepository(db *gorm.DB) ConversationRepository {
+	return &conversationRepository{db: db}
+}
+
+// Append stores messages together, so a question is never kept without its
+// answer.
+func (r *conversationRepository) Append(ctx context.Context, messages ...*models.ConversationMessage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, message := range messages {
+			if err := tx.Create(message).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *conversationRepository) GetBySubmissionID(ctx context.Context, submissionID string) ([]models.ConversationMessage, error) {
+	var messages []models.ConversationMessage
+	err := r.db.WithContext(ctx).
+		Where("submission_id = ?", submissionID).
+		Order("created_at ASC, id ASC").
+		Find(&messages).Error
+	return messages, err
+}
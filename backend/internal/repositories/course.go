@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CourseRepository interface {
+	GetByID(ctx context.Context, id string) (*models.Course, error)
+	GetAll(ctx context.Context) ([]models.Course, error)
+	Save(ctx context.Context, course *models.Course) error
+}
+
+type courseRepository struct {
+	db *gorm.DB
+}
+
+func NewCourseRepository(db *gorm.DB) CourseRepository {
+	return &courseRepository{db: db}
+}
+
+func (r *courseRepository) GetByID(ctx context.Context, id string) (*models.Course, error) {
+	var course models.Course
+	err := r.db.WithContext(ctx).First(&course, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &course, nil
+}
+
+func (r *courseRepository) GetAll(ctx context.Context) ([]models.Course, error) {
+	var courses []models.Course
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&courses).Error
+	return courses, err
+}
+
+func (r *courseRepository) Save(ctx context.Context, course *models.Course) error {
+	return r.db.WithContext(ctx).Save(course).Error
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmbeddingRepository interface {
+	Save(ctx context.Context, embedding *models.SubmissionEmbedding) error
+	GetBySubmissionID(ctx context.Context, submissionID string) (*models.SubmissionEmbedding, error)
+	FindNearest(ctx context.Context, vector models.Vector, fileType, model, excludeID string, limit int) ([]models.SimilarSubmission, error)
+	GetMissingSubmissionIDs(ctx context.Context, model string) ([]string, error)
+}
+
+type embeddingRepository struct {
+	db *gorm.DB
+}
+
+func NewEmbeddingRepository(db *gorm.DB) EmbeddingRepository {
+	return &embeddingRepository{db: db}
+}
+
+func (r *embeddingRepository) Save(ctx context.Context, embedding *models.SubmissionEmbedding) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(embedding).Error
+}
+
+func (r *embeddingRepository) GetBySubmissionID(ctx context.Context, submissionID string) (*models.SubmissionEmbedding, error) {
+	var embedding models.SubmissionEmbedding
+	err := r.db.WithContext(ctx).First(&embedding, "submission_id = ?", submissionID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &embedding, nil
+}
+
+func (r *embeddingRepository) FindNearest(ctx context.Context, vector models.Vector, fileType, model, excludeID string, limit int) ([]models.SimilarSubmission, error) {
+	var result []models.SimilarSubmission
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT s.id, s.file_name, s.file_type, s.grade, s.created_at, 1 - (e.embedding <=> ?) AS similarity
+		FROM submission_embeddings e
+		JOIN code_submissions s ON s.id = e.submission_id
+		WHERE e.file_type = ? AND e.model = ? AND e.submission_id <> ?
+		ORDER BY e.embedding <=> ?
+		LIMIT ?`,
+		vector, fileType, model, excludeID, vector, limit,
+	).Scan(&result).Error
+	return result, err
+}
+
+func (r *embeddingRepository) GetMissingSubmissionIDs(ctx context.Context, model string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT s.id
+		FROM code_submissions s
+		LEFT JOIN submission_embeddings e ON e.submission_id = s.id AND e.model = ?
+		WHERE e.submission_id IS NULL
+		ORDER BY s.created_at ASC`,
+		model,
+	).Scan(&ids).Error
+	return ids, err
+}
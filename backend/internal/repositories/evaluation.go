@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type EvaluationRepository interface {
+	Create(ctx context.Context, run *models.EvaluationRun) error
+	GetByID(ctx context.Context, id string) (*models.EvaluationRun, error)
+	GetAll(ctx context.Context) ([]models.EvaluationRun, error)
+	GetPending(ctx context.Context) ([]models.EvaluationRun, error)
+	Update(ctx context.Context, run *models.EvaluationRun) error
+	UpdateItem(ctx context.Context, item *models.EvaluationItem) error
+}
+
+type evaluationRepository struct {
+	db *gorm.DB
+}
+
+func NewEvaluationRepository(db *gorm.DB) EvaluationRepository {
+	return &evaluationRepository{db: db}
+}
+
+// Create stores run together with its items.
+func (r *evaluationRepository) Create(ctx context.Context, run *models.EvaluationRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *evaluationRepository) GetByID(ctx context.Context, id string) (*models.EvaluationRun, error) {
+	var run models.EvaluationRun
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		First(&run, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetAll lists runs without their items, newest first.
+func (r *evaluationRepository) GetAll(ctx context.Context) ([]models.EvaluationRun, error) {
+	var runs []models.EvaluationRun
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&runs).Error
+	return runs, err
+}
+
+func (r *evaluationRepository) GetPending(ctx context.Context) ([]models.EvaluationRun, error) {
+	var runs []models.EvaluationRun
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("status = ?", models.EvaluationStatusPending).
+		Order("created_at").
+		Find(&runs).Error
+	return runs, err
+}
+
+// Update saves the run's own columns; items are saved with UpdateItem.
+func (r *evaluationRepository) Update(ctx context.Context, run *models.EvaluationRun) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(run).Error
+}
+
+func (r *evaluationRepository) UpdateItem(ctx context.Context, item *models.EvaluationItem) error {
+	return r.db.WithContext(ctx).Save(item).Error
+}
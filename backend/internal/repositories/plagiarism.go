@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PlagiarismRepository interface {
+	Create(ctx context.Context, match *models.PlagiarismMatch) error
+	GetBySubmissionID(ctx context.Context, submissionID string) ([]models.PlagiarismMatch, error)
+}
+
+type plagiarismRepository struct {
+	db *gorm.DB
+}
+
+func NewPlagiarismRepository(db *gorm.DB) PlagiarismRepository {
+	return &plagiarismRepository{db: db}
+}
+
+func (r *plagiarismRepository) Create(ctx context.Context, match *models.PlagiarismMatch) error {
+	return r.db.WithContext(ctx).Create(match).Error
+}
+
+func (r *plagiarismRepository) GetBySubmissionID(ctx context.Context, submissionID string) ([]models.PlagiarismMatch, error) {
+	var matches []models.PlagiarismMatch
+	err := r.db.WithContext(ctx).
+		Where("submission_id = ? OR matched_submission_id = ?", submissionID, submissionID).
+		Order("created_at ASC").
+		Find(&matches).Error
+	return matches, err
+}
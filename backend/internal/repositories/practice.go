@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PracticeRepository interface {
+	Reserve(ctx context.Context, attempt *models.PracticeAttempt, since time.Time, limit int) ([]models.PracticeAttempt, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type practiceRepository struct {
+	db *gorm.DB
+}
+
+func NewPracticeRepository(db *gorm.DB) PracticeRepository {
+	return &practiceRepository{db: db}
+}
+
+// Reserve returns the student's attempts at the assignment made after since,
+// oldest first, and stores attempt unless there are already limit of them.
+// Counting and inserting hold an advisory lock on the student and assignment,
+// so concurrent requests cannot both take the last attempt. A limit of 0 or
+// less always stores the attempt.
+func (r *practiceRepository) Reserve(ctx context.Context, attempt *models.PracticeAttempt, since time.Time, limit int) ([]models.PracticeAttempt, error) {
+	var attempts []models.PracticeAttempt
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		key := attempt.StudentID + "/" + attempt.AssignmentID
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+			return err
+		}
+		err := tx.
+			Where("student_id = ? AND assignment_id = ? AND created_at > ?", attempt.StudentID, attempt.AssignmentID, since).
+			Order("created_at ASC").
+			Find(&attempts).Error
+		if err != nil {
+			return err
+		}
+		if limit > 0 && len(attempts) >= limit {
+			return nil
+		}
+		return tx.Create(attempt).Error
+	})
+	return attempts, err
+}
+
+func (r *practiceRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.PracticeAttempt{}, id).Error
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StyleProfileRepository interface {
+	Get(ctx context.Context, studentID, fileType string) (*models.StyleProfile, error)
+	Save(ctx context.Context, profile *models.StyleProfile) error
+}
+
+type styleProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewStyleProfileRepository(db *gorm.DB) StyleProfileRepository {
+	return &styleProfileRepository{db: db}
+}
+
+func (r *styleProfileRepository) Get(ctx context.Context, studentID, fileType string) (*models.StyleProfile, error) {
+	var profile models.StyleProfile
+	err := r.db.WithContext(ctx).
+		Where("student_id = ? AND file_type = ?", studentID, fileType).
+		First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Save inserts the profile or replaces the stored one of the same student
+// and language.
+func (r *styleProfileRepository) Save(ctx context.Context, profile *models.StyleProfile) error {
+	profile.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_id"}, {Name: "file_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"profile", "updated_at"}),
+	}).Create(profile).Error
+}
@@ -1,18 +1,25 @@
 package repositories
 
 import (
+	"context"
+
 	"codegrader-backend/internal/models"
 
 	"gorm.io/gorm"
 )
 
 type SubmissionRepository interface {
-	Create(submission *models.CodeSubmission) error
-	GetByID(id string) (*models.CodeSubmission, error)
-	GetAll() ([]models.CodeSubmission, error)
-	Update(submission *models.CodeSubmission) error
-	Delete(id string) error
-	GetContentByFileType(fileType string) ([]string, error)
+	Create(ctx context.Context, submission *models.CodeSubmission) error
+	GetByID(ctx context.Context, id string) (*models.CodeSubmission, error)
+	GetAll(ctx context.Context) ([]models.CodeSubmission, error)
+	Update(ctx context.Context, submission *models.CodeSubmission) error
+	Delete(ctx context.Context, id string) error
+	GetByFileType(ctx context.Context, fileType string) ([]models.CodeSubmission, error)
+	GetByAssignment(ctx context.Context, assignmentID string, fileTypes []string) ([]models.CodeSubmission, error)
+	GetPendingGrading(ctx context.Context, offset, limit int) ([]models.CodeSubmission, error)
+	GetByContentHash(ctx context.Context, contentHash, fileType string) ([]models.CodeSubmission, error)
+	GetRecentByStudent(ctx context.Context, studentID, fileType string, limit int) ([]models.CodeSubmission, error)
+	GetForReview(ctx context.Context, minAILikelihood float64) ([]models.CodeSubmission, error)
 }
 
 type submissionRepository struct {
@@ -23,44 +30,99 @@ func NewSubmissionRepository(db *gorm.DB) SubmissionRepository {
 	return &submissionRepository{db: db}
 }
 
-func (r *submissionRepository) Create(submission *models.CodeSubmission) error {
-	return r.db.Create(submission).Error
+func (r *submissionRepository) Create(ctx context.Context, submission *models.CodeSubmission) error {
+	return r.db.WithContext(ctx).Create(submission).Error
 }
 
-func (r *submissionRepository) GetByID(id string) (*models.CodeSubmission, error) {
+func (r *submissionRepository) GetByID(ctx context.Context, id string) (*models.CodeSubmission, error) {
 	var submission models.CodeSubmission
-	err := r.db.First(&submission, "id = ?", id).Error
+	err := r.db.WithContext(ctx).First(&submission, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &submission, nil
 }
 
-func (r *submissionRepository) GetAll() ([]models.CodeSubmission, error) {
+func (r *submissionRepository) GetAll(ctx context.Context) ([]models.CodeSubmission, error) {
 	var submissions []models.CodeSubmission
-	err := r.db.Order("created_at DESC").Find(&submissions).Error
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&submissions).Error
 	return submissions, err
 }
 
-func (r *submissionRepository) Update(submission *models.CodeSubmission) error {
-	return r.db.Save(submission).Error
+func (r *submissionRepository) Update(ctx context.Context, submission *models.CodeSubmission) error {
+	return r.db.WithContext(ctx).Save(submission).Error
 }
 
-func (r *submissionRepository) Delete(id string) error {
-	return r.db.Delete(&models.CodeSubmission{}, "id = ?", id).Error
+func (r *submissionRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.PlagiarismMatch{}, "submission_id = ? OR matched_submission_id = ?", id, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.SubmissionEmbedding{}, "submission_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Annotation{}, "submission_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ConversationMessage{}, "submission_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.CodeSubmission{}, "id = ?", id).Error
+	})
 }
 
-func (r *submissionRepository) GetContentByFileType(fileType string) ([]string, error) {
+func (r *submissionRepository) GetByFileType(ctx context.Context, fileType string) ([]models.CodeSubmission, error) {
 	var submissions []models.CodeSubmission
-	err := r.db.Select("content").Where("file_type = ?", fileType).Find(&submissions).Error
-	if err != nil {
-		return nil, err
-	}
+	err := r.db.WithContext(ctx).Where("file_type = ?", fileType).Order("created_at ASC").Find(&submissions).Error
+	return submissions, err
+}
 
-	contents := make([]string, len(submissions))
-	for i, submission := range submissions {
-		contents[i] = submission.Content
-	}
+func (r *submissionRepository) GetByAssignment(ctx context.Context, assignmentID string, fileTypes []string) ([]models.CodeSubmission, error) {
+	var submissions []models.CodeSubmission
+	err := r.db.WithContext(ctx).
+		Where("assignment_id = ? AND file_type IN ?", assignmentID, fileTypes).
+		Order("created_at ASC").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *submissionRepository) GetPendingGrading(ctx context.Context, offset, limit int) ([]models.CodeSubmission, error) {
+	var submissions []models.CodeSubmission
+	err := r.db.WithContext(ctx).
+		Where("grading_status = ?", models.GradingStatusPending).
+		Order("created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *submissionRepository) GetByContentHash(ctx context.Context, contentHash, fileType string) ([]models.CodeSubmission, error) {
+	var submissions []models.CodeSubmission
+	err := r.db.WithContext(ctx).
+		Where("content_hash = ? AND file_type = ?", contentHash, fileType).
+		Order("created_at ASC").
+		Find(&submissions).Error
+	return submissions, err
+}
 
-	return contents, nil
+func (r *submissionRepository) GetRecentByStudent(ctx context.Context, studentID, fileType string, limit int) ([]models.CodeSubmission, error) {
+	var submissions []models.CodeSubmission
+	err := r.db.WithContext(ctx).
+		Where("student_id = ? AND file_type = ?", studentID, fileType).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&submissions).Error
+	return submissions, err
+}
+
+// GetForReview returns the submissions waiting for a teacher: those marked
+// for review and those likely to be AI-generated.
+func (r *submissionRepository) GetForReview(ctx context.Context, minAILikelihood float64) ([]models.CodeSubmission, error) {
+	var submissions []models.CodeSubmission
+	err := r.db.WithContext(ctx).
+		Where("needs_review = ? OR ai_likelihood >= ?", true, minAILikelihood).
+		Order("created_at DESC").
+		Find(&submissions).Error
+	return submissions, err
 }
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"codegrader-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrUnsupportedGrouping = errors.New("unsupported usage grouping")
+
+// usageGroups maps the supported group_by values to SQL expressions.
+var usageGroups = map[string]string{
+	"day":        "to_char(date_trunc('day', created_at), 'YYYY-MM-DD')",
+	"course":     "course_id",
+	"assignment": "assignment_id",
+	"student":    "student_id",
+	"model":      "model",
+	"operation":  "operation",
+}
+
+type UsageRepository interface {
+	Create(ctx context.Context, usage *models.LLMUsage) error
+	Summarize(ctx context.Context, filter models.UsageFilter, groupBy string) ([]models.UsageSummary, error)
+	CostSince(ctx context.Context, courseID string, since time.Time) (float64, error)
+}
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) Create(ctx context.Context, usage *models.LLMUsage) error {
+	return r.db.WithContext(ctx).Create(usage).Error
+}
+
+func (r *usageRepository) Summarize(ctx context.Context, filter models.UsageFilter, groupBy string) ([]models.UsageSummary, error) {
+	group, ok := usageGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedGrouping, groupBy)
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.LLMUsage{}).
+		Select(fmt.Sprintf(`%s AS key, COUNT(*) AS calls,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(cost_usd), 0) AS cost_usd`, group))
+
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.CourseID != "" {
+		query = query.Where("course_id = ?", filter.CourseID)
+	}
+	if filter.AssignmentID != "" {
+		query = query.Where("assignment_id = ?", filter.AssignmentID)
+	}
+	if filter.StudentID != "" {
+		query = query.Where("student_id = ?", filter.StudentID)
+	}
+
+	var summaries []models.UsageSummary
+	err := query.Group(group).Order("key").Scan(&summaries).Error
+	return summaries, err
+}
+
+func (r *usageRepository) CostSince(ctx context.Context, courseID string, since time.Time) (float64, error) {
+	var cost float64
+	err := r.db.WithContext(ctx).Model(&models.LLMUsage{}).
+		Select("COALESCE(SUM(cost_usd), 0)").
+		Where("course_id = ? AND created_at >= ?", courseID, since).
+		Scan(&cost).Error
+	return cost, err
+}
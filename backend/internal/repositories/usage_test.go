@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"codegrader-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize_RejectsUnknownGrouping(t *testing.T) {
+	// The grouping is validated before the database is touched, so no
+	// connection is needed.
+	repo := NewUsageRepository(nil)
+
+	for _, groupBy := range []string{"", "week", "course_id; DROP TABLE llm_usages"} {
+		_, err := repo.Summarize(context.Background(), models.UsageFilter{}, groupBy)
+		assert.ErrorIs(t, err, ErrUnsupportedGrouping, groupBy)
+	}
+}
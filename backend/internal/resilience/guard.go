@@ -0,0 +1,115 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Guard wraps calls to an unreliable provider with classified retries and a
+// circuit breaker, and counts what happened for the metrics endpoint.
+type Guard struct {
+	name    string
+	policy  RetryPolicy
+	breaker *Breaker
+
+	calls     atomic.Int64
+	attempts  atomic.Int64
+	retries   atomic.Int64
+	failures  atomic.Int64
+	rejected  atomic.Int64
+	lastError atomic.Value
+}
+
+type Stats struct {
+	Name         string       `json:"name"`
+	BreakerState BreakerState `json:"breaker_state"`
+	BreakerTrips int64        `json:"breaker_trips"`
+	Calls        int64        `json:"calls"`
+	Attempts     int64        `json:"attempts"`
+	Retries      int64        `json:"retries"`
+	Failures     int64        `json:"failures"`
+	Rejected     int64        `json:"rejected"`
+	LastError    string       `json:"last_error,omitempty"`
+}
+
+func NewGuard(name string, policy RetryPolicy, breaker *Breaker) *Guard {
+	return &Guard{name: name, policy: policy, breaker: breaker}
+}
+
+// Do runs fn until it succeeds, fails with a non-retryable error, runs out of
+// retries or ctx ends. Only retryable failures count against the breaker, so
+// a malformed request does not take the provider offline for everyone: a
+// non-retryable error is still an answer from the provider and counts as a
+// success, and a cancelled call only releases a half-open probe.
+func (g *Guard) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	g.calls.Add(1)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			delay := g.policy.Delay(attempt, err)
+			log.Printf("%s call failed (%v), retry %d/%d in %s", g.name, err, attempt, g.policy.MaxRetries, delay.Round(time.Millisecond))
+			g.retries.Add(1)
+			if sleepErr := sleep(ctx, delay); sleepErr != nil {
+				return sleepErr
+			}
+		}
+
+		if allowErr := g.breaker.Allow(); allowErr != nil {
+			g.rejected.Add(1)
+			if err != nil {
+				return errors.Join(allowErr, err)
+			}
+			return allowErr
+		}
+
+		g.attempts.Add(1)
+		err = fn(ctx)
+		if err == nil {
+			g.breaker.Success()
+			return nil
+		}
+		if ctx.Err() != nil {
+			g.breaker.Release()
+			return ctx.Err()
+		}
+
+		g.lastError.Store(err.Error())
+		if !IsRetryable(err) {
+			g.breaker.Success()
+			g.failures.Add(1)
+			return err
+		}
+		g.breaker.Failure()
+		if attempt >= g.policy.MaxRetries {
+			g.failures.Add(1)
+			return err
+		}
+	}
+}
+
+func (g *Guard) Stats() Stats {
+	stats := Stats{
+		Name:         g.name,
+		BreakerState: g.breaker.State(),
+		BreakerTrips: g.breaker.Trips(),
+		Calls:        g.calls.Load(),
+		Attempts:     g.attempts.Load(),
+		Retries:      g.retries.Load(),
+		Failures:     g.failures.Load(),
+		Rejected:     g.rejected.Load(),
+	}
+	if lastError, ok := g.lastError.Load().(string); ok {
+		stats.LastError = lastError
+	}
+	return stats
+}
+
+// Unavailable reports whether err means the provider is down for now rather
+// than that the request itself is bad, i.e. the work should be retried later.
+func Unavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || IsRetryable(err)
+}
@@ -0,0 +1,107 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = &RetryableError{Err: errors.New("status code: 503")}
+
+func TestGuard_RetriesTransientErrors(t *testing.T) {
+	guard := NewGuard("test", RetryPolicy{MaxRetries: 3}, NewBreaker(10, time.Minute))
+
+	calls := 0
+	err := guard.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, int64(2), guard.Stats().Retries)
+}
+
+func TestGuard_DoesNotRetryPermanentErrors(t *testing.T) {
+	guard := NewGuard("test", RetryPolicy{MaxRetries: 3}, NewBreaker(1, time.Minute))
+
+	calls := 0
+	err := guard.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("status code: 400")
+	})
+
+	assert.Error(t, err)
+	assert.False(t, Unavailable(err))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, StateClosed, guard.Stats().BreakerState)
+}
+
+func TestBreaker_OpensAndProbesAfterCooldown(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	guard := NewGuard("test", RetryPolicy{MaxRetries: 5}, breaker)
+
+	calls := 0
+	err := guard.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errTransient
+	})
+
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, Unavailable(err))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.Allow())
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+	breaker.Success()
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_ProbeEndsOnPermanentErrorOrCancel(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	guard := NewGuard("test", RetryPolicy{}, breaker)
+
+	_ = guard.Do(context.Background(), func(ctx context.Context) error { return errTransient })
+	assert.Equal(t, StateOpen, breaker.State())
+
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	err := guard.Do(ctx, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, breaker.Allow())
+	breaker.Release()
+
+	err = guard.Do(context.Background(), func(ctx context.Context) error {
+		return errors.New("status code: 400")
+	})
+	assert.False(t, Unavailable(err))
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestRetryPolicy_DelayHonorsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	assert.Equal(t, 5*time.Second, policy.Delay(1, &RetryableError{Err: errTransient, RetryAfter: 5 * time.Second}))
+	assert.Equal(t, 10*time.Second, policy.Delay(1, &RetryableError{Err: errTransient, RetryAfter: time.Hour}))
+	for retry := 1; retry <= 6; retry++ {
+		delay := policy.Delay(retry, errTransient)
+		assert.Greater(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, 10*time.Second)
+	}
+}
@@ -0,0 +1,69 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// RetryableError marks a failure that may succeed if repeated, such as a
+// rate limit or a provider-side 5xx. RetryAfter is the delay requested by
+// the provider, zero when it did not send one.
+type RetryableError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err was classified as transient.
+func IsRetryable(err error) bool {
+	var retryable *RetryableError
+	return errors.As(err, &retryable)
+}
+
+// Delay returns the wait before the given retry (1-based): the provider's
+// Retry-After when present, otherwise exponential backoff with full jitter.
+func (p RetryPolicy) Delay(retry int, err error) time.Duration {
+	var retryable *RetryableError
+	if errors.As(err, &retryable) && retryable.RetryAfter > 0 {
+		if p.MaxDelay > 0 && retryable.RetryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return retryable.RetryAfter
+	}
+
+	backoff := p.BaseDelay << (retry - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff)) + 1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
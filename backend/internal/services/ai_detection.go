@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"codegrader-backend/internal/aidetect"
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/metrics"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+)
+
+// AIDetectionService estimates whether submissions were written with an AI
+// assistant. The estimate is advisory: it never changes a grade and only
+// brings a submission to the review queue.
+type AIDetectionService interface {
+	// Detect returns nil when detection is disabled. useLLM allows asking the
+	// model, when that is configured.
+	Detect(ctx context.Context, submission *models.CodeSubmission, useLLM bool) *aidetect.Result
+	// Flagged reports whether likelihood is high enough for the review queue.
+	Flagged(likelihood float64) bool
+	Threshold() float64
+}
+
+type aiDetectionService struct {
+	submissionRepo repositories.SubmissionRepository
+	openaiSvc      OpenAIService
+	cfg            config.AIDetectionConfig
+}
+
+func NewAIDetectionService(submissionRepo repositories.SubmissionRepository, openaiSvc OpenAIService, cfg config.AIDetectionConfig) AIDetectionService {
+	return &aiDetectionService{
+		submissionRepo: submissionRepo,
+		openaiSvc:      openaiSvc,
+		cfg:            cfg,
+	}
+}
+
+func (s *aiDetectionService) Detect(ctx context.Context, submission *models.CodeSubmission, useLLM bool) *aidetect.Result {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	input := aidetect.Input{
+		Code:     submission.Content,
+		FileType: submission.FileType,
+		Locale:   submission.Locale,
+		Metrics:  submission.Metrics,
+		Prior:    s.prior(ctx, submission),
+	}
+	if useLLM && s.cfg.UseLLM {
+		judgement, err := s.openaiSvc.JudgeAIGeneration(ctx, &AIJudgementInput{
+			Code:     submission.Content,
+			FileType: submission.FileType,
+			Locale:   submission.Locale,
+		})
+		if err != nil {
+			log.Printf("AI generation judgement failed for submission %s: %v", submission.ID, err)
+		} else {
+			input.LLM = judgement
+		}
+	}
+	return aidetect.Detect(input)
+}
+
+// prior returns the style features of the student's earlier submissions in
+// the same language. Submissions stored before detection existed are
+// measured on the spot.
+func (s *aiDetectionService) prior(ctx context.Context, submission *models.CodeSubmission) []aidetect.Features {
+	if submission.StudentID == "" || s.cfg.History <= 0 {
+		return nil
+	}
+	previous, err := s.submissionRepo.GetRecentByStudent(ctx, submission.StudentID, submission.FileType, s.cfg.History+1)
+	if err != nil {
+		log.Printf("Failed to load earlier submissions of student %s: %v", submission.StudentID, err)
+		return nil
+	}
+
+	var features []aidetect.Features
+	for _, p := range previous {
+		if p.ID == submission.ID || len(features) == s.cfg.History {
+			continue
+		}
+		if p.AIDetection != nil {
+			features = append(features, p.AIDetection.Features)
+			continue
+		}
+		m := p.Metrics
+		if m == nil {
+			m = metrics.Compute(p.Content, p.FileType)
+		}
+		features = append(features, aidetect.Extract(p.Content, p.FileType, m))
+	}
+	return features
+}
+
+func (s *aiDetectionService) Flagged(likelihood float64) bool {
+	return s.cfg.Enabled && likelihood >= s.cfg.ReviewThreshold
+}
+
+func (s *aiDetectionService) Threshold() float64 {
+	return s.cfg.ReviewThreshold
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"codegrader-backend/internal/aidetect"
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type studentHistoryRepo struct {
+	repositories.SubmissionRepository
+	previous []models.CodeSubmission
+	limit    int
+}
+
+func (r *studentHistoryRepo) GetRecentByStudent(ctx context.Context, studentID, fileType string, limit int) ([]models.CodeSubmission, error) {
+	r.limit = limit
+	return r.previous, nil
+}
+
+type judgeStub struct {
+	OpenAIService
+	judgement *aidetect.Judgement
+	err       error
+	calls     int
+}
+
+func (s *judgeStub) JudgeAIGeneration(ctx context.Context, input *AIJudgementInput) (*aidetect.Judgement, error) {
+	s.calls++
+	return s.judgement, s.err
+}
+
+func TestAIDetectionService_UsesStudentHistory(t *testing.T) {
+	stored := aidetect.Features{CommentDensity: 0.5}
+	repo := &studentHistoryRepo{previous: []models.CodeSubmission{
+		{ID: "new"},
+		{ID: "old-1", AIDetection: &aidetect.Result{Features: stored}},
+		{ID: "old-2", FileType: ".py", Content: "x=1\nprint(x)\n"},
+	}}
+	svc := NewAIDetectionService(repo, &judgeStub{}, config.AIDetectionConfig{Enabled: true, History: 5, ReviewThreshold: 0.8})
+
+	result := svc.Detect(context.Background(), &models.CodeSubmission{ID: "new", StudentID: "s1", FileType: ".py", Content: "print(1)\n"}, true)
+
+	require.NotNil(t, result)
+	assert.True(t, result.Advisory)
+	assert.Equal(t, 2, result.Prior)
+	assert.Equal(t, 6, repo.limit)
+	assert.True(t, svc.Flagged(0.8))
+	assert.False(t, svc.Flagged(0.79))
+}
+
+func TestAIDetectionService_LLMOnlyWhenAllowed(t *testing.T) {
+	judge := &judgeStub{judgement: &aidetect.Judgement{Likelihood: 1, Explanation: "шаблонные комментарии"}}
+	svc := NewAIDetectionService(&studentHistoryRepo{}, judge, config.AIDetectionConfig{Enabled: true, UseLLM: true})
+	sub := &models.CodeSubmission{ID: "1", FileType: ".py", Content: "print(1)\n"}
+
+	withoutBudget := svc.Detect(context.Background(), sub, false)
+	assert.Nil(t, withoutBudget.LLM)
+	assert.Equal(t, 0, judge.calls)
+
+	withLLM := svc.Detect(context.Background(), sub, true)
+	assert.Equal(t, judge.judgement, withLLM.LLM)
+
+	judge.err = errors.New("timeout")
+	failed := svc.Detect(context.Background(), sub, true)
+	assert.Nil(t, failed.LLM)
+
+	disabled := NewAIDetectionService(&studentHistoryRepo{}, judge, config.AIDetectionConfig{})
+	assert.Nil(t, disabled.Detect(context.Background(), sub, true))
+	assert.False(t, disabled.Flagged(1))
+}
+
+func TestParseAIJudgement(t *testing.T) {
+	judgement, ok := parseAIJudgement("**Вероятность:** 70%\nОбъяснение: однотипные docstring у всех функций,\nимена как в учебнике")
+	require.True(t, ok)
+	assert.Equal(t, 0.7, judgement.Likelihood)
+	assert.Equal(t, "однотипные docstring у всех функций, имена как в учебнике", judgement.Explanation)
+
+	judgement, ok = parseAIJudgement("Likelihood: [15]\nExplanation: typical student code")
+	require.True(t, ok)
+	assert.Equal(t, 0.15, judgement.Likelihood)
+
+	_, ok = parseAIJudgement("Likelihood: high")
+	assert.False(t, ok)
+	_, ok = parseAIJudgement("Likelihood: 150")
+	assert.False(t, ok)
+}
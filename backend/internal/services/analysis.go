@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/metrics"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/similarity"
+	"codegrader-backend/internal/tokens"
+
+	"gorm.io/gorm"
+)
+
+// AnalysisService grades a submission, reusing the stored result for code
+// that was already graded under the same assignment, prompt and model.
+type AnalysisService interface {
+	Validate(submission *models.CodeSubmission) error
+	Analyze(ctx context.Context, submission *models.CodeSubmission) (*AnalysisResult, error)
+	// Hint answers a practice submission. Practice results are not cached.
+	Hint(ctx context.Context, submission *models.CodeSubmission) (*PracticeResult, error)
+	ResolveLocale(ctx context.Context, courseID, requested, acceptLanguage string) string
+	PromptVersion(ctx context.Context, submission *models.CodeSubmission) (string, error)
+}
+
+var ErrSubmissionTooLarge = errors.New("submission is too large for automatic analysis")
+
+// Reasons for sending a graded submission to manual review.
+const (
+	reviewPromptInjection   = "prompt_injection"
+	reviewMissingGrade      = "missing_grade"
+	reviewGradeOutOfRange   = "grade_out_of_range"
+	reviewConflictingGrades = "conflicting_grades"
+	reviewLowConfidence     = "low_confidence"
+)
+
+type analysisService struct {
+	openaiSvc      OpenAIService
+	cacheRepo      repositories.AnalysisCacheRepository
+	assignmentRepo repositories.AssignmentRepository
+	courseRepo     repositories.CourseRepository
+	scales         ScaleService
+	prompts        *prompts.Store
+	cfg            config.OpenAIConfig
+}
+
+func NewAnalysisService(openaiSvc OpenAIService, cacheRepo repositories.AnalysisCacheRepository, assignmentRepo repositories.AssignmentRepository, courseRepo repositories.CourseRepository, scales ScaleService, promptStore *prompts.Store, cfg config.OpenAIConfig) AnalysisService {
+	return &analysisService{
+		openaiSvc:      openaiSvc,
+		cacheRepo:      cacheRepo,
+		assignmentRepo: assignmentRepo,
+		courseRepo:     courseRepo,
+		scales:         scales,
+		prompts:        promptStore,
+		cfg:            cfg,
+	}
+}
+
+// Validate rejects code above the configured input budget before any work
+// is spent on it.
+func (s *analysisService) Validate(submission *models.CodeSubmission) error {
+	if s.cfg.MaxInputTokens <= 0 {
+		return nil
+	}
+	if size := tokens.Count(submission.Content, s.openaiSvc.Model()); size > s.cfg.MaxInputTokens {
+		return fmt.Errorf("%w: about %d tokens, limit is %d", ErrSubmissionTooLarge, size, s.cfg.MaxInputTokens)
+	}
+	return nil
+}
+
+// Analyze grades submission and flags it for review when the code looks like
+// it addresses the grading model. The check runs on every call, since cached
+// results are shared by code that differs only in comments and formatting.
+func (s *analysisService) Analyze(ctx context.Context, submission *models.CodeSubmission) (*AnalysisResult, error) {
+	input := s.input(ctx, submission)
+	result, err := s.analyze(ctx, submission, input)
+	if err != nil {
+		return nil, err
+	}
+	result.Label = input.Scale.Label(result.Grade)
+	result.Passed = input.Scale.Passed(result.Grade)
+	if found := detectPromptInjection(submission.Content); len(found) > 0 {
+		log.Printf("Possible prompt injection in submission %s: %q", submission.ID, found)
+		result.ReviewReasons = append([]string{reviewPromptInjection}, result.ReviewReasons...)
+	}
+	return result, nil
+}
+
+func (s *analysisService) Hint(ctx context.Context, submission *models.CodeSubmission) (*PracticeResult, error) {
+	return s.openaiSvc.GiveHints(ctx, s.input(ctx, submission))
+}
+
+func (s *analysisService) analyze(ctx context.Context, submission *models.CodeSubmission, input *AnalysisInput) (*AnalysisResult, error) {
+	if !s.cfg.CacheResults {
+		return s.openaiSvc.AnalyzeCode(ctx, input)
+	}
+
+	version, err := s.openaiSvc.PromptVersion(input)
+	if err != nil {
+		return nil, err
+	}
+	key := &models.AnalysisCacheEntry{
+		ContentHash:   ensureContentHash(submission),
+		FileType:      submission.FileType,
+		AssignmentID:  submission.AssignmentID,
+		PromptVersion: version,
+		Model:         s.openaiSvc.Model(),
+	}
+
+	source := sourceHash(submission.Content)
+	cached, err := s.cacheRepo.Get(ctx, key)
+	if err == nil {
+		log.Printf("Reusing cached analysis for submission %s", submission.ID)
+		// The content hash ignores formatting and comments, so the cached
+		// line numbers only fit byte-identical code.
+		var annotations []models.Annotation
+		if cached.SourceHash == source {
+			annotations = cached.Annotations
+		}
+		return &AnalysisResult{
+			Grade:         cached.Grade,
+			Feedback:      cached.Feedback,
+			Annotations:   annotations,
+			ReviewReasons: splitList(cached.ReviewReasons),
+			Samples:       parseGrades(cached.Samples),
+			Confidence:    cached.Confidence,
+			Comparison:    cached.Comparison,
+		}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Analysis cache lookup failed: %v", err)
+	}
+
+	result, err := s.openaiSvc.AnalyzeCode(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Grade = result.Grade
+	key.Feedback = result.Feedback
+	key.Annotations = result.Annotations
+	key.SourceHash = source
+	key.ReviewReasons = strings.Join(result.ReviewReasons, ",")
+	key.Samples = formatGrades(result.Samples)
+	key.Confidence = result.Confidence
+	key.Comparison = result.Comparison
+	if err := s.cacheRepo.Save(ctx, key); err != nil {
+		log.Printf("Failed to cache analysis for submission %s: %v", submission.ID, err)
+	}
+
+	return result, nil
+}
+
+// applyAnalysis stores result on submission.
+func applyAnalysis(submission *models.CodeSubmission, result *AnalysisResult) {
+	submission.Grade = result.Grade
+	submission.GradeLabel = result.Label
+	submission.Passed = result.Passed
+	submission.Feedback = result.Feedback
+	submission.NeedsReview = len(result.ReviewReasons) > 0
+	submission.ReviewReasons = strings.Join(result.ReviewReasons, ",")
+	submission.GradeSamples = formatGrades(result.Samples)
+	submission.GradeConfidence = result.Confidence
+	submission.ReferenceComparison = result.Comparison
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+func formatGrades(grades []int) string {
+	parts := make([]string, len(grades))
+	for i, g := range grades {
+		parts[i] = strconv.Itoa(g)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseGrades(list string) []int {
+	var grades []int
+	for _, part := range splitList(list) {
+		if g, err := strconv.Atoi(part); err == nil {
+			grades = append(grades, g)
+		}
+	}
+	return grades
+}
+
+// PromptVersion identifies the prompt and settings submission would be
+// graded with.
+func (s *analysisService) PromptVersion(ctx context.Context, submission *models.CodeSubmission) (string, error) {
+	return s.openaiSvc.PromptVersion(s.input(ctx, submission))
+}
+
+func (s *analysisService) input(ctx context.Context, submission *models.CodeSubmission) *AnalysisInput {
+	// Submissions that were not stored, such as calibration samples, have
+	// no metrics yet.
+	codeMetrics := submission.Metrics
+	if codeMetrics == nil {
+		codeMetrics = metrics.Compute(submission.Content, submission.FileType)
+	}
+	return &AnalysisInput{
+		Code:       submission.Content,
+		FileType:   submission.FileType,
+		Locale:     submission.Locale,
+		Assignment: s.assignment(ctx, submission.AssignmentID),
+		Scale:      s.scales.Resolve(ctx, submission.CourseID, submission.AssignmentID),
+		Metrics:    codeMetrics,
+		Lint:       submission.LintFindings,
+	}
+}
+
+// ResolveLocale picks the feedback language: the one requested explicitly,
+// then the course setting, then the request's Accept-Language. Browsers always
+// send the header, so it is only a fallback for courses without a language.
+func (s *analysisService) ResolveLocale(ctx context.Context, courseID, requested, acceptLanguage string) string {
+	if requested != "" && s.prompts.Locale(requested) == requested {
+		return requested
+	}
+	if courseID != "" {
+		course, err := s.courseRepo.GetByID(ctx, courseID)
+		if err == nil && course.Locale != "" {
+			return s.prompts.Locale(course.Locale)
+		}
+	}
+	if locale := s.prompts.MatchLocale(acceptLanguage); locale != "" {
+		return locale
+	}
+	return s.prompts.Locale("")
+}
+
+// assignment loads the settings of assignmentID; a missing assignment just
+// means the default prompt without rubric or task statement.
+func (s *analysisService) assignment(ctx context.Context, assignmentID string) *models.Assignment {
+	if assignmentID == "" {
+		return nil
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load assignment %s: %v", assignmentID, err)
+		}
+		return nil
+	}
+	return assignment
+}
+
+// sourceHash identifies code byte for byte, unlike the content hash.
+func sourceHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func ensureContentHash(submission *models.CodeSubmission) string {
+	if submission.ContentHash == "" {
+		submission.ContentHash = similarity.ContentHash(submission.Content, submission.FileType)
+	}
+	return submission.ContentHash
+}
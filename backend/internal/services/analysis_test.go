@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLocale(t *testing.T) {
+	store, err := prompts.Load("", "ru")
+	require.NoError(t, err)
+	courses := &courseStub{courses: map[string]*models.Course{
+		"english": {ID: "english", Locale: "en"},
+		"unset":   {ID: "unset"},
+	}}
+	svc := NewAnalysisService(nil, nil, nil, courses, NewScaleService(nil, nil, grading.Default()), store, config.OpenAIConfig{})
+
+	tests := []struct {
+		name           string
+		courseID       string
+		requested      string
+		acceptLanguage string
+		expected       string
+	}{
+		{"requested wins", "english", "ru", "en-US,en;q=0.9", "ru"},
+		{"course before browser", "english", "", "ru-RU,ru;q=0.9", "en"},
+		{"browser without course locale", "unset", "", "en-US,en;q=0.9", "en"},
+		{"browser without course", "", "", "en-US,en;q=0.9", "en"},
+		{"unknown course", "missing", "", "en", "en"},
+		{"unsupported request", "", "de", "de-DE", "ru"},
+		{"default", "", "", "", "ru"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, svc.ResolveLocale(context.Background(), tt.courseID, tt.requested, tt.acceptLanguage))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"codegrader-backend/internal/models"
+)
+
+// annotationLine is one remark as the model writes it.
+type annotationLine struct {
+	StartLine  int    `json:"start_line"`
+	EndLine    int    `json:"end_line"`
+	Severity   string `json:"severity"`
+	Category   string `json:"category"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion"`
+}
+
+// parseAnnotations reads the remarks listed after the "Замечания:" or
+// "Annotations:" line of a response, one JSON object per line, and returns
+// the response without them. lines is the length of the graded file:
+// remarks past its end are dropped and ranges are clamped to it.
+func parseAnnotations(response string, lines int) (string, []models.Annotation) {
+	var feedback []string
+	var annotations []models.Annotation
+	inSection := false
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if annotationsHeading(trimmed) {
+			inSection = true
+			continue
+		}
+		if inSection && (trimmed == "" || strings.HasPrefix(trimmed, "```")) {
+			continue
+		}
+		if inSection && strings.HasPrefix(trimmed, "{") {
+			if annotation, ok := parseAnnotation(strings.TrimSuffix(trimmed, ","), lines); ok {
+				annotations = append(annotations, annotation)
+			}
+			continue
+		}
+		feedback = append(feedback, line)
+	}
+	return strings.TrimSpace(strings.Join(feedback, "\n")), annotations
+}
+
+func annotationsHeading(line string) bool {
+	switch strings.ToLower(strings.Trim(line, "*#_> ")) {
+	case "замечания:", "annotations:", "замечания", "annotations":
+		return true
+	default:
+		return false
+	}
+}
+
+func parseAnnotation(text string, lines int) (models.Annotation, bool) {
+	var raw annotationLine
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return models.Annotation{}, false
+	}
+	if strings.TrimSpace(raw.Message) == "" || raw.StartLine > lines {
+		return models.Annotation{}, false
+	}
+
+	annotation := models.Annotation{
+		StartLine:  max(raw.StartLine, 1),
+		EndLine:    min(raw.EndLine, lines),
+		Severity:   strings.ToLower(strings.TrimSpace(raw.Severity)),
+		Category:   strings.ToLower(strings.TrimSpace(raw.Category)),
+		Message:    strings.TrimSpace(raw.Message),
+		Suggestion: strings.TrimRight(raw.Suggestion, " \n"),
+	}
+	if annotation.EndLine < annotation.StartLine {
+		annotation.EndLine = annotation.StartLine
+	}
+	switch annotation.Severity {
+	case models.SeverityError, models.SeverityWarning, models.SeverityInfo:
+	default:
+		annotation.Severity = models.SeverityInfo
+	}
+	if !contains(models.AnnotationCategories, annotation.Category) {
+		annotation.Category = "other"
+	}
+	return annotation, true
+}
+
+// numberLines prefixes every line of code with its number in the file, so
+// that the model can refer to lines without counting them.
+func numberLines(code string, first int) string {
+	lines := strings.Split(code, "\n")
+	width := len(fmt.Sprint(first + len(lines) - 1))
+	for i, line := range lines {
+		lines[i] = fmt.Sprintf("%*d| %s", width, first+i, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func lineCount(code string) int {
+	return strings.Count(code, "\n") + 1
+}
+
+// annotationsFor returns result's annotations followed by the linter
+// findings as records of submission.
+func annotationsFor(submission *models.CodeSubmission, result *AnalysisResult) []models.Annotation {
+	annotations := make([]models.Annotation, 0, len(result.Annotations)+len(submission.LintFindings))
+	for _, annotation := range result.Annotations {
+		annotation.SubmissionID = submission.ID
+		annotation.File = submission.FileName
+		annotations = append(annotations, annotation)
+	}
+	for _, finding := range submission.LintFindings {
+		message := finding.Message
+		if finding.Rule != "" {
+			message += " (" + finding.Rule + ")"
+		}
+		annotations = append(annotations, models.Annotation{
+			SubmissionID: submission.ID,
+			File:         submission.FileName,
+			StartLine:    finding.Line,
+			EndLine:      finding.Line,
+			Severity:     finding.Severity,
+			Category:     "style",
+			Message:      message,
+			Source:       finding.Tool,
+		})
+	}
+	return annotations
+}
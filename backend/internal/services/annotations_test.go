@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/lint"
+	"codegrader-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestParseAnnotations_SplitsRemarksFromFeedback(t *testing.T) {
+	response := `Оценка: 4
+Комментарии: решение верное, но медленное.
+Замечания:
+` + "```json" + `
+{"start_line": 3, "end_line": 5, "severity": "Warning", "category": "performance", "message": "Поиск в списке внутри цикла", "suggestion": "seen = set(items)"},
+{"start_line": 1, "end_line": 1, "severity": "info", "category": "naming", "message": "Неинформативное имя переменной"}
+` + "```"
+
+	feedback, annotations := parseAnnotations(response, 10)
+
+	assert.Equal(t, "Оценка: 4\nКомментарии: решение верное, но медленное.", feedback)
+	require.Len(t, annotations, 2)
+	assert.Equal(t, models.Annotation{
+		StartLine:  3,
+		EndLine:    5,
+		Severity:   models.SeverityWarning,
+		Category:   "performance",
+		Message:    "Поиск в списке внутри цикла",
+		Suggestion: "seen = set(items)",
+	}, annotations[0])
+	assert.Equal(t, "other", annotations[1].Category)
+}
+
+func TestParseAnnotations_ClampsLines(t *testing.T) {
+	response := `Grade: 5
+Annotations:
+{"start_line": 0, "end_line": 2, "severity": "fatal", "category": "style", "message": "a"}
+{"start_line": 8, "end_line": 40, "severity": "error", "category": "correctness", "message": "b"}
+{"start_line": 7, "end_line": 3, "severity": "info", "category": "style", "message": "c"}
+{"start_line": 12, "end_line": 12, "severity": "info", "category": "style", "message": "past the end"}
+{"start_line": 2, "end_line": 2, "severity": "info", "category": "style", "message": ""}
+{not json}`
+
+	_, annotations := parseAnnotations(response, 10)
+
+	require.Len(t, annotations, 3)
+	assert.Equal(t, 1, annotations[0].StartLine)
+	assert.Equal(t, models.SeverityInfo, annotations[0].Severity)
+	assert.Equal(t, 10, annotations[1].EndLine)
+	assert.Equal(t, 7, annotations[2].EndLine)
+}
+
+func TestParseAnalysisResponse_KeepsGradeWithoutAnnotations(t *testing.T) {
+	input := &AnalysisInput{Code: "print(1)", Scale: grading.Default()}
+
+	result := parseAnalysisResponse("Оценка: 5\nКомментарии: отлично", input)
+
+	assert.Equal(t, 5, result.Grade)
+	assert.Empty(t, result.ReviewReasons)
+	assert.Empty(t, result.Annotations)
+	assert.Equal(t, "Оценка: 5\nКомментарии: отлично", result.Feedback)
+}
+
+func TestNumberLines(t *testing.T) {
+	assert.Equal(t, " 9| a\n10| b", numberLines("a\nb", 9))
+	assert.Equal(t, "1| x = 1", numberLines("x = 1", 1))
+}
+
+func TestAnnotationsFor_SetsSubmissionAndFile(t *testing.T) {
+	sub := &models.CodeSubmission{ID: "sub-1", FileName: "main.py"}
+	result := &AnalysisResult{Annotations: []models.Annotation{{StartLine: 1, EndLine: 1, Message: "m"}}}
+
+	annotations := annotationsFor(sub, result)
+
+	assert.Equal(t, "sub-1", annotations[0].SubmissionID)
+	assert.Equal(t, "main.py", annotations[0].File)
+	assert.Empty(t, result.Annotations[0].SubmissionID)
+}
+
+func TestAnnotationsFor_AddsLintFindings(t *testing.T) {
+	sub := &models.CodeSubmission{ID: "sub-1", FileName: "main.py", LintFindings: []lint.Finding{
+		{Tool: "pylint", Rule: "C0103 invalid-name", Severity: lint.SeverityInfo, Line: 2, Message: "bad name"},
+	}}
+	result := &AnalysisResult{Annotations: []models.Annotation{{StartLine: 1, EndLine: 1, Message: "m"}}}
+
+	annotations := annotationsFor(sub, result)
+
+	require.Len(t, annotations, 2)
+	assert.Equal(t, models.Annotation{
+		SubmissionID: "sub-1", File: "main.py", StartLine: 2, EndLine: 2, Severity: "info",
+		Category: "style", Message: "bad name (C0103 invalid-name)", Source: "pylint",
+	}, annotations[1])
+}
+
+type memoryCacheRepo struct {
+	entry *models.AnalysisCacheEntry
+}
+
+func (r *memoryCacheRepo) Get(ctx context.Context, key *models.AnalysisCacheEntry) (*models.AnalysisCacheEntry, error) {
+	if r.entry == nil || r.entry.ContentHash != key.ContentHash {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.entry, nil
+}
+
+func (r *memoryCacheRepo) Save(ctx context.Context, entry *models.AnalysisCacheEntry) error {
+	r.entry = entry
+	return nil
+}
+
+func TestAnalyze_ReusesCachedAnnotationsOnlyForIdenticalCode(t *testing.T) {
+	openaiSvc := &stubOpenAIService{result: &AnalysisResult{
+		Grade:       4,
+		Feedback:    "ok",
+		Annotations: []models.Annotation{{StartLine: 2, EndLine: 2, Message: "Лишний вывод"}},
+	}}
+	svc := NewAnalysisService(openaiSvc, &memoryCacheRepo{}, nil, nil, NewScaleService(nil, nil, grading.Default()), nil, config.OpenAIConfig{CacheResults: true})
+	code := "x = 1\nprint(x)\n"
+
+	_, err := svc.Analyze(context.Background(), &models.CodeSubmission{ID: "a", FileType: ".py", Content: code})
+	require.NoError(t, err)
+
+	openaiSvc.result = &AnalysisResult{Grade: 1}
+	same, err := svc.Analyze(context.Background(), &models.CodeSubmission{ID: "b", FileType: ".py", Content: code})
+	require.NoError(t, err)
+	assert.Equal(t, 4, same.Grade)
+	assert.Len(t, same.Annotations, 1)
+
+	commented := "# first exercise\n\nx = 1\nprint(x)\n"
+	shifted, err := svc.Analyze(context.Background(), &models.CodeSubmission{ID: "c", FileType: ".py", Content: commented})
+	require.NoError(t, err)
+	assert.Equal(t, 4, shifted.Grade)
+	assert.Empty(t, shifted.Annotations)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/metrics"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+	"codegrader-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidAssignment = errors.New("invalid assignment settings")
+
+// maxReferenceSolutions keeps the reference solutions from crowding the
+// student's code out of the prompt.
+const maxReferenceSolutions = 5
+
+type AssignmentService interface {
+	GetAssignment(ctx context.Context, id string) (*models.Assignment, error)
+	UpdateAssignment(ctx context.Context, id string, req *models.AssignmentRequest) (*models.Assignment, error)
+	// GetReferenceSolutions and UpdateReferenceSolutions serve teachers; the
+	// solutions are left out of the assignment itself.
+	GetReferenceSolutions(ctx context.Context, id string) ([]models.ReferenceSolution, error)
+	UpdateReferenceSolutions(ctx context.Context, id string, solutions []models.ReferenceSolution) ([]models.ReferenceSolution, error)
+}
+
+type assignmentService struct {
+	repo         repositories.AssignmentRepository
+	prompts      *prompts.Store
+	defaultScale *grading.Scale
+}
+
+func NewAssignmentService(repo repositories.AssignmentRepository, promptStore *prompts.Store, defaultScale *grading.Scale) AssignmentService {
+	return &assignmentService{repo: repo, prompts: promptStore, defaultScale: defaultScale}
+}
+
+func (s *assignmentService) GetAssignment(ctx context.Context, id string) (*models.Assignment, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// UpdateAssignment creates or replaces the assignment settings other than
+// the reference solutions. A prompt override must be a valid template in
+// every supported locale.
+func (s *assignmentService) UpdateAssignment(ctx context.Context, id string, req *models.AssignmentRequest) (*models.Assignment, error) {
+	scale := s.defaultScale
+	if req.GradingScale != nil {
+		if err := req.GradingScale.Normalize(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAssignment, err)
+		}
+		scale = req.GradingScale
+	}
+
+	if req.AnalysisPrompt != "" {
+		for _, locale := range s.prompts.Locales() {
+			prompt, err := s.prompts.Parse(prompts.Analysis, locale, req.AnalysisPrompt)
+			if err == nil {
+				_, err = prompt.Render(promptData{
+					Language:   "Python",
+					Code:       "print(1)",
+					Scale:      scale,
+					Metrics:    metrics.Compute("print(1)", ".py"),
+					References: []referenceData{{Name: "#1", Language: "Python", Code: "print(2)"}},
+				})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidAssignment, err)
+			}
+		}
+	}
+
+	assignment, err := s.getOrNew(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment.CourseID = req.CourseID
+	assignment.Title = req.Title
+	assignment.TaskStatement = req.TaskStatement
+	assignment.Rubric = req.Rubric
+	assignment.AnalysisPrompt = req.AnalysisPrompt
+	assignment.GradingScale = req.GradingScale
+	assignment.Version++
+
+	if err := s.repo.Save(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("failed to save assignment: %w", err)
+	}
+	return assignment, nil
+}
+
+func (s *assignmentService) GetReferenceSolutions(ctx context.Context, id string) ([]models.ReferenceSolution, error) {
+	assignment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return assignment.ReferenceSolutions, nil
+}
+
+// UpdateReferenceSolutions replaces the reference solutions of the
+// assignment, creating it if needed. Solutions must not be empty.
+func (s *assignmentService) UpdateReferenceSolutions(ctx context.Context, id string, solutions []models.ReferenceSolution) ([]models.ReferenceSolution, error) {
+	if len(solutions) > maxReferenceSolutions {
+		return nil, fmt.Errorf("%w: at most %d reference solutions are allowed", ErrInvalidAssignment, maxReferenceSolutions)
+	}
+	for i, r := range solutions {
+		if strings.TrimSpace(r.Content) == "" {
+			return nil, fmt.Errorf("%w: reference solution %d is empty", ErrInvalidAssignment, i+1)
+		}
+		if r.FileType != "" && getLanguageName(r.FileType) == "Unknown" {
+			return nil, fmt.Errorf("%w: unsupported file type of reference solution %d: %s", ErrInvalidAssignment, i+1, r.FileType)
+		}
+	}
+
+	assignment, err := s.getOrNew(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	assignment.ReferenceSolutions = solutions
+	assignment.Version++
+
+	if err := s.repo.Save(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("failed to save assignment: %w", err)
+	}
+	return assignment.ReferenceSolutions, nil
+}
+
+func (s *assignmentService) getOrNew(ctx context.Context, id string) (*models.Assignment, error) {
+	assignment, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Assignment{ID: id}, nil
+	}
+	return assignment, err
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"codegrader-backend/internal/calibration"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/resilience"
+
+	"github.com/google/uuid"
+)
+
+// CalibrationService runs the grading pipeline over teacher-labelled
+// submissions and measures the agreement, so prompt and model changes can be
+// compared before they reach students.
+type CalibrationService interface {
+	// CreateRun stores a run for the background worker started by Run.
+	CreateRun(ctx context.Context, req *models.CalibrationRequest) (*models.EvaluationRun, error)
+	// Evaluate stores a run and grades it before returning.
+	Evaluate(ctx context.Context, req *models.CalibrationRequest) (*models.EvaluationRun, error)
+	GetRuns(ctx context.Context) ([]models.EvaluationRun, error)
+	GetRun(ctx context.Context, id string) (*models.EvaluationRun, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+var ErrInvalidDataset = errors.New("invalid calibration dataset")
+
+type calibrationService struct {
+	repo        repositories.EvaluationRepository
+	analysisSvc AnalysisService
+	openaiSvc   OpenAIService
+}
+
+func NewCalibrationService(repo repositories.EvaluationRepository, analysisSvc AnalysisService, openaiSvc OpenAIService) CalibrationService {
+	return &calibrationService{repo: repo, analysisSvc: analysisSvc, openaiSvc: openaiSvc}
+}
+
+func (s *calibrationService) CreateRun(ctx context.Context, req *models.CalibrationRequest) (*models.EvaluationRun, error) {
+	run, err := newEvaluationRun(req, models.EvaluationStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to create evaluation run: %w", err)
+	}
+	return run, nil
+}
+
+func (s *calibrationService) Evaluate(ctx context.Context, req *models.CalibrationRequest) (*models.EvaluationRun, error) {
+	run, err := newEvaluationRun(req, models.EvaluationStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to create evaluation run: %w", err)
+	}
+	if err := s.execute(ctx, run); err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+func (s *calibrationService) GetRuns(ctx context.Context) ([]models.EvaluationRun, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *calibrationService) GetRun(ctx context.Context, id string) (*models.EvaluationRun, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Run grades pending runs every interval. A run stays pending until all its
+// items are graded, so a restart or a provider outage only delays it.
+func (s *calibrationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runs, err := s.repo.GetPending(ctx)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					log.Printf("Failed to get pending evaluation runs: %v", err)
+				}
+				continue
+			}
+			for i := range runs {
+				if err := s.execute(ctx, &runs[i]); err != nil {
+					log.Printf("Evaluation run %s interrupted: %v", runs[i].ID, err)
+					break
+				}
+			}
+		}
+	}
+}
+
+// execute grades the items of run that have no grade yet and stores the
+// metrics. Provider outages and cancellation leave the run as it is, other
+// errors fail it.
+func (s *calibrationService) execute(ctx context.Context, run *models.EvaluationRun) error {
+	log.Printf("Evaluating run %s (%s): %d samples", run.ID, run.Name, len(run.Items))
+
+	for i := range run.Items {
+		item := &run.Items[i]
+		if item.Graded {
+			continue
+		}
+
+		sub := &models.CodeSubmission{
+			ID:           fmt.Sprintf("calibration-%s-%d", run.ID, item.Position),
+			FileName:     item.FileName,
+			FileType:     item.FileType,
+			AssignmentID: item.AssignmentID,
+			Content:      item.Content,
+			Locale:       s.analysisSvc.ResolveLocale(ctx, "", item.Locale, ""),
+		}
+
+		version, err := s.analysisSvc.PromptVersion(ctx, sub)
+		if err == nil {
+			var result *AnalysisResult
+			result, err = s.analysisSvc.Analyze(withUsageScope(ctx, sub), sub)
+			if err == nil {
+				item.Grade = result.Grade
+				item.Graded = true
+				item.PromptVersion = version
+				item.ReviewReasons = strings.Join(result.ReviewReasons, ",")
+				err = s.repo.UpdateItem(ctx, item)
+			}
+		}
+		if err != nil {
+			if resilience.Unavailable(err) || ctx.Err() != nil {
+				return err
+			}
+			run.Status = models.EvaluationStatusFailed
+			run.Error = fmt.Sprintf("sample %d: %v", item.Position, err)
+			if updateErr := s.repo.Update(ctx, run); updateErr != nil {
+				log.Printf("Failed to update evaluation run %s: %v", run.ID, updateErr)
+			}
+			return err
+		}
+	}
+
+	completeEvaluationRun(run, s.openaiSvc.Model())
+	if err := s.repo.Update(ctx, run); err != nil {
+		return fmt.Errorf("failed to update evaluation run %s: %w", run.ID, err)
+	}
+	log.Printf("Evaluation run %s completed: exact match %.2f, MAE %.2f, kappa %.2f", run.ID, run.ExactMatch, run.MAE, run.Kappa)
+	return nil
+}
+
+func completeEvaluationRun(run *models.EvaluationRun, model string) {
+	expected := make([]int, len(run.Items))
+	actual := make([]int, len(run.Items))
+	versions := make(map[string]bool)
+	for i, item := range run.Items {
+		expected[i] = item.TeacherGrade
+		actual[i] = item.Grade
+		versions[item.PromptVersion] = true
+	}
+
+	metrics := calibration.Agreement(expected, actual)
+	run.ExactMatch = metrics.ExactMatch
+	run.MAE = metrics.MAE
+	run.Kappa = metrics.Kappa
+	run.Labels = metrics.Labels
+	run.Confusion = metrics.Confusion
+	run.Model = model
+	run.PromptVersion = "mixed"
+	if len(versions) == 1 && len(run.Items) > 0 {
+		run.PromptVersion = run.Items[0].PromptVersion
+	}
+
+	now := time.Now()
+	run.CompletedAt = &now
+	run.Status = models.EvaluationStatusCompleted
+}
+
+func newEvaluationRun(req *models.CalibrationRequest, status string) (*models.EvaluationRun, error) {
+	if len(req.Samples) == 0 {
+		return nil, fmt.Errorf("%w: no samples", ErrInvalidDataset)
+	}
+
+	run := &models.EvaluationRun{
+		ID:     uuid.New().String(),
+		Name:   req.Name,
+		Status: status,
+		Size:   len(req.Samples),
+		Items:  make([]models.EvaluationItem, len(req.Samples)),
+	}
+	for i, sample := range req.Samples {
+		if getLanguageName(sample.FileType) == "Unknown" {
+			return nil, fmt.Errorf("%w: sample %d has unsupported file type %q", ErrInvalidDataset, i+1, sample.FileType)
+		}
+		if strings.TrimSpace(sample.Content) == "" {
+			return nil, fmt.Errorf("%w: sample %d has no content", ErrInvalidDataset, i+1)
+		}
+		if sample.TeacherGrade == nil {
+			return nil, fmt.Errorf("%w: sample %d has no teacher_grade", ErrInvalidDataset, i+1)
+		}
+		run.Items[i] = models.EvaluationItem{
+			RunID:        run.ID,
+			Position:     i + 1,
+			FileName:     sample.FileName,
+			FileType:     sample.FileType,
+			AssignmentID: sample.AssignmentID,
+			Locale:       sample.Locale,
+			Content:      sample.Content,
+			TeacherGrade: *sample.TeacherGrade,
+		}
+	}
+	return run, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryEvaluationRepo struct {
+	repositories.EvaluationRepository
+	runs map[string]*models.EvaluationRun
+}
+
+func (r *memoryEvaluationRepo) Create(ctx context.Context, run *models.EvaluationRun) error {
+	r.runs[run.ID] = run
+	return nil
+}
+
+func (r *memoryEvaluationRepo) Update(ctx context.Context, run *models.EvaluationRun) error {
+	return nil
+}
+
+func (r *memoryEvaluationRepo) UpdateItem(ctx context.Context, item *models.EvaluationItem) error {
+	return nil
+}
+
+// gradeByContent grades a submission with the digit its content ends with.
+type gradeByContent struct {
+	AnalysisService
+}
+
+func (a *gradeByContent) Analyze(ctx context.Context, sub *models.CodeSubmission) (*AnalysisResult, error) {
+	return &AnalysisResult{Grade: int(sub.Content[len(sub.Content)-1] - '0')}, nil
+}
+
+func (a *gradeByContent) PromptVersion(ctx context.Context, sub *models.CodeSubmission) (string, error) {
+	return "v1", nil
+}
+
+func (a *gradeByContent) ResolveLocale(ctx context.Context, courseID, requested, acceptLanguage string) string {
+	return "ru"
+}
+
+func TestCalibration_EvaluateStoresMetrics(t *testing.T) {
+	repo := &memoryEvaluationRepo{runs: make(map[string]*models.EvaluationRun)}
+	svc := NewCalibrationService(repo, &gradeByContent{}, &stubOpenAIService{})
+
+	run, err := svc.Evaluate(context.Background(), &models.CalibrationRequest{
+		Name: "baseline",
+		Samples: []models.CalibrationSample{
+			{FileType: ".py", Content: "x = 5", TeacherGrade: intPtr(5)},
+			{FileType: ".py", Content: "x = 4", TeacherGrade: intPtr(5)},
+			{FileType: ".c", Content: "int x = 3", TeacherGrade: intPtr(3)},
+			{FileType: ".c", Content: "int x = 4", TeacherGrade: intPtr(4)},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.EvaluationStatusCompleted, run.Status)
+	assert.Equal(t, "v1", run.PromptVersion)
+	assert.Equal(t, openAIModel, run.Model)
+	assert.InDelta(t, 0.75, run.ExactMatch, 1e-9)
+	assert.InDelta(t, 0.25, run.MAE, 1e-9)
+	assert.Equal(t, []int{3, 4, 5}, run.Labels)
+	assert.Equal(t, [][]int{{1, 0, 0}, {0, 1, 0}, {0, 1, 1}}, run.Confusion)
+	assert.NotNil(t, run.CompletedAt)
+	assert.Same(t, run, repo.runs[run.ID])
+}
+
+func TestCalibration_RejectsInvalidSamples(t *testing.T) {
+	svc := NewCalibrationService(&memoryEvaluationRepo{runs: make(map[string]*models.EvaluationRun)}, &gradeByContent{}, &stubOpenAIService{})
+
+	tests := map[string]models.CalibrationSample{
+		"file type": {FileType: ".rb", Content: "puts 1", TeacherGrade: intPtr(4)},
+		"content":   {FileType: ".py", Content: "  ", TeacherGrade: intPtr(4)},
+		"grade":     {FileType: ".py", Content: "print(1)"},
+	}
+	for name, sample := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.CreateRun(context.Background(), &models.CalibrationRequest{Samples: []models.CalibrationSample{sample}})
+			assert.ErrorIs(t, err, ErrInvalidDataset)
+		})
+	}
+
+	_, err := svc.CreateRun(context.Background(), &models.CalibrationRequest{})
+	assert.ErrorIs(t, err, ErrInvalidDataset)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"strings"
+
+	"codegrader-backend/internal/similarity"
+	"codegrader-backend/internal/tokens"
+)
+
+type codeChunk struct {
+	startLine int
+	endLine   int
+	text      string
+}
+
+// splitIntoChunks cuts code into pieces of at most budget tokens. Cuts are
+// made between top-level declarations where possible, so that a function
+// is only split when it does not fit into a chunk on its own.
+func splitIntoChunks(code, fileType, model string, budget int) []codeChunk {
+	lines := strings.Split(code, "\n")
+	boundaries := topLevelBoundaries(code, fileType, len(lines))
+
+	var chunks []codeChunk
+	start, size := 0, 0
+	flush := func(end int) {
+		if end > start {
+			chunks = append(chunks, codeChunk{
+				startLine: start + 1,
+				endLine:   end,
+				text:      strings.Join(lines[start:end], "\n"),
+			})
+		}
+		start, size = end, 0
+	}
+
+	segmentStart := 0
+	for i := range lines {
+		if !boundaries[i] && i != len(lines)-1 {
+			continue
+		}
+
+		segment := lines[segmentStart : i+1]
+		segmentSize := tokens.Count(strings.Join(segment, "\n"), model)
+		if size > 0 && size+segmentSize > budget {
+			flush(segmentStart)
+		}
+
+		if segmentSize > budget {
+			// A single declaration larger than the budget is split by lines.
+			for j, line := range segment {
+				lineSize := tokens.Count(line, model) + 1
+				if size > 0 && size+lineSize > budget {
+					flush(segmentStart + j)
+				}
+				size += lineSize
+			}
+		} else {
+			size += segmentSize
+		}
+		segmentStart = i + 1
+	}
+	flush(len(lines))
+
+	return chunks
+}
+
+// topLevelBoundaries marks the lines after which the code is back at the top
+// level: brace depth zero, or for Python the line before a non-indented one.
+func topLevelBoundaries(code, fileType string, lineCount int) []bool {
+	boundaries := make([]bool, lineCount)
+	scanned := similarity.Scan(code, fileType)
+
+	if fileType == ".py" {
+		for _, t := range scanned {
+			if t.Column == 0 && t.Line >= 2 && t.Line-2 < lineCount {
+				boundaries[t.Line-2] = true
+			}
+		}
+		return boundaries
+	}
+
+	depth := 0
+	for i, t := range scanned {
+		switch t.Text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+		lastOnLine := i == len(scanned)-1 || scanned[i+1].Line != t.Line
+		if lastOnLine && depth <= 0 && t.Line-1 < lineCount {
+			boundaries[t.Line-1] = true
+		}
+	}
+	return boundaries
+}
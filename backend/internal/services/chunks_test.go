@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitIntoChunks_CutsBetweenFunctions(t *testing.T) {
+	var code strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&code, "int f%d(int x) {\n    int y = x * %d;\n    return y + 1;\n}\n", i, i)
+	}
+
+	chunks := splitIntoChunks(code.String(), ".c", "gpt-4o-mini", 120)
+
+	assert.Greater(t, len(chunks), 1)
+	for _, chunk := range chunks {
+		assert.True(t, strings.HasPrefix(chunk.text, "int f"), "chunk at line %d starts mid-function", chunk.startLine)
+	}
+	assert.Equal(t, 1, chunks[0].startLine)
+	assert.Equal(t, strings.Count(code.String(), "\n")+1, chunks[len(chunks)-1].endLine)
+}
+
+func TestSplitIntoChunks_SmallFileIsOneChunk(t *testing.T) {
+	code := "def main():\n    print('hi')\n\nmain()\n"
+
+	chunks := splitIntoChunks(code, ".py", "gpt-4o-mini", 1000)
+
+	assert.Len(t, chunks, 1)
+	assert.Equal(t, code, chunks[0].text)
+}
+
+func TestChunkBudget_LeavesRoomForThePrompt(t *testing.T) {
+	store, err := prompts.Load("", "ru")
+	require.NoError(t, err)
+	chunkPrompt, err := store.Get(prompts.AnalysisChunk, "ru")
+	require.NoError(t, err)
+	svc := &openAIService{prompts: store, chunkTokens: 4000}
+	code := strings.Repeat("total += values[i]\n", 200)
+
+	input := &AnalysisInput{Code: code, FileType: ".py", Locale: "ru", Scale: grading.Default()}
+	plain, err := svc.chunkBudget(newPromptData(input), chunkPrompt, "system", code, "gpt-4o-mini")
+	require.NoError(t, err)
+	assert.Less(t, plain, 4000)
+
+	input.Assignment = &models.Assignment{Rubric: strings.Repeat("Проверьте обработку пустого списка. ", 150)}
+	withRubric, err := svc.chunkBudget(newPromptData(input), chunkPrompt, "system", code, "gpt-4o-mini")
+	require.NoError(t, err)
+	assert.Less(t, withRubric, plain-500)
+	assert.GreaterOrEqual(t, withRubric, 1000)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ConversationService lets students ask follow-up questions about the
+// feedback on a submission. The thread is stored with the submission and
+// teachers read it through GetThread.
+type ConversationService interface {
+	Ask(ctx context.Context, submissionID, question string) (*models.ConversationMessage, error)
+	GetThread(ctx context.Context, submissionID string) ([]models.ConversationMessage, error)
+}
+
+// maxQuestionLength is the longest question accepted, in characters.
+const maxQuestionLength = 2000
+
+var (
+	ErrInvalidQuestion   = errors.New("invalid question")
+	ErrConversationLimit = errors.New("question limit reached for this submission")
+)
+
+type conversationService struct {
+	repo           repositories.ConversationRepository
+	submissionRepo repositories.SubmissionRepository
+	annotationRepo repositories.AnnotationRepository
+	assignmentRepo repositories.AssignmentRepository
+	openaiSvc      OpenAIService
+	usageSvc       UsageService
+	cfg            config.ConversationConfig
+}
+
+func NewConversationService(repo repositories.ConversationRepository, submissionRepo repositories.SubmissionRepository, annotationRepo repositories.AnnotationRepository, assignmentRepo repositories.AssignmentRepository, openaiSvc OpenAIService, usageSvc UsageService, cfg config.ConversationConfig) ConversationService {
+	return &conversationService{
+		repo:           repo,
+		submissionRepo: submissionRepo,
+		annotationRepo: annotationRepo,
+		assignmentRepo: assignmentRepo,
+		openaiSvc:      openaiSvc,
+		usageSvc:       usageSvc,
+		cfg:            cfg,
+	}
+}
+
+// Ask answers question with the code, the feedback and the earlier messages
+// in view and stores both the question and the answer.
+func (s *conversationService) Ask(ctx context.Context, submissionID, question string) (*models.ConversationMessage, error) {
+	if !s.cfg.Enabled {
+		return nil, fmt.Errorf("%w: follow-up questions are disabled", ErrInvalidQuestion)
+	}
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("%w: message is empty", ErrInvalidQuestion)
+	}
+	if utf8.RuneCountInString(question) > maxQuestionLength {
+		return nil, fmt.Errorf("%w: message is longer than %d characters", ErrInvalidQuestion, maxQuestionLength)
+	}
+
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load submission %s: %w", submissionID, err)
+	}
+
+	history, err := s.repo.GetBySubmissionID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation of submission %s: %w", submissionID, err)
+	}
+	if s.cfg.MaxQuestions > 0 && questions(history) >= s.cfg.MaxQuestions {
+		return nil, fmt.Errorf("%w: at most %d questions", ErrConversationLimit, s.cfg.MaxQuestions)
+	}
+
+	ctx = withUsageScope(ctx, submission)
+	if err := s.usageSvc.CheckQuota(ctx, submission.CourseID); quotaAction(err) != "" {
+		return nil, err
+	}
+
+	asked := time.Now()
+	input := &ConversationInput{
+		Code:     submission.Content,
+		FileType: submission.FileType,
+		Locale:   submission.Locale,
+		Feedback: submission.Feedback,
+		History:  history,
+		Question: question,
+	}
+	if input.Annotations, err = s.annotationRepo.GetBySubmissionID(ctx, submissionID); err != nil {
+		log.Printf("Failed to load annotations of submission %s: %v", submissionID, err)
+	}
+	if submission.AssignmentID != "" {
+		if assignment, err := s.assignmentRepo.GetByID(ctx, submission.AssignmentID); err == nil {
+			input.TaskStatement = assignment.TaskStatement
+		}
+	}
+
+	answer, err := s.openaiSvc.Converse(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	studentMessage := &models.ConversationMessage{SubmissionID: submissionID, Role: models.ConversationRoleStudent, Content: question, CreatedAt: asked}
+	reply := &models.ConversationMessage{SubmissionID: submissionID, Role: models.ConversationRoleAssistant, Content: answer, CreatedAt: time.Now()}
+	if err := s.repo.Append(ctx, studentMessage, reply); err != nil {
+		return nil, fmt.Errorf("failed to save conversation of submission %s: %w", submissionID, err)
+	}
+	return reply, nil
+}
+
+func (s *conversationService) GetThread(ctx context.Context, submissionID string) ([]models.ConversationMessage, error) {
+	_, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load submission %s: %w", submissionID, err)
+	}
+	return s.repo.GetBySubmissionID(ctx, submissionID)
+}
+
+func questions(history []models.ConversationMessage) int {
+	n := 0
+	for _, message := range history {
+		if message.Role == models.ConversationRoleStudent {
+			n++
+		}
+	}
+	return n
+}
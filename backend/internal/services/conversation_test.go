@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type conversationRepoStub struct {
+	repositories.ConversationRepository
+	messages []models.ConversationMessage
+}
+
+func (r *conversationRepoStub) Append(ctx context.Context, messages ...*models.ConversationMessage) error {
+	for _, message := range messages {
+		r.messages = append(r.messages, *message)
+	}
+	return nil
+}
+
+func (r *conversationRepoStub) GetBySubmissionID(ctx context.Context, submissionID string) ([]models.ConversationMessage, error) {
+	return append([]models.ConversationMessage(nil), r.messages...), nil
+}
+
+type submissionByIDRepo struct {
+	repositories.SubmissionRepository
+	submission *models.CodeSubmission
+}
+
+func (r *submissionByIDRepo) GetByID(ctx context.Context, id string) (*models.CodeSubmission, error) {
+	if r.submission == nil || r.submission.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.submission, nil
+}
+
+type annotationsStub struct {
+	repositories.AnnotationRepository
+	annotations []models.Annotation
+}
+
+func (r *annotationsStub) GetBySubmissionID(ctx context.Context, submissionID string) ([]models.Annotation, error) {
+	return r.annotations, nil
+}
+
+type openQuotaStub struct {
+	UsageService
+}
+
+func (s *openQuotaStub) CheckQuota(ctx context.Context, courseID string) error {
+	return nil
+}
+
+type conversationStub struct {
+	OpenAIService
+	input *ConversationInput
+}
+
+func (s *conversationStub) Converse(ctx context.Context, input *ConversationInput) (string, error) {
+	s.input = input
+	return "Потому что цикл начинается с единицы.", nil
+}
+
+func newTestConversationService(maxQuestions int) (*conversationService, *conversationRepoStub, *conversationStub) {
+	repo := &conversationRepoStub{}
+	ai := &conversationStub{}
+	s := &conversationService{
+		repo: repo,
+		submissionRepo: &submissionByIDRepo{submission: &models.CodeSubmission{
+			ID: "sub1", FileType: ".py", Content: "for i in range(1, 10):\n    print(i)\n", Feedback: "Цикл пропускает ноль.",
+		}},
+		annotationRepo: &annotationsStub{annotations: []models.Annotation{{StartLine: 1, EndLine: 1, Message: "Начните с нуля"}}},
+		openaiSvc:      ai,
+		usageSvc:       &openQuotaStub{},
+		cfg:            config.ConversationConfig{Enabled: true, MaxQuestions: maxQuestions},
+	}
+	return s, repo, ai
+}
+
+func TestConversation_AskKeepsThread(t *testing.T) {
+	s, repo, ai := newTestConversationService(5)
+
+	reply, err := s.Ask(context.Background(), "sub1", "  Почему ноль?  ")
+	require.NoError(t, err)
+	assert.Equal(t, models.ConversationRoleAssistant, reply.Role)
+	assert.Equal(t, "Цикл пропускает ноль.", ai.input.Feedback)
+	assert.Equal(t, "Почему ноль?", ai.input.Question)
+	assert.Len(t, ai.input.Annotations, 1)
+	assert.Empty(t, ai.input.History)
+
+	_, err = s.Ask(context.Background(), "sub1", "А как исправить?")
+	require.NoError(t, err)
+	require.Len(t, ai.input.History, 2)
+	assert.Equal(t, models.ConversationRoleStudent, ai.input.History[0].Role)
+	assert.Equal(t, "Почему ноль?", ai.input.History[0].Content)
+
+	thread, err := s.GetThread(context.Background(), "sub1")
+	require.NoError(t, err)
+	assert.Len(t, thread, 4)
+	assert.Equal(t, repo.messages, thread)
+}
+
+func TestConversation_AskLimitsQuestions(t *testing.T) {
+	s, _, _ := newTestConversationService(1)
+
+	_, err := s.Ask(context.Background(), "sub1", "Почему ноль?")
+	require.NoError(t, err)
+
+	_, err = s.Ask(context.Background(), "sub1", "А ещё?")
+	assert.ErrorIs(t, err, ErrConversationLimit)
+}
+
+func TestConversation_AskRejectsInvalid(t *testing.T) {
+	s, repo, _ := newTestConversationService(5)
+
+	_, err := s.Ask(context.Background(), "sub1", "   ")
+	assert.ErrorIs(t, err, ErrInvalidQuestion)
+
+	_, err = s.Ask(context.Background(), "missing", "Почему ноль?")
+	assert.ErrorIs(t, err, ErrSubmissionNotFound)
+
+	_, err = s.GetThread(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrSubmissionNotFound)
+
+	s.cfg.Enabled = false
+	_, err = s.Ask(context.Background(), "sub1", "Почему ноль?")
+	assert.ErrorIs(t, err, ErrInvalidQuestion)
+	assert.Empty(t, repo.messages)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCourse = errors.New("invalid course settings")
+
+type CourseService interface {
+	GetCourses(ctx context.Context) ([]models.Course, error)
+	GetCourse(ctx context.Context, id string) (*models.Course, error)
+	UpdateCourse(ctx context.Context, id string, req *models.CourseRequest) (*models.Course, error)
+}
+
+type courseService struct {
+	repo repositories.CourseRepository
+	cfg  config.UsageConfig
+}
+
+func NewCourseService(repo repositories.CourseRepository, cfg config.UsageConfig) CourseService {
+	return &courseService{repo: repo, cfg: cfg}
+}
+
+func (s *courseService) GetCourses(ctx context.Context) ([]models.Course, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// GetCourse returns the stored settings, or the configured defaults for a
+// course that has none yet.
+func (s *courseService) GetCourse(ctx context.Context, id string) (*models.Course, error) {
+	course, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.defaults(id), nil
+	}
+	return course, err
+}
+
+func (s *courseService) UpdateCourse(ctx context.Context, id string, req *models.CourseRequest) (*models.Course, error) {
+	if req.MonthlyBudgetUSD < 0 {
+		return nil, fmt.Errorf("%w: budget must not be negative", ErrInvalidCourse)
+	}
+	if req.QuotaAction != "" && req.QuotaAction != models.QuotaActionQueue && req.QuotaAction != models.QuotaActionReject {
+		return nil, fmt.Errorf("%w: quota_action must be %q or %q", ErrInvalidCourse, models.QuotaActionQueue, models.QuotaActionReject)
+	}
+	if req.GradingScale != nil {
+		if err := req.GradingScale.Normalize(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCourse, err)
+		}
+	}
+
+	course, err := s.GetCourse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	course.Name = req.Name
+	course.MonthlyBudgetUSD = req.MonthlyBudgetUSD
+	if req.QuotaAction != "" {
+		course.QuotaAction = req.QuotaAction
+	}
+	course.Locale = req.Locale
+	course.GradingScale = req.GradingScale
+
+	if err := s.repo.Save(ctx, course); err != nil {
+		return nil, fmt.Errorf("failed to save course: %w", err)
+	}
+	return course, nil
+}
+
+func (s *courseService) defaults(id string) *models.Course {
+	action := s.cfg.DefaultQuotaAction
+	if action != models.QuotaActionReject {
+		action = models.QuotaActionQueue
+	}
+	return &models.Course{
+		ID:               id,
+		MonthlyBudgetUSD: s.cfg.DefaultMonthlyBudget,
+		QuotaAction:      action,
+	}
+}
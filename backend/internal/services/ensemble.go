@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	aggregationMedian   = "median"
+	aggregationMajority = "majority"
+)
+
+// analyzeEnsemble runs sample Size times concurrently, rotating through the
+// configured models, and combines the grades. Failed samples are dropped as
+// long as at least MinSamples succeed; they still count against confidence,
+// so a partial ensemble is less likely to skip manual review.
+func (s *openAIService) analyzeEnsemble(ctx context.Context, sample func(ctx context.Context, model string) (*AnalysisResult, error)) (*AnalysisResult, error) {
+	models := s.ensemble.Models
+	if len(models) == 0 {
+		models = []string{openAIModel}
+	}
+	log.Printf("Starting ensemble analysis: %d samples over %s", s.ensemble.Size, strings.Join(models, ", "))
+
+	results := make([]*AnalysisResult, s.ensemble.Size)
+	errs := make([]error, s.ensemble.Size)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = sample(ctx, models[i%len(models)])
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		log.Printf("Ensemble sample %d failed: %v", i+1, err)
+		if firstErr == nil {
+			firstErr = fmt.Errorf("ensemble sample %d failed: %w", i+1, err)
+		}
+	}
+	if quorum := s.ensembleQuorum(); succeeded < quorum {
+		return nil, fmt.Errorf("%d of %d ensemble samples succeeded, %d needed: %w", succeeded, s.ensemble.Size, quorum, firstErr)
+	}
+
+	result := aggregateGrades(results, s.ensemble.Aggregation, s.ensemble.MinConfidence)
+	log.Printf("Ensemble analysis completed: grades %v, grade %d, confidence %.2f", result.Samples, result.Grade, result.Confidence)
+	return result, nil
+}
+
+// ensembleQuorum is the number of samples that must succeed: MinSamples
+// within 1..Size, or a majority of Size when it is not set.
+func (s *openAIService) ensembleQuorum() int {
+	if s.ensemble.MinSamples <= 0 {
+		return s.ensemble.Size/2 + 1
+	}
+	return min(s.ensemble.MinSamples, s.ensemble.Size)
+}
+
+// aggregateGrades combines ensemble samples. Failed samples are nil. They
+// and samples without a usable grade do not vote but still count against
+// confidence: Confidence is the share of all samples that gave the final
+// grade. The feedback, annotations and reference comparison are taken from
+// the first of them.
+func aggregateGrades(results []*AnalysisResult, aggregation string, minConfidence float64) *AnalysisResult {
+	var votes []*AnalysisResult
+	var grades []int
+	var first *AnalysisResult
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if first == nil {
+			first = r
+		}
+		if !contains(r.ReviewReasons, reviewMissingGrade) && !contains(r.ReviewReasons, reviewGradeOutOfRange) {
+			votes = append(votes, r)
+			grades = append(grades, r.Grade)
+		}
+	}
+	if len(votes) == 0 {
+		result := *first
+		result.Confidence = 0
+		return &result
+	}
+
+	grade := medianGrade(grades)
+	if aggregation == aggregationMajority {
+		grade = majorityGrade(grades)
+	}
+
+	agree := 0
+	var chosen *AnalysisResult
+	for _, r := range votes {
+		if r.Grade == grade {
+			agree++
+			if chosen == nil {
+				chosen = r
+			}
+		}
+	}
+
+	result := &AnalysisResult{
+		Grade:      grade,
+		Samples:    grades,
+		Confidence: float64(agree) / float64(len(results)),
+	}
+	if chosen != nil {
+		result.Feedback = chosen.Feedback
+		result.Annotations = chosen.Annotations
+		result.Comparison = chosen.Comparison
+		result.ReviewReasons = append(result.ReviewReasons, chosen.ReviewReasons...)
+	} else {
+		// An even number of samples can have a median nobody voted for.
+		result.Feedback = votes[0].Feedback
+		result.Annotations = votes[0].Annotations
+		result.Comparison = votes[0].Comparison
+	}
+	if result.Confidence < minConfidence {
+		result.ReviewReasons = append(result.ReviewReasons, reviewLowConfidence)
+	}
+	return result
+}
+
+// medianGrade returns the lower middle value, so a tie between two grades
+// resolves to the stricter one.
+func medianGrade(grades []int) int {
+	sorted := append([]int(nil), grades...)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
+
+// majorityGrade returns the most frequent grade; ties fall back to the
+// median of the tied grades.
+func majorityGrade(grades []int) int {
+	counts := make(map[int]int)
+	best := 0
+	for _, g := range grades {
+		counts[g]++
+		best = max(best, counts[g])
+	}
+	var tied []int
+	for g, n := range counts {
+		if n == best {
+			tied = append(tied, g)
+		}
+	}
+	return medianGrade(tied)
+}
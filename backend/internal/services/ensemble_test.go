@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"codegrader-backend/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samples(grades ...int) []*AnalysisResult {
+	results := make([]*AnalysisResult, len(grades))
+	for i, g := range grades {
+		results[i] = &AnalysisResult{Grade: g, Feedback: "feedback " + string(rune('a'+i))}
+	}
+	return results
+}
+
+func TestAggregateGrades_Median(t *testing.T) {
+	result := aggregateGrades(samples(5, 3, 4, 4, 5), aggregationMedian, 0.6)
+
+	assert.Equal(t, 4, result.Grade)
+	assert.Equal(t, []int{5, 3, 4, 4, 5}, result.Samples)
+	assert.InDelta(t, 0.4, result.Confidence, 1e-9)
+	assert.Equal(t, "feedback c", result.Feedback)
+	assert.Equal(t, []string{reviewLowConfidence}, result.ReviewReasons)
+}
+
+func TestAggregateGrades_Majority(t *testing.T) {
+	result := aggregateGrades(samples(5, 3, 5), aggregationMajority, 0.6)
+
+	assert.Equal(t, 5, result.Grade)
+	assert.InDelta(t, 2.0/3, result.Confidence, 1e-9)
+	assert.Empty(t, result.ReviewReasons)
+}
+
+func TestAggregateGrades_TiesResolveToStricterGrade(t *testing.T) {
+	assert.Equal(t, 4, aggregateGrades(samples(4, 5), aggregationMedian, 0).Grade)
+	assert.Equal(t, 4, aggregateGrades(samples(5, 4, 5, 4), aggregationMajority, 0).Grade)
+}
+
+func TestAggregateGrades_SkipsUnparsedSamples(t *testing.T) {
+	results := samples(5, 5, 3)
+	results[2].ReviewReasons = []string{reviewMissingGrade}
+
+	result := aggregateGrades(results, aggregationMedian, 0.6)
+
+	assert.Equal(t, 5, result.Grade)
+	assert.Equal(t, []int{5, 5}, result.Samples)
+	assert.InDelta(t, 2.0/3, result.Confidence, 1e-9)
+	assert.Empty(t, result.ReviewReasons)
+}
+
+func TestAnalyzeEnsemble_RotatesModels(t *testing.T) {
+	svc := &openAIService{ensemble: config.EnsembleConfig{
+		Size:          4,
+		Models:        []string{"gpt-4o-mini", "gpt-4o"},
+		Aggregation:   aggregationMedian,
+		MinConfidence: 0.6,
+	}}
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	result, err := svc.analyzeEnsemble(context.Background(), func(ctx context.Context, model string) (*AnalysisResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[model]++
+		return &AnalysisResult{Grade: 4, Feedback: "ok"}, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"gpt-4o-mini": 2, "gpt-4o": 2}, calls)
+	assert.Equal(t, 4, result.Grade)
+	assert.InDelta(t, 1.0, result.Confidence, 1e-9)
+}
+
+func TestAnalyzeEnsemble_FailsOnSampleError(t *testing.T) {
+	svc := &openAIService{ensemble: config.EnsembleConfig{Size: 3, Aggregation: aggregationMedian}}
+	failure := errors.New("provider down")
+
+	_, err := svc.analyzeEnsemble(context.Background(), func(ctx context.Context, model string) (*AnalysisResult, error) {
+		return nil, failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestAnalyzeEnsemble_DropsFailedSamplesAboveQuorum(t *testing.T) {
+	svc := &openAIService{ensemble: config.EnsembleConfig{Size: 3, Aggregation: aggregationMedian, MinConfidence: 0.6}}
+	failure := errors.New("provider down")
+
+	var mu sync.Mutex
+	calls := 0
+	result, err := svc.analyzeEnsemble(context.Background(), func(ctx context.Context, model string) (*AnalysisResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			return nil, failure
+		}
+		return &AnalysisResult{Grade: 4, Feedback: "ok"}, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, result.Grade)
+	assert.Equal(t, []int{4, 4}, result.Samples)
+	assert.InDelta(t, 2.0/3, result.Confidence, 1e-9)
+
+	svc.ensemble.MinSamples = 3
+	calls = 0
+	_, err = svc.analyzeEnsemble(context.Background(), func(ctx context.Context, model string) (*AnalysisResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			return nil, failure
+		}
+		return &AnalysisResult{Grade: 4, Feedback: "ok"}, nil
+	})
+	assert.ErrorIs(t, err, failure)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/resilience"
+)
+
+// GradingQueue grades submissions that were stored while the LLM provider
+// was unavailable.
+type GradingQueue interface {
+	GradePending(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type gradingQueue struct {
+	repo           repositories.SubmissionRepository
+	annotationRepo repositories.AnnotationRepository
+	analysisSvc    AnalysisService
+	usageSvc       UsageService
+	batchSize      int
+}
+
+func NewGradingQueue(repo repositories.SubmissionRepository, annotationRepo repositories.AnnotationRepository, analysisSvc AnalysisService, usageSvc UsageService) GradingQueue {
+	return &gradingQueue{repo: repo, annotationRepo: annotationRepo, analysisSvc: analysisSvc, usageSvc: usageSvc, batchSize: 20}
+}
+
+// GradePending grades up to one batch of pending submissions and stops at
+// the first sign that the provider is still down; the rest wait for the next
+// run. Submissions of courses over budget are skipped, not counted.
+func (q *gradingQueue) GradePending(ctx context.Context) (int, error) {
+	graded, skipped := 0, 0
+	for graded < q.batchSize {
+		pending, err := q.repo.GetPendingGrading(ctx, skipped, q.batchSize)
+		if err != nil {
+			return graded, fmt.Errorf("failed to get pending submissions: %w", err)
+		}
+		if len(pending) == 0 {
+			return graded, nil
+		}
+
+		for i := range pending {
+			sub := &pending[i]
+			if quotaAction(q.usageSvc.CheckQuota(ctx, sub.CourseID)) != "" {
+				skipped++
+				continue
+			}
+
+			result, err := q.analysisSvc.Analyze(withUsageScope(ctx, sub), sub)
+			if resilience.Unavailable(err) || ctx.Err() != nil {
+				return graded, nil
+			}
+			if err != nil {
+				log.Printf("Deferred grading failed for submission %s: %v", sub.ID, err)
+				skipped++
+				continue
+			}
+
+			applyAnalysis(sub, result)
+			sub.GradingStatus = models.GradingStatusGraded
+			if err := q.repo.Update(ctx, sub); err != nil {
+				return graded, fmt.Errorf("failed to update submission %s: %w", sub.ID, err)
+			}
+			if err := q.annotationRepo.Replace(ctx, sub.ID, annotationsFor(sub, result)); err != nil {
+				log.Printf("Failed to store annotations for submission %s: %v", sub.ID, err)
+			}
+			graded++
+		}
+	}
+
+	return graded, nil
+}
+
+func (q *gradingQueue) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			graded, err := q.GradePending(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Deferred grading run failed: %v", err)
+			}
+			if graded > 0 {
+				log.Printf("Graded %d deferred submissions", graded)
+			}
+		}
+	}
+}
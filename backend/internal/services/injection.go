@@ -0,0 +1,70 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// injectionPatterns catch text in submitted code that addresses the grading
+// model rather than the reader of the program. A match does not change the
+// grade, it sends the submission to manual review. RE2 word boundaries are
+// ASCII-only, so Cyrillic words are delimited with \P{L} instead of \b.
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,40}\b(previous|prior|above|earlier|all|any|system)\b.{0,20}\b(instructions?|prompts?|rules|directions)`),
+	regexp.MustCompile(`(?i)(игнорируй|забудь|не\s+учитывай|отмени)\S*.{0,40}(предыдущ|прошл|вышеуказ|все|любые|системн)\S*.{0,20}(инструкц|указани|правил|промпт)`),
+	regexp.MustCompile(`(?i)(system\s+prompt|системн\S*\s+(промпт|сообщени|инструкц))`),
+	regexp.MustCompile(`(?i)\b(you\s+are\s+now|from\s+now\s+on\s+you|act\s+as\s+(a|an|the)\b)|(^|\P{L})(ты\s+теперь|представь,?\s+что\s+ты)(\P{L}|$)`),
+	// A fake grade line: "Grade: 5" after a comment or quote mark, or on a
+	// line of its own. Assignments like "score = 0" and object keys like
+	// "score: 10" are ordinary code.
+	regexp.MustCompile(`(?m)(?i:(#|//|/\*|^\s*\*|--|["'\x60])[^\S\n]*(оценка|grade|score)\s*:\s*\[?\d)|^\s*(Оценка|ОЦЕНКА|Grade|GRADE)\s*:\s*\[?\d`),
+	regexp.MustCompile(`(?i)(плагиат|plagiarism)\s*:\s*(нет|no|да|yes)`),
+	regexp.MustCompile(`(?i)(выстав|постав)\S*\s+(\S+\s+){0,2}(5|пять|пятёрку|пятерку|максимальн|отлично)`),
+	regexp.MustCompile(`(?i)\b(give|assign|award)\s+(me\s+|this\s+(code\s+|solution\s+)?)?(a\s+)?(5|five|full\s+marks|the\s+highest|maximum|top)\b`),
+	regexp.MustCompile(`(?i)<\|im_start\|>|<\|system\|>|\[/?INST\]|(?m)^\W*(system|assistant)\s*:`),
+	regexp.MustCompile(`(?i)\b(note|message)\s+(to|for)\s+(the\s+)?(ai|llm|model|grader|reviewer|assistant)\b|\b(dear|hey)\s+(ai|gpt|chatgpt|grader|reviewer)\b`),
+	regexp.MustCompile(`(?i)(примечание|заметка|сообщение|обращение)\s+(для|к)\s+(проверяющ|ии(\P{L}|$)|модел|нейросет|ассистент)`),
+	regexp.MustCompile(`STUDENT_CODE_`),
+}
+
+// detectPromptInjection returns the fragments of code that look like
+// instructions aimed at the grading model.
+func detectPromptInjection(code string) []string {
+	var found []string
+	for _, pattern := range injectionPatterns {
+		if match := pattern.FindString(code); match != "" {
+			found = append(found, strings.TrimSpace(match))
+		}
+	}
+	return found
+}
+
+// newFence returns a marker that does not occur in any of texts. Code is
+// placed between "<<<fence" and "fence>>>" lines; since the marker is random
+// per call, the code cannot close the block early and continue as prompt.
+func newFence(texts ...string) string {
+	for {
+		nonce := make([]byte, 8)
+		if _, err := rand.Read(nonce); err != nil {
+			log.Printf("Failed to generate prompt fence: %v", err)
+		}
+		fence := "STUDENT_CODE_" + hex.EncodeToString(nonce)
+
+		collides := false
+		for _, text := range texts {
+			if strings.Contains(text, fence) {
+				collides = true
+			}
+		}
+		if !collides {
+			return fence
+		}
+	}
+}
+
+func fenceCode(code, fence string) string {
+	return "<<<" + fence + "\n" + code + "\n" + fence + ">>>"
+}
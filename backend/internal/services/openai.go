@@ -2,190 +2,794 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"codegrader-backend/internal/aidetect"
 	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/lint"
+	"codegrader-backend/internal/metrics"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+	"codegrader-backend/internal/redaction"
+	"codegrader-backend/internal/resilience"
+	"codegrader-backend/internal/tokens"
 
 	"github.com/sashabaranov/go-openai"
 )
 
+const openAIModel = "gpt-4o-mini"
+
 type OpenAIService interface {
-	AnalyzeCode(code, fileType string) (int, string, error)
-	CheckForPlagiarism(code, fileType string, existingSubmissions []string) (bool, string, error)
+	AnalyzeCode(ctx context.Context, input *AnalysisInput) (*AnalysisResult, error)
+	ConfirmPlagiarism(ctx context.Context, input *PlagiarismInput) (*PlagiarismResult, error)
+	JudgeAIGeneration(ctx context.Context, input *AIJudgementInput) (*aidetect.Judgement, error)
+	GiveHints(ctx context.Context, input *AnalysisInput) (*PracticeResult, error)
+	Converse(ctx context.Context, input *ConversationInput) (string, error)
+	Model() string
+	PromptVersion(input *AnalysisInput) (string, error)
+}
+
+// AnalysisInput is what the grading prompt is built from. Assignment is nil
+// for submissions without one; Scale is always set.
+type AnalysisInput struct {
+	Code       string
+	FileType   string
+	Locale     string
+	Assignment *models.Assignment
+	Scale      *grading.Scale
+	Metrics    *metrics.Metrics
+	Lint       []lint.Finding
+}
+
+// AnalysisResult is a parsed grading response. ReviewReasons is non-empty
+// when the grade should not be trusted without a teacher looking at it.
+// Label and Passed describe Grade on the submission's scale. Annotations
+// have no submission or file yet. Samples and Confidence are only set in
+// ensemble mode. Comparison is the teacher-only comparison with the
+// assignment's reference solutions.
+type AnalysisResult struct {
+	Grade         int
+	Label         string
+	Passed        bool
+	Feedback      string
+	Annotations   []models.Annotation
+	ReviewReasons []string
+	Samples       []int
+	Confidence    float64
+	Comparison    *models.ReferenceComparison
+}
+
+type PlagiarismInput struct {
+	Code              string
+	FileType          string
+	Candidate         string
+	CandidateFileType string
+	Locale            string
+}
+
+type PlagiarismResult struct {
+	IsPlagiarism bool
+	Explanation  string
+}
+
+// PracticeResult is the answer to a practice submission.
+type PracticeResult struct {
+	Hints     []string
+	Questions []string
+}
+
+// ConversationInput is a student's question about the feedback on their
+// code. History holds the earlier messages, oldest first.
+type ConversationInput struct {
+	Code          string
+	FileType      string
+	Locale        string
+	TaskStatement string
+	Feedback      string
+	Annotations   []models.Annotation
+	History       []models.ConversationMessage
+	Question      string
+}
+
+// conversationData holds the variables of the conversation template.
+type conversationData struct {
+	Language      string
+	TaskStatement string
+	Code          string
+	Feedback      string
+	Annotations   []models.Annotation
+	History       []conversationTurn
+	Question      string
+}
+
+type conversationTurn struct {
+	Role    string
+	Content string
+}
+
+type AIJudgementInput struct {
+	Code     string
+	FileType string
+	Locale   string
 }
 
 type openAIService struct {
-	client *openai.Client
+	client          *openai.Client
+	timeout         time.Duration
+	guard           *resilience.Guard
+	usage           UsageRecorder
+	prompts         *prompts.Store
+	redactor        *redaction.Redactor
+	ensemble        config.EnsembleConfig
+	chunkTokens     int
+	maxOutputTokens int
 }
 
-func NewOpenAIService(cfg *config.Config) OpenAIService {
+func NewOpenAIService(cfg *config.Config, guard *resilience.Guard, usage UsageRecorder, promptStore *prompts.Store, redactor *redaction.Redactor) OpenAIService {
 	if cfg.OpenAI.APIKey == "" {
 		log.Printf("WARNING: OpenAI API key is not set")
 	}
-	client := openai.NewClient(cfg.OpenAI.APIKey)
-	return &openAIService{client: client}
+	clientConfig := openai.DefaultConfig(cfg.OpenAI.APIKey)
+	clientConfig.HTTPClient = &http.Client{Transport: retryAfterTransport{base: http.DefaultTransport}}
+	client := openai.NewClientWithConfig(clientConfig)
+	return &openAIService{
+		client:          client,
+		timeout:         cfg.OpenAI.Timeout,
+		guard:           guard,
+		usage:           usage,
+		prompts:         promptStore,
+		redactor:        redactor,
+		ensemble:        cfg.OpenAI.Ensemble,
+		chunkTokens:     cfg.OpenAI.ChunkTokens,
+		maxOutputTokens: cfg.OpenAI.MaxOutputTokens,
+	}
 }
 
-func (s *openAIService) AnalyzeCode(code, fileType string) (int, string, error) {
-	language := getLanguageName(fileType)
+// maxPromptFindings is the number of linter findings listed in the prompt;
+// all of them are still stored as annotations.
+const maxPromptFindings = 30
+
+// promptData holds the variables available to the analysis templates.
+type promptData struct {
+	Language      string
+	Code          string
+	Rubric        string
+	TaskStatement string
+	Scale         *grading.Scale
+	Metrics       *metrics.Metrics
+	Lint          []lint.Finding
+	LintOmitted   int
+	References    []referenceData
+	// Fence marks the student's code and everything quoting it.
+	Fence string
+
+	Index     int
+	Total     int
+	StartLine int
+	EndLine   int
+	Lines     int
+	Findings  []chunkFinding
+}
 
-	log.Printf("Starting OpenAI analysis for %s code", language)
+type chunkFinding struct {
+	StartLine int
+	EndLine   int
+	Text      string
+}
 
-	prompt := fmt.Sprintf(`Проанализируй следующий код на языке %s и оцени его по критериям:
-1. Читаемость и структура
-2. Соблюдение стиль-гайдов
-3. Логика решения
-4. Эффективность алгоритма
+func newPromptData(input *AnalysisInput) promptData {
+	data := promptData{
+		Language: getLanguageName(input.FileType),
+		Code:     input.Code,
+		Scale:    input.Scale,
+		Metrics:  input.Metrics,
+		Lint:     input.Lint,
+	}
+	if len(data.Lint) > maxPromptFindings {
+		data.Lint = data.Lint[:maxPromptFindings]
+		data.LintOmitted = len(input.Lint) - maxPromptFindings
+	}
+	if input.Assignment != nil {
+		data.Rubric = input.Assignment.Rubric
+		data.TaskStatement = input.Assignment.TaskStatement
+		data.References = referenceSolutions(input)
+	}
+	return data
+}
 
-Выставь оценку от 3 до 5 баллов и дай краткие комментарии с рекомендациями.
+// analysisPrompts returns the templates used to grade input: the
+// assignment's override of the analysis prompt, if any, and the locale's
+// chunk and summary prompts.
+func (s *openAIService) analysisPrompts(input *AnalysisInput) (analysis, chunk, summary *prompts.Prompt, err error) {
+	if input.Assignment != nil && input.Assignment.AnalysisPrompt != "" {
+		analysis, err = s.prompts.Parse(prompts.Analysis, input.Locale, input.Assignment.AnalysisPrompt)
+	} else {
+		analysis, err = s.prompts.Get(prompts.Analysis, input.Locale)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if chunk, err = s.prompts.Get(prompts.AnalysisChunk, input.Locale); err != nil {
+		return nil, nil, nil, err
+	}
+	if summary, err = s.prompts.Get(prompts.AnalysisSummary, input.Locale); err != nil {
+		return nil, nil, nil, err
+	}
+	return analysis, chunk, summary, nil
+}
 
-Код:
-%s
+// AnalyzeCode grades input. Credentials and personal data are masked before
+// anything is sent, the same way as in ConfirmPlagiarism.
+func (s *openAIService) AnalyzeCode(ctx context.Context, input *AnalysisInput) (*AnalysisResult, error) {
+	analysis, chunk, summary, err := s.analysisPrompts(input)
+	if err != nil {
+		return nil, err
+	}
 
-Ответ должен быть в формате:
-Оценка: [число от 3 до 5]
-Комментарии: [твои комментарии]`, language, code)
+	redacted := *input
+	redacted.Code = s.redactor.Redact(input.Code).Text
+	if input.Assignment != nil && len(input.Assignment.ReferenceSolutions) > 0 {
+		assignment := *input.Assignment
+		assignment.ReferenceSolutions = make([]models.ReferenceSolution, len(input.Assignment.ReferenceSolutions))
+		for i, r := range input.Assignment.ReferenceSolutions {
+			r.Content = s.redactor.Redact(r.Content).Text
+			assignment.ReferenceSolutions[i] = r
+		}
+		redacted.Assignment = &assignment
+	}
+	input = &redacted
+	system, prompt, err := s.wholePrompt(input, analysis)
+	if err != nil {
+		return nil, err
+	}
+	sample := func(ctx context.Context, model string) (*AnalysisResult, error) {
+		// The budget covers everything sent, not just the code: the rubric,
+		// reference solutions and linter findings can be as large.
+		if size := tokens.Count(system, model) + tokens.Count(prompt, model); size > s.chunkTokens {
+			return s.analyzeChunked(ctx, input, chunk, summary, size, model)
+		}
+		return s.analyzeWhole(ctx, input, analysis, system, prompt, model)
+	}
+	if s.ensemble.Size > 1 {
+		return s.analyzeEnsemble(ctx, sample)
+	}
+	return sample(ctx, openAIModel)
+}
 
-	resp, err := s.client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: "gpt-4o-mini",
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-			MaxTokens:   500,
-			Temperature: 0.7,
-		},
-	)
+// wholePrompt renders the system and user messages that grade input in a
+// single request.
+func (s *openAIService) wholePrompt(input *AnalysisInput, analysis *prompts.Prompt) (system, prompt string, err error) {
+	data := newPromptData(input)
+	fence := newFence(data.fencedTexts(input.Code)...)
+	if system, err = s.systemPrompt(input.Locale, fence); err != nil {
+		return "", "", err
+	}
+	data.Code = fenceCode(numberLines(input.Code, 1), fence)
+	data.Fence = fence
+	data.fenceReferences(fence)
+	if prompt, err = analysis.Render(data); err != nil {
+		return "", "", err
+	}
+	return system, prompt, nil
+}
+
+func (s *openAIService) analyzeWhole(ctx context.Context, input *AnalysisInput, analysis *prompts.Prompt, system, prompt, model string) (*AnalysisResult, error) {
+	log.Printf("Starting OpenAI analysis for %s code (prompt %s/%s@%s)", getLanguageName(input.FileType), analysis.Locale, analysis.Name, analysis.Version)
 
+	response, err := s.complete(ctx, operationAnalysis, model, system, prompt, s.maxOutputTokens, 0.7)
 	if err != nil {
 		log.Printf("OpenAI API error: %v", err)
-		return 0, "", fmt.Errorf("failed to analyze code with OpenAI: %w", err)
+		return nil, fmt.Errorf("failed to analyze code with OpenAI: %w", err)
 	}
 
-	if len(resp.Choices) == 0 {
-		log.Printf("OpenAI returned no choices")
-		return 0, "", fmt.Errorf("no response from OpenAI")
+	log.Printf("OpenAI analysis completed successfully")
+
+	return parseAnalysisResponse(response, input), nil
+}
+
+// analyzeChunked reviews a file that does not fit into one prompt section by
+// section and then asks for a single grade based on the collected findings.
+func (s *openAIService) analyzeChunked(ctx context.Context, input *AnalysisInput, chunkPrompt, summaryPrompt *prompts.Prompt, size int, model string) (*AnalysisResult, error) {
+	data := newPromptData(input)
+
+	// Chunk findings are model output that may quote the code, so the
+	// summary request is fenced the same way. Only the summary sees the
+	// reference solutions.
+	fence := newFence(data.fencedTexts(input.Code)...)
+	system, err := s.systemPrompt(input.Locale, fence)
+	if err != nil {
+		return nil, err
 	}
+	data.Fence = fence
 
-	response := resp.Choices[0].Message.Content
-	log.Printf("OpenAI analysis completed successfully")
+	budget, err := s.chunkBudget(data, chunkPrompt, system, input.Code, model)
+	if err != nil {
+		return nil, err
+	}
+	chunks := splitIntoChunks(input.Code, input.FileType, model, budget)
+	log.Printf("Starting chunked OpenAI analysis for %s code: %d prompt tokens, %d chunks of up to %d code tokens", data.Language, size, len(chunks), budget)
+
+	findings := make([]chunkFinding, 0, len(chunks))
+	for i, chunk := range chunks {
+		chunkData := data
+		chunkData.References = nil
+		chunkData.Code = fenceCode(numberLines(chunk.text, chunk.startLine), fence)
+		chunkData.Index, chunkData.Total = i+1, len(chunks)
+		chunkData.StartLine, chunkData.EndLine = chunk.startLine, chunk.endLine
+
+		prompt, err := chunkPrompt.Render(chunkData)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := s.complete(ctx, operationAnalysisChunk, model, system, prompt, s.maxOutputTokens, 0.3)
+		if err != nil {
+			log.Printf("OpenAI API error on chunk %d/%d: %v", i+1, len(chunks), err)
+			return nil, fmt.Errorf("failed to analyze code chunk %d with OpenAI: %w", i+1, err)
+		}
+		findings = append(findings, chunkFinding{StartLine: chunk.startLine, EndLine: chunk.endLine, Text: response})
+	}
 
-	grade, feedback := parseGPTResponse(response)
+	data.Code = ""
+	data.Lines = lineCount(input.Code)
+	data.Findings = findings
+	data.fenceReferences(fence)
+	prompt, err := summaryPrompt.Render(data)
+	if err != nil {
+		return nil, err
+	}
 
-	return grade, feedback, nil
+	response, err := s.complete(ctx, operationAnalysis, model, system, prompt, s.maxOutputTokens, 0.7)
+	if err != nil {
+		log.Printf("OpenAI API error on chunk summary: %v", err)
+		return nil, fmt.Errorf("failed to merge chunk findings with OpenAI: %w", err)
+	}
+
+	log.Printf("Chunked OpenAI analysis completed successfully")
+
+	return parseAnalysisResponse(response, input), nil
 }
 
-func (s *openAIService) CheckForPlagiarism(code, fileType string, existingSubmissions []string) (bool, string, error) {
-	if len(existingSubmissions) == 0 {
-		return false, "", nil
+// chunkBudget is how many tokens of code fit into one chunk request: the
+// chunk budget without the system message and the rest of the chunk prompt,
+// and scaled down for the line numbers added to the code. A quarter of the
+// budget is always left for the code, so an oversized rubric or lint report
+// makes chunk requests larger rather than producing thousands of them.
+func (s *openAIService) chunkBudget(data promptData, chunkPrompt *prompts.Prompt, system, code, model string) (int, error) {
+	empty := data
+	empty.Code = ""
+	empty.References = nil
+	empty.Index, empty.Total = 1, 1
+	empty.StartLine, empty.EndLine = 1, 1
+	prompt, err := chunkPrompt.Render(empty)
+	if err != nil {
+		return 0, err
 	}
 
-	language := getLanguageName(fileType)
-	log.Printf("Starting plagiarism check for %s code against %d existing submissions", language, len(existingSubmissions))
+	budget := s.chunkTokens - tokens.Count(system, model) - tokens.Count(prompt, model)
+	if size := tokens.Count(code, model); size > 0 {
+		budget = budget * size / tokens.Count(numberLines(code, 1), model)
+	}
+	return max(budget, s.chunkTokens/4), nil
+}
 
-	existingCode := strings.Join(existingSubmissions, "\n\n--- NEXT SUBMISSION ---\n\n")
+// ConfirmPlagiarism asks the model to confirm or deny that code is a copy of
+// candidate. Only pairs shortlisted by the local similarity metrics are sent
+// here, so the prompt size does not grow with the corpus.
+func (s *openAIService) ConfirmPlagiarism(ctx context.Context, input *PlagiarismInput) (*PlagiarismResult, error) {
+	language := getLanguageName(input.FileType)
+	candidateLanguage := getLanguageName(input.CandidateFileType)
+	log.Printf("Starting pairwise plagiarism check for %s code against %s submission", language, candidateLanguage)
 
-	prompt := fmt.Sprintf(`Проанализируй следующий код на языке %s на предмет плагиата.
+	plagiarismPrompt, err := s.prompts.Get(prompts.Plagiarism, input.Locale)
+	if err != nil {
+		return nil, err
+	}
+	fence := newFence(input.Code, input.Candidate)
+	system, err := s.systemPrompt(input.Locale, fence)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := plagiarismPrompt.Render(map[string]string{
+		"Language":          language,
+		"CandidateLanguage": candidateLanguage,
+		"Code":              fenceCode(numberLines(s.redactor.Redact(input.Code).Text, 1), fence),
+		"Candidate":         fenceCode(numberLines(s.redactor.Redact(input.Candidate).Text, 1), fence),
+	})
+	if err != nil {
+		return nil, err
+	}
 
-Новый код для проверки:
-%s
+	response, err := s.complete(ctx, operationPlagiarism, openAIModel, system, prompt, s.maxOutputTokens, 0.3)
+	if err != nil {
+		log.Printf("OpenAI pairwise plagiarism check error: %v", err)
+		return nil, fmt.Errorf("failed to confirm plagiarism with OpenAI: %w", err)
+	}
 
-Существующие решения для сравнения:
-%s
+	log.Printf("Pairwise plagiarism check completed successfully")
 
-Определи, является ли новый код копией или очень похожим на одно из существующих решений.
-Учитывай:
-1. Идентичность или почти идентичность структуры кода
-2. Одинаковые названия переменных и функций
-3. Идентичную логику решения
-4. Минимальные изменения (переименование переменных, изменение комментариев)
+	return parsePlagiarismResponse(response), nil
+}
 
-Если код является плагиатом или очень похож на существующее решение, ответь:
-ПЛАГИАТ: Да
-Объяснение: [объяснение почему это плагиат]
+// JudgeAIGeneration asks the model how likely code is to be generated. The
+// answer only adds to the local signals of package aidetect.
+func (s *openAIService) JudgeAIGeneration(ctx context.Context, input *AIJudgementInput) (*aidetect.Judgement, error) {
+	detectionPrompt, err := s.prompts.Get(prompts.AIDetection, input.Locale)
+	if err != nil {
+		return nil, err
+	}
+	fence := newFence(input.Code)
+	system, err := s.systemPrompt(input.Locale, fence)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := detectionPrompt.Render(map[string]string{
+		"Language": getLanguageName(input.FileType),
+		"Code":     fenceCode(numberLines(s.redactor.Redact(input.Code).Text, 1), fence),
+	})
+	if err != nil {
+		return nil, err
+	}
 
-Если код оригинальный, ответь:
-ПЛАГИАТ: Нет
-Объяснение: Код имеет оригинальную структуру и подход к решению`, language, code, existingCode)
+	response, err := s.complete(ctx, operationAIDetection, openAIModel, system, prompt, s.maxOutputTokens, 0.3)
+	if err != nil {
+		log.Printf("OpenAI AI generation check error: %v", err)
+		return nil, fmt.Errorf("failed to judge AI generation with OpenAI: %w", err)
+	}
+
+	judgement, ok := parseAIJudgement(response)
+	if !ok {
+		return nil, fmt.Errorf("no likelihood in AI generation response")
+	}
+	return judgement, nil
+}
+
+// parseAIJudgement reads the "Вероятность:"/"Likelihood:" percentage and
+// the explanation that follows it.
+func parseAIJudgement(response string) (*aidetect.Judgement, bool) {
+	var judgement *aidetect.Judgement
+	var explanation []string
+	for _, line := range strings.Split(response, "\n") {
+		label, value, found := strings.Cut(line, ":")
+		label = strings.ToLower(strings.Trim(label, "*# "))
+		switch {
+		case found && (label == "вероятность" || label == "likelihood") && judgement == nil:
+			var percent float64
+			if _, err := fmt.Sscanf(strings.Trim(value, "*[] "), "%g", &percent); err != nil || percent < 0 || percent > 100 {
+				return nil, false
+			}
+			judgement = &aidetect.Judgement{Likelihood: percent / 100}
+		case found && (label == "объяснение" || label == "explanation"):
+			explanation = append(explanation, strings.TrimSpace(value))
+		case len(explanation) > 0:
+			explanation = append(explanation, strings.TrimSpace(line))
+		}
+	}
+	if judgement == nil {
+		return nil, false
+	}
+	judgement.Explanation = strings.TrimSpace(strings.Join(explanation, " "))
+	return judgement, true
+}
+
+// GiveHints asks for hints and guiding questions on a practice submission.
+// The reference solutions of the assignment are left out of the prompt so
+// that they cannot leak into the hints.
+func (s *openAIService) GiveHints(ctx context.Context, input *AnalysisInput) (*PracticeResult, error) {
+	practicePrompt, err := s.prompts.Get(prompts.Practice, input.Locale)
+	if err != nil {
+		return nil, err
+	}
+	code := s.redactor.Redact(input.Code).Text
+	data := newPromptData(input)
+	data.References = nil
+	fence := newFence(data.fencedTexts(code)...)
+	system, err := s.systemPrompt(input.Locale, fence)
+	if err != nil {
+		return nil, err
+	}
+	data.Code = fenceCode(numberLines(code, 1), fence)
+	data.Fence = fence
+	prompt, err := practicePrompt.Render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.complete(ctx, operationPractice, openAIModel, system, prompt, s.maxOutputTokens, 0.7)
+	if err != nil {
+		log.Printf("OpenAI practice hints error: %v", err)
+		return nil, fmt.Errorf("failed to get practice hints from OpenAI: %w", err)
+	}
+	return parsePracticeResponse(response), nil
+}
+
+// parsePracticeResponse reads the items listed under "Подсказки:"/"Hints:"
+// and "Вопросы:"/"Questions:". Code blocks are dropped in case the model
+// wrote a solution anyway; a response without the headings becomes one hint.
+func parsePracticeResponse(response string) *PracticeResult {
+	result := &PracticeResult{}
+	var list *[]string
+	var other []string
+	inCode := false
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || trimmed == "" {
+			continue
+		}
+		switch strings.ToLower(strings.Trim(trimmed, "*#_> ")) {
+		case "подсказки:", "hints:", "подсказки", "hints":
+			list = &result.Hints
+			continue
+		case "вопросы:", "questions:", "вопросы", "questions":
+			list = &result.Questions
+			continue
+		}
+		item := strings.TrimSpace(strings.TrimLeft(trimmed, "-*•0123456789.) "))
+		switch {
+		case item == "":
+		case list == nil:
+			other = append(other, trimmed)
+		case strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "•") || startsNumbered(trimmed) || len(*list) == 0:
+			*list = append(*list, item)
+		default:
+			// A list item wrapped onto the next line.
+			(*list)[len(*list)-1] += " " + trimmed
+		}
+	}
+	if len(result.Hints) == 0 && len(result.Questions) == 0 && len(other) > 0 {
+		result.Hints = []string{strings.Join(other, " ")}
+	}
+	return result
+}
+
+func startsNumbered(line string) bool {
+	digits := strings.TrimLeft(line, "0123456789")
+	return len(digits) < len(line) && (strings.HasPrefix(digits, ".") || strings.HasPrefix(digits, ")"))
+}
+
+// Converse answers a student's question about their feedback. The student's
+// messages and the earlier answers are fenced like the code and masked the
+// same way.
+func (s *openAIService) Converse(ctx context.Context, input *ConversationInput) (string, error) {
+	conversationPrompt, err := s.prompts.Get(prompts.Conversation, input.Locale)
+	if err != nil {
+		return "", err
+	}
+
+	data := conversationData{
+		Language:      getLanguageName(input.FileType),
+		TaskStatement: input.TaskStatement,
+		Annotations:   input.Annotations,
+		Question:      s.redactor.Redact(input.Question).Text,
+	}
+	code := s.redactor.Redact(input.Code).Text
+	feedback := s.redactor.Redact(input.Feedback).Text
+	texts := []string{code, feedback, data.Question}
+	for _, message := range input.History {
+		turn := conversationTurn{Role: message.Role, Content: s.redactor.Redact(message.Content).Text}
+		data.History = append(data.History, turn)
+		texts = append(texts, turn.Content)
+	}
+
+	fence := newFence(texts...)
+	system, err := s.systemPrompt(input.Locale, fence)
+	if err != nil {
+		return "", err
+	}
+	data.Code = fenceCode(numberLines(code, 1), fence)
+	data.Feedback = fenceCode(feedback, fence)
+	data.Question = fenceCode(data.Question, fence)
+	for i := range data.History {
+		data.History[i].Content = fenceCode(data.History[i].Content, fence)
+	}
+	prompt, err := conversationPrompt.Render(data)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := s.complete(ctx, operationConversation, openAIModel, system, prompt, s.maxOutputTokens, 0.7)
+	if err != nil {
+		log.Printf("OpenAI conversation error: %v", err)
+		return "", fmt.Errorf("failed to answer question with OpenAI: %w", err)
+	}
+	return strings.TrimSpace(response), nil
+}
+
+func (s *openAIService) Model() string {
+	return openAIModel
+}
+
+// PromptVersion identifies everything that shapes the grading result for
+// input: the template texts, the grading scale, the ensemble settings, the
+// code metrics and linter findings and the assignment's rubric and task
+// statement.
+func (s *openAIService) PromptVersion(input *AnalysisInput) (string, error) {
+	analysis, chunk, summary, err := s.analysisPrompts(input)
+	if err != nil {
+		return "", err
+	}
+	system, err := s.prompts.Get(prompts.System, input.Locale)
+	if err != nil {
+		return "", err
+	}
+
+	version := analysis.Version + chunk.Version + summary.Version + system.Version + input.Scale.Version()
+	if s.ensemble.Size > 1 {
+		version += fmt.Sprintf("ensemble:%d:%s:%s", s.ensemble.Size, s.ensemble.Aggregation, strings.Join(s.ensemble.Models, ","))
+	}
+	// Metrics count comments, which the content hash of the cache key ignores.
+	if input.Metrics != nil {
+		encoded, err := json.Marshal(input.Metrics)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode metrics: %w", err)
+		}
+		version += "metrics:" + string(encoded)
+	}
+	// The same code may be linted by different tools over time.
+	for _, f := range input.Lint {
+		version += fmt.Sprintf("lint:%s:%s:%d;", f.Tool, f.Rule, f.Line)
+	}
+	sum := sha256.Sum256([]byte(version))
+	version = hex.EncodeToString(sum[:])[:12]
+	if input.Assignment != nil {
+		version += fmt.Sprintf("-a%d", input.Assignment.Version)
+	}
+	return version, nil
+}
+
+// systemPrompt renders the system message that tells the model to treat
+// everything inside fence as data.
+func (s *openAIService) systemPrompt(locale, fence string) (string, error) {
+	system, err := s.prompts.Get(prompts.System, locale)
+	if err != nil {
+		return "", err
+	}
+	return system.Render(map[string]string{"Fence": fence})
+}
+
+func (s *openAIService) complete(ctx context.Context, operation, model, system, prompt string, maxTokens int, temperature float32) (string, error) {
+	var content string
+	err := s.guard.Do(ctx, func(ctx context.Context) error {
+		var err error
+		content, err = s.completeOnce(ctx, operation, model, system, prompt, maxTokens, temperature)
+		return err
+	})
+	return content, err
+}
+
+func (s *openAIService) completeOnce(ctx context.Context, operation, model, system, prompt string, maxTokens int, temperature float32) (string, error) {
+	retryAfter := &atomic.Int64{}
+	callCtx, cancel := context.WithTimeout(context.WithValue(ctx, retryAfterKey{}, retryAfter), s.timeout)
+	defer cancel()
 
 	resp, err := s.client.CreateChatCompletion(
-		context.Background(),
+		callCtx,
 		openai.ChatCompletionRequest{
-			Model: "gpt-4o-mini",
+			Model: model,
 			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: system,
+				},
 				{
 					Role:    openai.ChatMessageRoleUser,
 					Content: prompt,
 				},
 			},
-			MaxTokens:   800,
-			Temperature: 0.3,
+			MaxTokens:   maxTokens,
+			Temperature: temperature,
 		},
 	)
-
 	if err != nil {
-		log.Printf("OpenAI plagiarism check error: %v", err)
-		return false, "", fmt.Errorf("failed to check plagiarism with OpenAI: %w", err)
+		return "", classifyOpenAIError(ctx, err, time.Duration(retryAfter.Load()))
 	}
+	if resp.Model != "" {
+		model = resp.Model
+	}
+	s.usage.Record(ctx, operation, model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
 	if len(resp.Choices) == 0 {
-		log.Printf("OpenAI returned no choices for plagiarism check")
-		return false, "", fmt.Errorf("no response from OpenAI for plagiarism check")
+		return "", fmt.Errorf("no response from OpenAI")
 	}
 
-	response := resp.Choices[0].Message.Content
-	log.Printf("Plagiarism check completed successfully")
+	return resp.Choices[0].Message.Content, nil
+}
 
-	isPlagiarism, explanation := parsePlagiarismResponse(response)
-	return isPlagiarism, explanation, nil
+// parseAnalysisResponse reads the reference comparison, the line
+// annotations and the grade of a grading response for input.
+func parseAnalysisResponse(response string, input *AnalysisInput) *AnalysisResult {
+	feedback, comparison := parseReferenceComparison(response)
+	feedback, annotations := parseAnnotations(feedback, lineCount(input.Code))
+	result := parseGPTResponse(feedback, input.Scale)
+	result.Annotations = annotations
+	result.Comparison = comparison
+	return result
 }
 
-func parseGPTResponse(response string) (int, string) {
-	lines := strings.Split(response, "\n")
-	grade := 3
-	feedback := response
+// parseGPTResponse reads the grade from a line that starts with "Оценка:" or
+// "Grade:". Lines that merely mention a grade, e.g. a quoted comment from the
+// code, are ignored. A missing, off-scale or contradictory grade falls back
+// to the scale's fallback grade and marks the result for review.
+func parseGPTResponse(response string, scale *grading.Scale) *AnalysisResult {
+	result := &AnalysisResult{Grade: scale.Fallback(), Feedback: response}
+
+	var values []string
+	for _, line := range strings.Split(response, "\n") {
+		if value, ok := gradeLine(line); ok {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		result.ReviewReasons = append(result.ReviewReasons, reviewMissingGrade)
+		return result
+	}
 
-	for _, line := range lines {
-		if strings.Contains(strings.ToLower(line), "оценка:") {
-			if strings.Contains(line, "5") {
-				grade = 5
-			} else if strings.Contains(line, "4") {
-				grade = 4
-			} else if strings.Contains(line, "3") {
-				grade = 3
-			}
+	first, ok := scale.ParseGrade(values[0])
+	if !ok {
+		result.ReviewReasons = append(result.ReviewReasons, reviewGradeOutOfRange)
+		return result
+	}
+	result.Grade = first
+	for _, value := range values[1:] {
+		if grade, ok := scale.ParseGrade(value); !ok || grade != first {
+			result.ReviewReasons = append(result.ReviewReasons, reviewConflictingGrades)
+			break
 		}
 	}
 
-	return grade, feedback
+	return result
 }
 
-func parsePlagiarismResponse(response string) (bool, string) {
+// gradeLine returns what follows the colon of a "Grade:" line.
+func gradeLine(line string) (string, bool) {
+	label, value, ok := strings.Cut(strings.TrimLeft(strings.TrimSpace(line), "*#_> "), ":")
+	if !ok {
+		return "", false
+	}
+	switch strings.ToLower(strings.Trim(label, "*_ ")) {
+	case "оценка", "grade":
+		return strings.TrimSpace(value), true
+	default:
+		return "", false
+	}
+}
+
+func parsePlagiarismResponse(response string) *PlagiarismResult {
 	lines := strings.Split(response, "\n")
-	isPlagiarism := false
-	explanation := response
+	result := &PlagiarismResult{Explanation: response}
 
 	for _, line := range lines {
 		lowerLine := strings.ToLower(line)
 		if strings.Contains(lowerLine, "плагиат:") {
-			if strings.Contains(lowerLine, "да") {
-				isPlagiarism = true
-			}
+			result.IsPlagiarism = strings.Contains(lowerLine, "да")
+			break
+		}
+		if strings.Contains(lowerLine, "plagiarism:") {
+			result.IsPlagiarism = strings.Contains(lowerLine, "yes")
 			break
 		}
 	}
 
-	return isPlagiarism, explanation
+	return result
 }
 
 func getLanguageName(fileType string) string {
 	switch fileType {
+	case ".c":
+		return "C"
 	case ".cpp":
 		return "C++"
 	case ".java":
@@ -196,7 +800,24 @@ func getLanguageName(fileType string) string {
 		return "Kotlin"
 	case ".py":
 		return "Python"
+	case ".ts":
+		return "TypeScript"
 	default:
 		return "Unknown"
 	}
 }
+
+// relatedFileTypes lists file types whose solutions can be ported to each
+// other with little effort; used by cross-language plagiarism detection.
+func relatedFileTypes(fileType string) []string {
+	switch fileType {
+	case ".java", ".kt":
+		return []string{".java", ".kt"}
+	case ".js", ".ts":
+		return []string{".js", ".ts"}
+	case ".c", ".cpp":
+		return []string{".c", ".cpp"}
+	default:
+		return []string{fileType}
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"codegrader-backend/internal/resilience"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type retryAfterKey struct{}
+
+// retryAfterTransport records the Retry-After header of throttled responses
+// into the request context, because go-openai drops response headers when
+// it converts them into errors.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if hint, ok := req.Context().Value(retryAfterKey{}).(*atomic.Int64); ok {
+		if delay := parseRetryAfter(resp.Header.Get("Retry-After")); delay > 0 {
+			hint.Store(int64(delay))
+		}
+	}
+	return resp, nil
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// classifyOpenAIError wraps transient failures in resilience.RetryableError;
+// everything else (bad request, auth, exhausted quota) is returned as is.
+func classifyOpenAIError(ctx context.Context, err error, retryAfter time.Duration) error {
+	if ctx.Err() != nil {
+		return err
+	}
+
+	retryable := errors.Is(err, context.DeadlineExceeded)
+
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &apiErr):
+		retryable = retryableStatus(apiErr.HTTPStatusCode) && apiErr.Code != "insufficient_quota"
+	case errors.As(err, &reqErr):
+		retryable = retryableStatus(reqErr.HTTPStatusCode)
+	case errors.As(err, &netErr):
+		retryable = true
+	}
+
+	if !retryable {
+		return err
+	}
+	return &resilience.RetryableError{Err: err, RetryAfter: retryAfter}
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
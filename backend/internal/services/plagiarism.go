@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/similarity"
+	"codegrader-backend/internal/structure"
+)
+
+type PlagiarismService interface {
+	Check(ctx context.Context, submission *models.CodeSubmission) (*models.PlagiarismMatch, error)
+	Link(ctx context.Context, match *models.PlagiarismMatch) error
+	GetMatches(ctx context.Context, submissionID string) ([]models.PlagiarismMatch, error)
+	Recheck(ctx context.Context) (*models.RecheckResponse, error)
+}
+
+type plagiarismService struct {
+	submissionRepo repositories.SubmissionRepository
+	plagiarismRepo repositories.PlagiarismRepository
+	openaiSvc      OpenAIService
+	scales         ScaleService
+	similaritySvc  SimilarityService
+	cfg            config.PlagiarismConfig
+}
+
+func NewPlagiarismService(submissionRepo repositories.SubmissionRepository, plagiarismRepo repositories.PlagiarismRepository, openaiSvc OpenAIService, similaritySvc SimilarityService, scales ScaleService, cfg config.PlagiarismConfig) PlagiarismService {
+	return &plagiarismService{
+		submissionRepo: submissionRepo,
+		plagiarismRepo: plagiarismRepo,
+		openaiSvc:      openaiSvc,
+		scales:         scales,
+		similaritySvc:  similaritySvc,
+		cfg:            cfg,
+	}
+}
+
+// Check compares a new submission against the existing corpus of the same
+// file type. It does not persist anything; call Link once the submission
+// itself has been stored.
+func (s *plagiarismService) Check(ctx context.Context, submission *models.CodeSubmission) (*models.PlagiarismMatch, error) {
+	existing, err := s.submissionRepo.GetByFileType(ctx, submission.FileType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing submissions: %w", err)
+	}
+
+	corpusSize := 0
+	for _, candidate := range existing {
+		if candidate.ID != submission.ID {
+			corpusSize++
+		}
+	}
+
+	// The corpus size is only recorded once detection has finished, so a
+	// failed LLM confirmation leaves the submission for the next recheck.
+	match, err := s.check(ctx, submission, existing)
+	if err != nil {
+		return nil, err
+	}
+	submission.PlagiarismCorpusSize = corpusSize
+	return match, nil
+}
+
+func (s *plagiarismService) check(ctx context.Context, submission *models.CodeSubmission, existing []models.CodeSubmission) (*models.PlagiarismMatch, error) {
+	duplicates, err := s.submissionRepo.GetByContentHash(ctx, ensureContentHash(submission), submission.FileType)
+	if err != nil {
+		log.Printf("Exact duplicate lookup failed for submission %s: %v", submission.ID, err)
+	}
+	if match := exactDuplicate(submission, duplicates, nil); match != nil {
+		log.Printf("Submission %s is an exact copy of %s", submission.ID, match.MatchedSubmissionID)
+		return match, nil
+	}
+
+	candidates, semantic := s.candidatesFor(ctx, submission, existing, nil)
+	match, err := s.detect(ctx, submission, candidates, semantic)
+	if err != nil || match != nil {
+		return match, err
+	}
+
+	return s.detectCrossLanguage(ctx, submission, nil)
+}
+
+// Link stores the match and marks both sides of it. The suspect is the later
+// of the two submissions, the earlier one is recorded as involved.
+func (s *plagiarismService) Link(ctx context.Context, match *models.PlagiarismMatch) error {
+	if err := s.plagiarismRepo.Create(ctx, match); err != nil {
+		return fmt.Errorf("failed to save plagiarism match: %w", err)
+	}
+
+	source, err := s.submissionRepo.GetByID(ctx, match.MatchedSubmissionID)
+	if err != nil {
+		return fmt.Errorf("failed to load matched submission %s: %w", match.MatchedSubmissionID, err)
+	}
+	if source.PlagiarismInvolved {
+		return nil
+	}
+
+	source.PlagiarismInvolved = true
+	return s.submissionRepo.Update(ctx, source)
+}
+
+func (s *plagiarismService) GetMatches(ctx context.Context, submissionID string) ([]models.PlagiarismMatch, error) {
+	return s.plagiarismRepo.GetBySubmissionID(ctx, submissionID)
+}
+
+// Recheck re-runs detection for every submission whose corpus has grown
+// since it was last checked, e.g. after older solutions were imported.
+func (s *plagiarismService) Recheck(ctx context.Context) (*models.RecheckResponse, error) {
+	submissions, err := s.submissionRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submissions: %w", err)
+	}
+
+	byType := make(map[string][]models.CodeSubmission)
+	byID := make(map[string]*models.CodeSubmission, len(submissions))
+	for i := range submissions {
+		sub := &submissions[i]
+		if sub.ContentHash == "" {
+			ensureContentHash(sub)
+			if err := s.submissionRepo.Update(ctx, sub); err != nil {
+				return nil, fmt.Errorf("failed to store content hash of submission %s: %w", sub.ID, err)
+			}
+		}
+		byType[sub.FileType] = append(byType[sub.FileType], *sub)
+		byID[sub.ID] = sub
+	}
+
+	if indexed, err := s.similaritySvc.Backfill(ctx); err != nil {
+		log.Printf("Embedding backfill failed: %v", err)
+	} else if indexed > 0 {
+		log.Printf("Indexed %d submissions before plagiarism recheck", indexed)
+	}
+
+	result := &models.RecheckResponse{}
+	for i := range submissions {
+		submission := &submissions[i]
+		corpus := byType[submission.FileType]
+		if submission.PlagiarismCorpusSize >= len(corpus)-1 {
+			continue
+		}
+
+		ctx := withUsageScope(ctx, submission)
+		linked, err := s.linkedIDs(ctx, submission.ID)
+		if err != nil {
+			return result, err
+		}
+
+		match := exactDuplicate(submission, corpus, linked)
+		if match == nil {
+			candidates, semantic := s.candidatesFor(ctx, submission, corpus, linked)
+			match, err = s.detect(ctx, submission, candidates, semantic)
+		}
+		if err == nil && match == nil {
+			match, err = s.detectCrossLanguage(ctx, submission, linked)
+		}
+		if err != nil {
+			log.Printf("Plagiarism recheck failed for submission %s: %v", submission.ID, err)
+			continue
+		}
+		result.Checked++
+
+		submission.PlagiarismCorpusSize = len(corpus) - 1
+		if match != nil {
+			if err := s.linkRetroactively(ctx, submission, byID[match.MatchedSubmissionID], match); err != nil {
+				return result, err
+			}
+			result.Matches++
+		}
+
+		if err := s.submissionRepo.Update(ctx, submission); err != nil {
+			return result, fmt.Errorf("failed to update submission %s: %w", submission.ID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// candidatesFor narrows the corpus down to the semantically nearest
+// submissions once it grows beyond the configured ANN limit. The returned map
+// holds the cosine similarity of every neighbour that was found.
+func (s *plagiarismService) candidatesFor(ctx context.Context, submission *models.CodeSubmission, corpus []models.CodeSubmission, exclude map[string]bool) ([]models.CodeSubmission, map[string]float64) {
+	candidates := make([]models.CodeSubmission, 0, len(corpus))
+	for _, candidate := range corpus {
+		if candidate.ID != submission.ID && !exclude[candidate.ID] && !sameAuthor(submission, &candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	semantic := make(map[string]float64)
+	if len(candidates) == 0 || s.cfg.ANNCandidates <= 0 {
+		return candidates, semantic
+	}
+
+	neighbours, err := s.similaritySvc.Nearest(ctx, submission, s.cfg.ANNCandidates+len(exclude))
+	if err != nil {
+		log.Printf("Semantic candidate search failed for submission %s: %v", submission.ID, err)
+		return candidates, semantic
+	}
+	for _, n := range neighbours {
+		semantic[n.ID] = n.Similarity
+	}
+
+	if len(candidates) <= s.cfg.ANNCandidates || len(semantic) == 0 {
+		return candidates, semantic
+	}
+
+	nearest := make([]models.CodeSubmission, 0, s.cfg.ANNCandidates)
+	for _, candidate := range candidates {
+		if _, ok := semantic[candidate.ID]; ok && len(nearest) < s.cfg.ANNCandidates {
+			nearest = append(nearest, candidate)
+		}
+	}
+	return nearest, semantic
+}
+
+// detect shortlists the top-k candidates by the cheap local metrics and
+// asks the model to confirm or deny each shortlisted pair in turn.
+func (s *plagiarismService) detect(ctx context.Context, submission *models.CodeSubmission, candidates []models.CodeSubmission, semantic map[string]float64) (*models.PlagiarismMatch, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	tree := structure.Parse(submission.Content, submission.FileType)
+	scored := make([]scoredCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		scores := similarityScores{
+			token:      similarity.TokenSimilarity(submission.Content, candidate.Content, submission.FileType),
+			structural: structure.Similarity(tree, structure.Parse(candidate.Content, candidate.FileType)),
+			semantic:   semantic[candidate.ID],
+		}
+		if scores.best() >= s.cfg.MinSimilarity {
+			scored = append(scored, scoredCandidate{submission: candidate, scores: scores})
+		}
+	}
+
+	shortlist := topCandidates(scored, s.cfg.TopK)
+	log.Printf("Plagiarism shortlist for submission %s: %d of %d candidates", submission.ID, len(shortlist), len(candidates))
+
+	return s.confirm(ctx, submission, shortlist, false)
+}
+
+// detectCrossLanguage looks for ports of solutions to the same assignment in
+// related languages (Java/Kotlin, JS/TS, C/C++). Pairs are shortlisted by
+// the language-neutral structural similarity and then confirmed one by one.
+func (s *plagiarismService) detectCrossLanguage(ctx context.Context, submission *models.CodeSubmission, exclude map[string]bool) (*models.PlagiarismMatch, error) {
+	if !s.cfg.CrossLanguage || submission.AssignmentID == "" {
+		return nil, nil
+	}
+
+	var fileTypes []string
+	for _, fileType := range relatedFileTypes(submission.FileType) {
+		if fileType != submission.FileType {
+			fileTypes = append(fileTypes, fileType)
+		}
+	}
+	if len(fileTypes) == 0 {
+		return nil, nil
+	}
+
+	others, err := s.submissionRepo.GetByAssignment(ctx, submission.AssignmentID, fileTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submissions in related languages: %w", err)
+	}
+
+	tree := structure.Flatten(structure.Parse(submission.Content, submission.FileType))
+	var scored []scoredCandidate
+	for _, other := range others {
+		if other.ID == submission.ID || exclude[other.ID] || sameAuthor(submission, &other) {
+			continue
+		}
+		score := structure.Similarity(tree, structure.Flatten(structure.Parse(other.Content, other.FileType)))
+		if score >= s.cfg.CrossLanguageThreshold {
+			scored = append(scored, scoredCandidate{submission: other, scores: similarityScores{structural: score}})
+		}
+	}
+
+	return s.confirm(ctx, submission, topCandidates(scored, s.cfg.CrossLanguageCandidates), true)
+}
+
+func (s *plagiarismService) confirm(ctx context.Context, submission *models.CodeSubmission, shortlist []scoredCandidate, crossLanguage bool) (*models.PlagiarismMatch, error) {
+	for _, candidate := range shortlist {
+		verdict, err := s.openaiSvc.ConfirmPlagiarism(ctx, &PlagiarismInput{
+			Code:              submission.Content,
+			FileType:          submission.FileType,
+			Candidate:         candidate.submission.Content,
+			CandidateFileType: candidate.submission.FileType,
+			Locale:            submission.Locale,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !verdict.IsPlagiarism {
+			continue
+		}
+
+		return &models.PlagiarismMatch{
+			SubmissionID:         submission.ID,
+			MatchedSubmissionID:  candidate.submission.ID,
+			TokenSimilarity:      candidate.scores.token,
+			StructuralSimilarity: candidate.scores.structural,
+			SemanticSimilarity:   candidate.scores.semantic,
+			CrossLanguage:        crossLanguage,
+			Explanation:          verdict.Explanation,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// exactDuplicate returns a match against the earliest submission with the
+// same normalized content. Such a copy needs no LLM confirmation.
+func exactDuplicate(submission *models.CodeSubmission, corpus []models.CodeSubmission, exclude map[string]bool) *models.PlagiarismMatch {
+	var source *models.CodeSubmission
+	for i := range corpus {
+		candidate := &corpus[i]
+		if candidate.ID == submission.ID || exclude[candidate.ID] || sameAuthor(submission, candidate) {
+			continue
+		}
+		if candidate.ContentHash != submission.ContentHash {
+			continue
+		}
+		if source == nil || candidate.CreatedAt.Before(source.CreatedAt) {
+			source = candidate
+		}
+	}
+	if source == nil {
+		return nil
+	}
+
+	return &models.PlagiarismMatch{
+		SubmissionID:         submission.ID,
+		MatchedSubmissionID:  source.ID,
+		TokenSimilarity:      1,
+		StructuralSimilarity: 1,
+		SemanticSimilarity:   1,
+		Explanation:          prompts.Message(submission.Locale, prompts.MsgExactDuplicate),
+	}
+}
+
+// sameAuthor reports whether both submissions come from the same student;
+// a resubmission is never plagiarism of the student's own earlier attempt.
+func sameAuthor(a, b *models.CodeSubmission) bool {
+	return a.StudentID != "" && a.StudentID == b.StudentID
+}
+
+type similarityScores struct {
+	token      float64
+	structural float64
+	semantic   float64
+}
+
+// best is the local metric used for shortlisting. Semantic similarity only
+// selects ANN candidates: its scale differs between embedding models.
+func (s similarityScores) best() float64 {
+	return max(s.token, s.structural)
+}
+
+type scoredCandidate struct {
+	submission models.CodeSubmission
+	scores     similarityScores
+}
+
+func topCandidates(scored []scoredCandidate, k int) []scoredCandidate {
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].scores.best() > scored[j].scores.best()
+	})
+	if k >= 0 && len(scored) > k {
+		scored = scored[:k]
+	}
+	return scored
+}
+
+// linkRetroactively orients a match found during a recheck so that the later
+// submission is the suspect, penalises it if it was not flagged before and
+// marks both sides as involved.
+func (s *plagiarismService) linkRetroactively(ctx context.Context, submission, other *models.CodeSubmission, match *models.PlagiarismMatch) error {
+	suspect, source := submission, other
+	if other.CreatedAt.After(submission.CreatedAt) {
+		suspect, source = other, submission
+	}
+	match.SubmissionID = suspect.ID
+	match.MatchedSubmissionID = source.ID
+
+	if err := s.plagiarismRepo.Create(ctx, match); err != nil {
+		return fmt.Errorf("failed to save plagiarism match: %w", err)
+	}
+
+	if !suspect.IsPlagiarism {
+		applyPlagiarismPenalty(suspect, match, s.scales.Resolve(ctx, suspect.CourseID, suspect.AssignmentID))
+		log.Printf("Plagiarism detected retroactively for submission %s", suspect.ID)
+	}
+	source.PlagiarismInvolved = true
+
+	return s.submissionRepo.Update(ctx, other)
+}
+
+func (s *plagiarismService) linkedIDs(ctx context.Context, submissionID string) (map[string]bool, error) {
+	matches, err := s.plagiarismRepo.GetBySubmissionID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plagiarism matches: %w", err)
+	}
+
+	linked := make(map[string]bool, len(matches))
+	for _, m := range matches {
+		linked[m.SubmissionID] = true
+		linked[m.MatchedSubmissionID] = true
+	}
+	return linked, nil
+}
+
+func applyPlagiarismPenalty(submission *models.CodeSubmission, match *models.PlagiarismMatch, scale *grading.Scale) {
+	submission.Grade = scale.Lowest()
+	submission.GradeLabel = scale.Label(submission.Grade)
+	submission.Passed = false
+	submission.Feedback = prompts.Message(submission.Locale, prompts.MsgPlagiarismPenalty,
+		match.Explanation, match.TokenSimilarity*100, match.StructuralSimilarity*100, submission.Feedback)
+	submission.IsPlagiarism = true
+	submission.PlagiarismInvolved = true
+	submission.GradingStatus = models.GradingStatusGraded
+}
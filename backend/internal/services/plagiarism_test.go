@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const (
+	sumLoop = "def total(xs):\n    s = 0\n    for x in xs:\n        s += x\n    return s\n"
+	sumCopy = "def summa(items):\n    acc = 0\n    for item in items:\n        acc += item\n    return acc\n"
+	// Structural similarity to sumLoop: 0.68, 0.5 and 0.05.
+	sumPrinted = sumCopy + "\nprint(summa([1, 2, 3]))\n"
+	sumGuarded = "def total(xs):\n    s = 0\n    for x in xs:\n        if x > 0:\n            s += x\n    return s\n"
+	greeter    = "name = input()\nprint('Hello, ' + name)\n"
+)
+
+type corpusRepo struct {
+	repositories.SubmissionRepository
+	submissions []models.CodeSubmission
+	updated     []string
+}
+
+func (r *corpusRepo) GetAll(ctx context.Context) ([]models.CodeSubmission, error) {
+	return append([]models.CodeSubmission(nil), r.submissions...), nil
+}
+
+func (r *corpusRepo) GetByID(ctx context.Context, id string) (*models.CodeSubmission, error) {
+	for i := range r.submissions {
+		if r.submissions[i].ID == id {
+			return &r.submissions[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *corpusRepo) GetByFileType(ctx context.Context, fileType string) ([]models.CodeSubmission, error) {
+	var found []models.CodeSubmission
+	for _, sub := range r.submissions {
+		if sub.FileType == fileType {
+			found = append(found, sub)
+		}
+	}
+	return found, nil
+}
+
+func (r *corpusRepo) GetByContentHash(ctx context.Context, contentHash, fileType string) ([]models.CodeSubmission, error) {
+	var found []models.CodeSubmission
+	for _, sub := range r.submissions {
+		if sub.FileType == fileType && ensureContentHash(&sub) == contentHash {
+			found = append(found, sub)
+		}
+	}
+	return found, nil
+}
+
+func (r *corpusRepo) Update(ctx context.Context, submission *models.CodeSubmission) error {
+	r.updated = append(r.updated, submission.ID)
+	for i := range r.submissions {
+		if r.submissions[i].ID == submission.ID {
+			r.submissions[i] = *submission
+		}
+	}
+	return nil
+}
+
+type plagiarismRepoStub struct {
+	repositories.PlagiarismRepository
+	matches []models.PlagiarismMatch
+}
+
+func (r *plagiarismRepoStub) Create(ctx context.Context, match *models.PlagiarismMatch) error {
+	r.matches = append(r.matches, *match)
+	return nil
+}
+
+func (r *plagiarismRepoStub) GetBySubmissionID(ctx context.Context, submissionID string) ([]models.PlagiarismMatch, error) {
+	var found []models.PlagiarismMatch
+	for _, m := range r.matches {
+		if m.SubmissionID == submissionID || m.MatchedSubmissionID == submissionID {
+			found = append(found, m)
+		}
+	}
+	return found, nil
+}
+
+type backfillStub struct {
+	SimilarityService
+}
+
+func (s *backfillStub) Backfill(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// confirmStub confirms candidates whose content is listed in copies and
+// records every candidate it was asked about.
+type confirmStub struct {
+	OpenAIService
+	copies     map[string]bool
+	err        error
+	candidates []string
+}
+
+func (s *confirmStub) ConfirmPlagiarism(ctx context.Context, input *PlagiarismInput) (*PlagiarismResult, error) {
+	s.candidates = append(s.candidates, input.Candidate)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &PlagiarismResult{IsPlagiarism: s.copies[input.Candidate], Explanation: "Совпадает структура цикла."}, nil
+}
+
+func newTestPlagiarismService(submissions []models.CodeSubmission, ai *confirmStub, cfg config.PlagiarismConfig) (*plagiarismService, *corpusRepo, *plagiarismRepoStub) {
+	repo := &corpusRepo{submissions: submissions}
+	matches := &plagiarismRepoStub{}
+	s := NewPlagiarismService(repo, matches, ai, &backfillStub{}, NewScaleService(nil, nil, grading.Default()), cfg)
+	return s.(*plagiarismService), repo, matches
+}
+
+func TestPlagiarismLink_MarksSource(t *testing.T) {
+	s, repo, matches := newTestPlagiarismService([]models.CodeSubmission{
+		{ID: "old", FileType: ".py", Content: sumLoop},
+	}, &confirmStub{}, config.PlagiarismConfig{})
+
+	match := &models.PlagiarismMatch{SubmissionID: "new", MatchedSubmissionID: "old"}
+	require.NoError(t, s.Link(context.Background(), match))
+	require.Len(t, matches.matches, 1)
+	assert.True(t, repo.submissions[0].PlagiarismInvolved)
+	assert.Equal(t, []string{"old"}, repo.updated)
+
+	// An already involved source is not written again.
+	require.NoError(t, s.Link(context.Background(), match))
+	assert.Len(t, matches.matches, 2)
+	assert.Equal(t, []string{"old"}, repo.updated)
+}
+
+func TestPlagiarismRecheck_LinksRetroactively(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ai := &confirmStub{copies: map[string]bool{sumLoop: true, sumCopy: true}}
+	s, repo, matches := newTestPlagiarismService([]models.CodeSubmission{
+		// The older solution was imported after the newer one had been graded.
+		{ID: "old", StudentID: "alice", FileType: ".py", Content: sumLoop, CreatedAt: created},
+		{ID: "new", StudentID: "bob", FileType: ".py", Content: sumCopy, CreatedAt: created.Add(time.Hour),
+			Grade: 5, Passed: true, Feedback: "Отлично.", GradingStatus: models.GradingStatusGraded},
+	}, ai, config.PlagiarismConfig{TopK: 3, MinSimilarity: 0.5})
+
+	result, err := s.Recheck(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, 1, result.Matches)
+
+	require.Len(t, matches.matches, 1)
+	assert.Equal(t, "new", matches.matches[0].SubmissionID)
+	assert.Equal(t, "old", matches.matches[0].MatchedSubmissionID)
+
+	old, suspect := repo.submissions[0], repo.submissions[1]
+	assert.True(t, old.PlagiarismInvolved)
+	assert.False(t, old.IsPlagiarism)
+	assert.True(t, suspect.IsPlagiarism)
+	assert.Equal(t, grading.Default().Lowest(), suspect.Grade)
+	assert.False(t, suspect.Passed)
+	assert.Equal(t, 1, old.PlagiarismCorpusSize)
+	assert.Equal(t, 1, suspect.PlagiarismCorpusSize)
+
+	// The linked pair is not sent for confirmation a second time.
+	assert.Len(t, ai.candidates, 1)
+}
+
+func TestPlagiarismRecheck_SkipsUnchangedCorpus(t *testing.T) {
+	ai := &confirmStub{copies: map[string]bool{sumLoop: true, sumCopy: true}}
+	s, repo, matches := newTestPlagiarismService([]models.CodeSubmission{
+		{ID: "a", FileType: ".py", Content: sumLoop, ContentHash: "a", PlagiarismCorpusSize: 1},
+		{ID: "b", FileType: ".py", Content: sumCopy, ContentHash: "b", PlagiarismCorpusSize: 1},
+	}, ai, config.PlagiarismConfig{TopK: 3, MinSimilarity: 0.5})
+
+	result, err := s.Recheck(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.Checked)
+	assert.Empty(t, ai.candidates)
+	assert.Empty(t, matches.matches)
+	assert.Empty(t, repo.updated)
+}
+
+func TestPlagiarismCheck_CorpusSizeOnlyAfterDetection(t *testing.T) {
+	corpus := []models.CodeSubmission{{ID: "old", FileType: ".py", Content: sumLoop}}
+	submission := &models.CodeSubmission{ID: "new", FileType: ".py", Content: sumCopy}
+
+	ai := &confirmStub{err: errors.New("circuit breaker is open")}
+	s, _, _ := newTestPlagiarismService(corpus, ai, config.PlagiarismConfig{TopK: 3, MinSimilarity: 0.5})
+	_, err := s.Check(context.Background(), submission)
+	require.Error(t, err)
+	assert.Zero(t, submission.PlagiarismCorpusSize, "a failed check must be retried by the next recheck")
+
+	ai.err = nil
+	match, err := s.Check(context.Background(), submission)
+	require.NoError(t, err)
+	assert.Nil(t, match)
+	assert.Equal(t, 1, submission.PlagiarismCorpusSize)
+}
+
+func shortlistCorpus() []models.CodeSubmission {
+	return []models.CodeSubmission{
+		{ID: "greeter", FileType: ".py", Content: greeter},
+		{ID: "guarded", FileType: ".py", Content: sumGuarded},
+		{ID: "printed", FileType: ".py", Content: sumPrinted},
+		{ID: "copy", FileType: ".py", Content: sumCopy},
+	}
+}
+
+func TestPlagiarismDetect_Shortlist(t *testing.T) {
+	submission := &models.CodeSubmission{ID: "new", FileType: ".py", Content: sumLoop}
+
+	tests := []struct {
+		name     string
+		cfg      config.PlagiarismConfig
+		expected []string
+	}{
+		{"top k", config.PlagiarismConfig{TopK: 2, MinSimilarity: 0.4}, []string{sumCopy, sumPrinted}},
+		{"min similarity", config.PlagiarismConfig{TopK: 10, MinSimilarity: 0.4}, []string{sumCopy, sumPrinted, sumGuarded}},
+		{"nothing similar", config.PlagiarismConfig{TopK: 10, MinSimilarity: 1.1}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ai := &confirmStub{}
+			s, _, _ := newTestPlagiarismService(nil, ai, tt.cfg)
+			match, err := s.detect(context.Background(), submission, shortlistCorpus(), nil)
+			require.NoError(t, err)
+			assert.Nil(t, match)
+			assert.Equal(t, tt.expected, ai.candidates)
+		})
+	}
+}
+
+func TestPlagiarismDetect_RejectedCandidateFallsThrough(t *testing.T) {
+	ai := &confirmStub{copies: map[string]bool{sumPrinted: true}}
+	s, _, _ := newTestPlagiarismService(nil, ai, config.PlagiarismConfig{TopK: 3, MinSimilarity: 0.4})
+
+	submission := &models.CodeSubmission{ID: "new", FileType: ".py", Content: sumLoop}
+	match, err := s.detect(context.Background(), submission, shortlistCorpus(), nil)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "new", match.SubmissionID)
+	assert.Equal(t, "printed", match.MatchedSubmissionID)
+	assert.InDelta(t, 0.68, match.StructuralSimilarity, 0.01)
+	assert.Equal(t, "Совпадает структура цикла.", match.Explanation)
+	// The candidate after the confirmed one is never sent.
+	assert.Equal(t, []string{sumCopy, sumPrinted}, ai.candidates)
+}
+
+func TestPlagiarismDetect_FailedConfirmation(t *testing.T) {
+	ai := &confirmStub{err: errors.New("circuit breaker is open")}
+	s, _, _ := newTestPlagiarismService(nil, ai, config.PlagiarismConfig{TopK: 3, MinSimilarity: 0.4})
+
+	submission := &models.CodeSubmission{ID: "new", FileType: ".py", Content: sumLoop}
+	match, err := s.detect(context.Background(), submission, shortlistCorpus(), nil)
+	require.Error(t, err)
+	assert.Nil(t, match)
+	assert.Equal(t, []string{sumCopy}, ai.candidates)
+}
+
+func TestPlagiarismCheck_ExcludesSameAuthor(t *testing.T) {
+	ai := &confirmStub{copies: map[string]bool{sumLoop: true, sumCopy: true, sumPrinted: true}}
+	s, _, _ := newTestPlagiarismService([]models.CodeSubmission{
+		{ID: "resubmitted", StudentID: "bob", FileType: ".py", Content: sumLoop},
+		{ID: "earlier", StudentID: "bob", FileType: ".py", Content: sumCopy},
+		{ID: "printed", StudentID: "alice", FileType: ".py", Content: sumPrinted},
+	}, ai, config.PlagiarismConfig{TopK: 3, MinSimilarity: 0.4})
+
+	submission := &models.CodeSubmission{ID: "new", StudentID: "bob", FileType: ".py", Content: sumLoop}
+	match, err := s.Check(context.Background(), submission)
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "printed", match.MatchedSubmissionID)
+	assert.Equal(t, []string{sumPrinted}, ai.candidates)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"codegrader-backend/internal/metrics"
+	"codegrader-backend/internal/models"
+)
+
+var (
+	ErrInvalidPractice = errors.New("invalid practice submission")
+	ErrPracticeLimit   = errors.New("practice limit reached")
+)
+
+// PracticeLimitError tells the student when the next practice submission
+// for the assignment will be accepted.
+type PracticeLimitError struct {
+	Limit      int
+	Window     time.Duration
+	RetryAfter time.Duration
+}
+
+func (e *PracticeLimitError) Error() string {
+	return fmt.Sprintf("%v: %d practice submissions per %s, next one in %s", ErrPracticeLimit, e.Limit, e.Window, e.RetryAfter.Round(time.Second))
+}
+
+func (e *PracticeLimitError) Unwrap() error {
+	return ErrPracticeLimit
+}
+
+// Practice answers a practice submission with hints and guiding questions.
+// Nothing is graded or stored except the attempt itself, which counts
+// towards the student's limit for the assignment.
+func (s *submissionService) Practice(ctx context.Context, req *models.SubmissionRequest) (*models.PracticeResponse, error) {
+	if !s.practice.Enabled {
+		return nil, fmt.Errorf("%w: practice mode is disabled", ErrInvalidPractice)
+	}
+	if req.StudentID == "" {
+		return nil, fmt.Errorf("%w: student_id is required", ErrInvalidPractice)
+	}
+	allowedTypes := []string{".c", ".cpp", ".java", ".js", ".kt", ".py", ".ts"}
+	if !contains(allowedTypes, req.FileType) {
+		return nil, fmt.Errorf("unsupported file type: %s", req.FileType)
+	}
+
+	attempt := &models.PracticeAttempt{
+		CourseID:     req.CourseID,
+		AssignmentID: req.AssignmentID,
+		StudentID:    req.StudentID,
+	}
+	used, err := s.reservePracticeAttempt(ctx, attempt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.practiceHints(ctx, req)
+	if err != nil {
+		// Only answered submissions count towards the limit.
+		if err := s.practiceRepo.Delete(context.WithoutCancel(ctx), attempt.ID); err != nil {
+			log.Printf("Failed to release practice attempt of student %s: %v", req.StudentID, err)
+		}
+		return nil, err
+	}
+
+	if s.practice.Limit > 0 {
+		remaining := max(0, s.practice.Limit-used-1)
+		resp.RemainingAttempts = &remaining
+	}
+	return resp, nil
+}
+
+func (s *submissionService) practiceHints(ctx context.Context, req *models.SubmissionRequest) (*models.PracticeResponse, error) {
+	submission := &models.CodeSubmission{
+		FileName:     req.FileName,
+		FileType:     req.FileType,
+		CourseID:     req.CourseID,
+		AssignmentID: req.AssignmentID,
+		StudentID:    req.StudentID,
+		Content:      req.Content,
+	}
+	submission.Locale = s.analysisSvc.ResolveLocale(ctx, req.CourseID, req.Locale, req.AcceptLanguage)
+	if err := s.analysisSvc.Validate(submission); err != nil {
+		return nil, err
+	}
+	submission.Metrics = metrics.Compute(submission.Content, submission.FileType)
+	submission.LintFindings = s.linter.Run(ctx, submission.FileName, submission.FileType, submission.Content)
+	if live := s.redactor.Redact(submission.Content).Live(); s.rejectSecrets && len(live) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSecretsDetected, strings.Join(live, ", "))
+	}
+
+	// There is nothing to queue for later: without budget practice is
+	// refused whatever the course's quota action.
+	ctx = withUsageScope(ctx, submission)
+	if err := s.usageSvc.CheckQuota(ctx, submission.CourseID); quotaAction(err) != "" {
+		return nil, err
+	}
+
+	result, err := s.analysisSvc.Hint(ctx, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PracticeResponse{
+		Mode:      models.SubmissionModePractice,
+		Hints:     result.Hints,
+		Questions: result.Questions,
+		Metrics:   submission.Metrics,
+	}, nil
+}
+
+// reservePracticeAttempt records attempt and returns the number of practice
+// submissions the student made for the assignment within the window before
+// it, or a *PracticeLimitError without recording anything when the limit is
+// reached. A limit of 0 or less disables the check.
+func (s *submissionService) reservePracticeAttempt(ctx context.Context, attempt *models.PracticeAttempt, now time.Time) (int, error) {
+	attempts, err := s.practiceRepo.Reserve(ctx, attempt, now.Add(-s.practice.Window), s.practice.Limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve practice attempt: %w", err)
+	}
+	if s.practice.Limit <= 0 || len(attempts) < s.practice.Limit {
+		return len(attempts), nil
+	}
+	// The oldest attempts leave the window first.
+	oldest := attempts[len(attempts)-s.practice.Limit]
+	return len(attempts), &PracticeLimitError{
+		Limit:      s.practice.Limit,
+		Window:     s.practice.Window,
+		RetryAfter: oldest.CreatedAt.Add(s.practice.Window).Sub(now),
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// practiceRepoStub serialises Reserve the way the advisory lock does.
+type practiceRepoStub struct {
+	repositories.PracticeRepository
+	mu       sync.Mutex
+	attempts []models.PracticeAttempt
+	since    time.Time
+	nextID   uint
+}
+
+func (r *practiceRepoStub) Reserve(ctx context.Context, attempt *models.PracticeAttempt, since time.Time, limit int) ([]models.PracticeAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.since = since
+	attempts := append([]models.PracticeAttempt(nil), r.attempts...)
+	if limit > 0 && len(attempts) >= limit {
+		return attempts, nil
+	}
+	r.nextID++
+	attempt.ID = r.nextID
+	attempt.CreatedAt = time.Now()
+	r.attempts = append(r.attempts, *attempt)
+	return attempts, nil
+}
+
+func (r *practiceRepoStub) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, attempt := range r.attempts {
+		if attempt.ID == id {
+			r.attempts = append(r.attempts[:i], r.attempts[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *practiceRepoStub) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.attempts)
+}
+
+type hintStub struct {
+	AnalysisService
+	err error
+}
+
+func (a *hintStub) ResolveLocale(ctx context.Context, courseID, requested, acceptLanguage string) string {
+	return "ru"
+}
+
+func (a *hintStub) Validate(submission *models.CodeSubmission) error {
+	return nil
+}
+
+func (a *hintStub) Hint(ctx context.Context, submission *models.CodeSubmission) (*PracticeResult, error) {
+	// Leave room for concurrent requests to overlap with the LLM call.
+	time.Sleep(10 * time.Millisecond)
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &PracticeResult{Hints: []string{"Проверьте границы цикла."}}, nil
+}
+
+func TestReservePracticeAttempt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &practiceRepoStub{attempts: []models.PracticeAttempt{
+		{CreatedAt: now.Add(-50 * time.Minute)},
+		{CreatedAt: now.Add(-20 * time.Minute)},
+	}}
+	s := &submissionService{practiceRepo: repo, practice: config.PracticeConfig{Enabled: true, Limit: 3, Window: time.Hour}}
+
+	used, err := s.reservePracticeAttempt(context.Background(), &models.PracticeAttempt{StudentID: "s1"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, used)
+	assert.Equal(t, now.Add(-time.Hour), repo.since)
+	assert.Equal(t, 3, repo.count())
+
+	_, err = s.reservePracticeAttempt(context.Background(), &models.PracticeAttempt{StudentID: "s1"}, now)
+	var limit *PracticeLimitError
+	require.True(t, errors.As(err, &limit))
+	assert.ErrorIs(t, err, ErrPracticeLimit)
+	assert.Equal(t, 10*time.Minute, limit.RetryAfter)
+	assert.Equal(t, 3, repo.count(), "a refused attempt is not recorded")
+
+	s.practice.Limit = 0
+	_, err = s.reservePracticeAttempt(context.Background(), &models.PracticeAttempt{StudentID: "s1"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, repo.count())
+}
+
+func newTestPracticeService(limit int, hint *hintStub) (*submissionService, *practiceRepoStub) {
+	repo := &practiceRepoStub{}
+	return &submissionService{
+		analysisSvc:  hint,
+		usageSvc:     &openQuotaStub{},
+		practiceRepo: repo,
+		practice:     config.PracticeConfig{Enabled: true, Limit: limit, Window: time.Hour},
+	}, repo
+}
+
+func TestPractice_ConcurrentRequestsRespectLimit(t *testing.T) {
+	s, repo := newTestPracticeService(3, &hintStub{})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.Practice(context.Background(), &models.SubmissionRequest{
+				FileType: ".py", StudentID: "s1", AssignmentID: "a1", Content: "print(1)",
+			})
+		}()
+	}
+	wg.Wait()
+
+	answered := 0
+	for _, err := range errs {
+		if err == nil {
+			answered++
+		} else {
+			assert.ErrorIs(t, err, ErrPracticeLimit)
+		}
+	}
+	assert.Equal(t, 3, answered)
+	assert.Equal(t, 3, repo.count())
+}
+
+func TestPractice_FailedHintReleasesAttempt(t *testing.T) {
+	s, repo := newTestPracticeService(3, &hintStub{err: errors.New("circuit breaker is open")})
+
+	req := &models.SubmissionRequest{FileType: ".py", StudentID: "s1", AssignmentID: "a1", Content: "print(1)"}
+	_, err := s.Practice(context.Background(), req)
+	require.Error(t, err)
+	assert.Zero(t, repo.count())
+
+	s.analysisSvc = &hintStub{}
+	resp, err := s.Practice(context.Background(), req)
+	require.NoError(t, err)
+	require.NotNil(t, resp.RemainingAttempts)
+	assert.Equal(t, 2, *resp.RemainingAttempts)
+	assert.Equal(t, 1, repo.count())
+}
+
+func TestPractice_RequiresStudent(t *testing.T) {
+	s := &submissionService{practice: config.PracticeConfig{Enabled: true, Limit: 3, Window: time.Hour}}
+
+	_, err := s.Practice(context.Background(), &models.SubmissionRequest{FileType: ".py", Content: "print(1)"})
+	assert.ErrorIs(t, err, ErrInvalidPractice)
+
+	s.practice.Enabled = false
+	_, err = s.Practice(context.Background(), &models.SubmissionRequest{FileType: ".py", StudentID: "s1", Content: "print(1)"})
+	assert.ErrorIs(t, err, ErrInvalidPractice)
+}
+
+func TestParsePracticeResponse(t *testing.T) {
+	result := parsePracticeResponse(`**Подсказки:**
+- Что вернёт функция для пустого списка?
+  Проверьте это отдельно.
+2. Посмотрите на условие цикла.
+` + "```python\nreturn sum(xs)\n```" + `
+Вопросы:
+* Зачем нужен второй цикл?`)
+
+	assert.Equal(t, []string{"Что вернёт функция для пустого списка? Проверьте это отдельно.", "Посмотрите на условие цикла."}, result.Hints)
+	assert.Equal(t, []string{"Зачем нужен второй цикл?"}, result.Questions)
+
+	result = parsePracticeResponse("Check the loop bounds.")
+	assert.Equal(t, []string{"Check the loop bounds."}, result.Hints)
+	assert.Empty(t, result.Questions)
+}
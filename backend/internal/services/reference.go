@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"codegrader-backend/internal/models"
+)
+
+// referenceData is a reference solution as the analysis templates see it.
+type referenceData struct {
+	Name     string
+	Language string
+	Code     string
+}
+
+// referenceSolutions returns the reference solutions of input's assignment.
+// Unnamed ones are numbered.
+func referenceSolutions(input *AnalysisInput) []referenceData {
+	if input.Assignment == nil {
+		return nil
+	}
+	var references []referenceData
+	for i, r := range input.Assignment.ReferenceSolutions {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		fileType := r.FileType
+		if fileType == "" {
+			fileType = input.FileType
+		}
+		references = append(references, referenceData{Name: name, Language: getLanguageName(fileType), Code: r.Content})
+	}
+	return references
+}
+
+// fencedTexts returns the code, the linter findings that quote it and the
+// reference solutions, everything a fence must not collide with.
+func (d promptData) fencedTexts(code string) []string {
+	texts := []string{code}
+	for _, f := range d.Lint {
+		texts = append(texts, f.Message)
+	}
+	for _, r := range d.References {
+		texts = append(texts, r.Code)
+	}
+	return texts
+}
+
+// fenceReferences wraps the reference solutions in fence like the student's
+// code, so that the model treats both as data.
+func (d *promptData) fenceReferences(fence string) {
+	fenced := make([]referenceData, len(d.References))
+	for i, r := range d.References {
+		r.Code = fenceCode(r.Code, fence)
+		fenced[i] = r
+	}
+	d.References = fenced
+}
+
+// parseReferenceComparison cuts the "Сравнение с эталоном:" or "Reference
+// comparison:" section out of a response. The section runs up to the
+// annotations or the end of the response; students get the rest only.
+func parseReferenceComparison(response string) (string, *models.ReferenceComparison) {
+	var feedback []string
+	var comparison *models.ReferenceComparison
+	var field *string
+	inSection := false
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case comparisonHeading(trimmed):
+			inSection = true
+			comparison = &models.ReferenceComparison{}
+			field = &comparison.Functional
+			continue
+		case !inSection:
+			feedback = append(feedback, line)
+			continue
+		case annotationsHeading(trimmed):
+			inSection = false
+			feedback = append(feedback, line)
+			continue
+		}
+
+		label, value, found := strings.Cut(trimmed, ":")
+		switch strings.ToLower(strings.Trim(label, "*#_-> ")) {
+		case "ближайшее эталонное решение", "closest reference":
+			field, line = &comparison.Closest, value
+		case "функциональные отличия", "functional differences":
+			field, line = &comparison.Functional, value
+		case "отличия в подходе", "approach differences":
+			field, line = &comparison.Approach, value
+		default:
+			found = false
+		}
+		if !found {
+			line = trimmed
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			*field = strings.TrimSpace(*field + "\n" + line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(feedback, "\n")), comparison
+}
+
+func comparisonHeading(line string) bool {
+	switch strings.ToLower(strings.Trim(line, "*#_> ")) {
+	case "сравнение с эталоном:", "reference comparison:", "сравнение с эталоном", "reference comparison":
+		return true
+	default:
+		return false
+	}
+}
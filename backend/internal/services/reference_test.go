@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestParseAnalysisResponse_HidesReferenceComparison(t *testing.T) {
+	input := &AnalysisInput{Code: "a\nb\nc", Scale: grading.Default()}
+	response := `Оценка: 4
+Комментарии: решение верное.
+**Сравнение с эталоном:**
+Ближайшее эталонное решение: через множество
+Функциональные отличия: не обрабатывается пустой список;
+возвращает None вместо 0
+Отличия в подходе: O(n²) вместо O(n)
+Замечания:
+{"start_line": 2, "end_line": 2, "severity": "warning", "category": "correctness", "message": "Пустой список"}`
+
+	result := parseAnalysisResponse(response, input)
+
+	assert.Equal(t, 4, result.Grade)
+	assert.Equal(t, "Оценка: 4\nКомментарии: решение верное.", result.Feedback)
+	require.Len(t, result.Annotations, 1)
+	assert.Equal(t, &models.ReferenceComparison{
+		Closest:    "через множество",
+		Functional: "не обрабатывается пустой список;\nвозвращает None вместо 0",
+		Approach:   "O(n²) вместо O(n)",
+	}, result.Comparison)
+}
+
+func TestParseReferenceComparison_WithoutSection(t *testing.T) {
+	feedback, comparison := parseReferenceComparison("Grade: 5\nComments: fine")
+
+	assert.Equal(t, "Grade: 5\nComments: fine", feedback)
+	assert.Nil(t, comparison)
+
+	feedback, comparison = parseReferenceComparison("Grade: 5\nReference comparison:\nSame idea as the reference.")
+	assert.Equal(t, "Grade: 5", feedback)
+	assert.Equal(t, &models.ReferenceComparison{Functional: "Same idea as the reference."}, comparison)
+}
+
+func TestNewPromptData_NamesAndFencesReferences(t *testing.T) {
+	input := &AnalysisInput{Code: "print(1)", FileType: ".py", Scale: grading.Default(), Assignment: &models.Assignment{
+		ReferenceSolutions: []models.ReferenceSolution{
+			{Name: "loop", Content: "for x in xs: pass"},
+			{FileType: ".java", Content: "class A {}"},
+		},
+	}}
+
+	data := newPromptData(input)
+	fenced := data
+	fenced.fenceReferences("F")
+
+	require.Len(t, data.References, 2)
+	assert.Equal(t, referenceData{Name: "loop", Language: "Python", Code: "for x in xs: pass"}, data.References[0])
+	assert.Equal(t, referenceData{Name: "#2", Language: "Java", Code: "<<<F\nclass A {}\nF>>>"}, fenced.References[1])
+	assert.Equal(t, []string{"print(1)", "for x in xs: pass", "class A {}"}, data.fencedTexts(input.Code))
+}
+
+type assignmentStore struct {
+	repositories.AssignmentRepository
+	assignments map[string]*models.Assignment
+}
+
+func (r *assignmentStore) GetByID(ctx context.Context, id string) (*models.Assignment, error) {
+	if a, ok := r.assignments[id]; ok {
+		return a, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *assignmentStore) Save(ctx context.Context, assignment *models.Assignment) error {
+	r.assignments[assignment.ID] = assignment
+	return nil
+}
+
+func TestReferenceSolutions_KeptOutOfAssignment(t *testing.T) {
+	repo := &assignmentStore{assignments: map[string]*models.Assignment{}}
+	svc := NewAssignmentService(repo, nil, grading.Default())
+	solutions := []models.ReferenceSolution{{Name: "через множество", FileType: ".py", Content: "len(set(xs))"}}
+
+	_, err := svc.UpdateReferenceSolutions(context.Background(), "a1", []models.ReferenceSolution{{Content: " "}})
+	assert.ErrorIs(t, err, ErrInvalidAssignment)
+
+	_, err = svc.UpdateReferenceSolutions(context.Background(), "a1", solutions)
+	require.NoError(t, err)
+	assignment, err := svc.UpdateAssignment(context.Background(), "a1", &models.AssignmentRequest{Title: "Уникальные элементы"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, assignment.Version)
+
+	encoded, err := json.Marshal(assignment)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "len(set(xs))")
+
+	stored, err := svc.GetReferenceSolutions(context.Background(), "a1")
+	require.NoError(t, err)
+	assert.Equal(t, solutions, stored)
+}
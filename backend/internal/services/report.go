@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"codegrader-backend/internal/report"
+	"codegrader-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ReportService renders a submission's grade, annotations and plagiarism
+// findings in the formats of package report.
+type ReportService interface {
+	Render(ctx context.Context, id, format string) ([]byte, string, error)
+}
+
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+type reportService struct {
+	submissionRepo repositories.SubmissionRepository
+	annotationRepo repositories.AnnotationRepository
+	plagiarismRepo repositories.PlagiarismRepository
+}
+
+func NewReportService(submissionRepo repositories.SubmissionRepository, annotationRepo repositories.AnnotationRepository, plagiarismRepo repositories.PlagiarismRepository) ReportService {
+	return &reportService{submissionRepo: submissionRepo, annotationRepo: annotationRepo, plagiarismRepo: plagiarismRepo}
+}
+
+// Render returns the report of submission id and its content type.
+func (s *reportService) Render(ctx context.Context, id, format string) ([]byte, string, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load submission %s: %w", id, err)
+	}
+
+	annotations, err := s.annotationRepo.GetBySubmissionID(ctx, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load annotations of submission %s: %w", id, err)
+	}
+	matches, err := s.plagiarismRepo.GetBySubmissionID(ctx, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load plagiarism matches of submission %s: %w", id, err)
+	}
+
+	return report.Render(format, &report.Report{Submission: submission, Annotations: annotations, Plagiarism: matches})
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"codegrader-backend/internal/grading"
+	"codegrader-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ScaleService finds the grading scale of a submission: the assignment's,
+// then the course's, then the configured default.
+type ScaleService interface {
+	Resolve(ctx context.Context, courseID, assignmentID string) *grading.Scale
+}
+
+type scaleService struct {
+	assignmentRepo repositories.AssignmentRepository
+	courseRepo     repositories.CourseRepository
+	defaultScale   *grading.Scale
+}
+
+func NewScaleService(assignmentRepo repositories.AssignmentRepository, courseRepo repositories.CourseRepository, defaultScale *grading.Scale) ScaleService {
+	return &scaleService{assignmentRepo: assignmentRepo, courseRepo: courseRepo, defaultScale: defaultScale}
+}
+
+func (s *scaleService) Resolve(ctx context.Context, courseID, assignmentID string) *grading.Scale {
+	if assignmentID != "" {
+		assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+		switch {
+		case err == nil && assignment.GradingScale != nil:
+			return assignment.GradingScale
+		case err == nil && courseID == "":
+			courseID = assignment.CourseID
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			log.Printf("Failed to load assignment %s: %v", assignmentID, err)
+		}
+	}
+
+	if courseID != "" {
+		course, err := s.courseRepo.GetByID(ctx, courseID)
+		switch {
+		case err == nil && course.GradingScale != nil:
+			return course.GradingScale
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			log.Printf("Failed to load course %s: %v", courseID, err)
+		}
+	}
+
+	return s.defaultScale
+}
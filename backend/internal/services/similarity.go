@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"codegrader-backend/internal/embedding"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+)
+
+type SimilarityService interface {
+	Embed(ctx context.Context, submission *models.CodeSubmission) error
+	Index(ctx context.Context, submission *models.CodeSubmission) error
+	Nearest(ctx context.Context, submission *models.CodeSubmission, limit int) ([]models.SimilarSubmission, error)
+	FindSimilar(ctx context.Context, submissionID string, limit int) ([]models.SimilarSubmission, error)
+	Backfill(ctx context.Context) (int, error)
+}
+
+type similarityService struct {
+	submissionRepo repositories.SubmissionRepository
+	embeddingRepo  repositories.EmbeddingRepository
+	embedder       embedding.Embedder
+}
+
+func NewSimilarityService(submissionRepo repositories.SubmissionRepository, embeddingRepo repositories.EmbeddingRepository, embedder embedding.Embedder) SimilarityService {
+	return &similarityService{
+		submissionRepo: submissionRepo,
+		embeddingRepo:  embeddingRepo,
+		embedder:       embedder,
+	}
+}
+
+// Embed computes the vector of a submission in memory without storing it.
+func (s *similarityService) Embed(ctx context.Context, submission *models.CodeSubmission) error {
+	if submission.Embedding != nil {
+		return nil
+	}
+
+	vector, err := s.embedder.Embed(ctx, submission.Content, submission.FileType)
+	if err != nil {
+		return err
+	}
+	submission.Embedding = vector
+	return nil
+}
+
+func (s *similarityService) Index(ctx context.Context, submission *models.CodeSubmission) error {
+	if err := s.Embed(ctx, submission); err != nil {
+		return err
+	}
+
+	return s.embeddingRepo.Save(ctx, &models.SubmissionEmbedding{
+		SubmissionID: submission.ID,
+		FileType:     submission.FileType,
+		Model:        s.embedder.Model(),
+		Embedding:    submission.Embedding,
+	})
+}
+
+// Nearest runs an approximate nearest-neighbour search over stored
+// embeddings of the same file type.
+func (s *similarityService) Nearest(ctx context.Context, submission *models.CodeSubmission, limit int) ([]models.SimilarSubmission, error) {
+	if err := s.Embed(ctx, submission); err != nil {
+		return nil, err
+	}
+
+	return s.embeddingRepo.FindNearest(ctx, submission.Embedding, submission.FileType, s.embedder.Model(), submission.ID, limit)
+}
+
+func (s *similarityService) FindSimilar(ctx context.Context, submissionID string, limit int) ([]models.SimilarSubmission, error) {
+	submission, err := s.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.embeddingRepo.GetBySubmissionID(ctx, submissionID)
+	if err == nil && stored.Model == s.embedder.Model() {
+		submission.Embedding = stored.Embedding
+	} else if err := s.Index(ctx, submission); err != nil {
+		return nil, fmt.Errorf("failed to index submission: %w", err)
+	}
+
+	return s.Nearest(ctx, submission, limit)
+}
+
+// Backfill embeds submissions stored before indexing existed or under a
+// different embedding model.
+func (s *similarityService) Backfill(ctx context.Context) (int, error) {
+	ids, err := s.embeddingRepo.GetMissingSubmissionIDs(ctx, s.embedder.Model())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get submissions without embeddings: %w", err)
+	}
+
+	indexed := 0
+	for _, id := range ids {
+		submission, err := s.submissionRepo.GetByID(ctx, id)
+		if err != nil {
+			return indexed, err
+		}
+		if err := s.Index(ctx, submission); err != nil {
+			log.Printf("Failed to index submission %s: %v", id, err)
+			continue
+		}
+		indexed++
+	}
+
+	return indexed, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/style"
+
+	"gorm.io/gorm"
+)
+
+// StyleService keeps a style profile per student and language and measures
+// how far new submissions deviate from it. The deviation is shown to
+// teachers only and never changes a grade.
+type StyleService interface {
+	// Assess returns nil when profiles are disabled, the submission has no
+	// student or the profile has too few submissions yet.
+	Assess(ctx context.Context, submission *models.CodeSubmission) *style.Deviation
+	// Record adds a stored submission to its student's profile.
+	Record(ctx context.Context, submission *models.CodeSubmission)
+}
+
+type styleService struct {
+	profileRepo    repositories.StyleProfileRepository
+	submissionRepo repositories.SubmissionRepository
+	cfg            config.StyleConfig
+}
+
+func NewStyleService(profileRepo repositories.StyleProfileRepository, submissionRepo repositories.SubmissionRepository, cfg config.StyleConfig) StyleService {
+	return &styleService{
+		profileRepo:    profileRepo,
+		submissionRepo: submissionRepo,
+		cfg:            cfg,
+	}
+}
+
+func (s *styleService) Assess(ctx context.Context, submission *models.CodeSubmission) *style.Deviation {
+	if !s.cfg.Enabled || submission.StudentID == "" {
+		return nil
+	}
+	profile, err := s.profile(ctx, submission)
+	if err != nil {
+		log.Printf("Failed to load style profile of student %s: %v", submission.StudentID, err)
+		return nil
+	}
+	return profile.Profile.Compare(style.Extract(submission.Content, submission.FileType))
+}
+
+func (s *styleService) Record(ctx context.Context, submission *models.CodeSubmission) {
+	if !s.cfg.Enabled || submission.StudentID == "" {
+		return
+	}
+	profile, err := s.profile(ctx, submission)
+	if err != nil {
+		log.Printf("Failed to load style profile of student %s: %v", submission.StudentID, err)
+		return
+	}
+	profile.Profile.Add(style.Extract(submission.Content, submission.FileType))
+	if err := s.profileRepo.Save(ctx, profile); err != nil {
+		log.Printf("Failed to save style profile of student %s: %v", submission.StudentID, err)
+	}
+}
+
+// profile returns the stored profile of the submission's student. Students
+// without one, such as those who submitted before profiles existed, get a
+// profile built from their earlier submissions.
+func (s *styleService) profile(ctx context.Context, submission *models.CodeSubmission) (*models.StyleProfile, error) {
+	profile, err := s.profileRepo.Get(ctx, submission.StudentID, submission.FileType)
+	if err == nil {
+		return profile, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	profile = &models.StyleProfile{StudentID: submission.StudentID, FileType: submission.FileType}
+	if s.cfg.History <= 0 {
+		return profile, nil
+	}
+	previous, err := s.submissionRepo.GetRecentByStudent(ctx, submission.StudentID, submission.FileType, s.cfg.History+1)
+	if err != nil {
+		return nil, err
+	}
+	added := 0
+	for _, p := range previous {
+		if p.ID == submission.ID || added == s.cfg.History {
+			continue
+		}
+		profile.Profile.Add(style.Extract(p.Content, p.FileType))
+		added++
+	}
+	return profile, nil
+}
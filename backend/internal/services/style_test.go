@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/style"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type styleProfileStub struct {
+	stored *models.StyleProfile
+	saved  int
+}
+
+func (r *styleProfileStub) Get(ctx context.Context, studentID, fileType string) (*models.StyleProfile, error) {
+	if r.stored == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *r.stored
+	return &copied, nil
+}
+
+func (r *styleProfileStub) Save(ctx context.Context, profile *models.StyleProfile) error {
+	r.stored = profile
+	r.saved++
+	return nil
+}
+
+const snakeCase = "def add_all(values):\n    total_sum = 0\n    for value in values:\n        total_sum += value\n    return total_sum\n"
+
+func TestStyleService_BuildsProfileFromHistory(t *testing.T) {
+	history := &studentHistoryRepo{previous: []models.CodeSubmission{
+		{ID: "new", FileType: ".py", Content: snakeCase},
+		{ID: "old-1", FileType: ".py", Content: snakeCase},
+		{ID: "old-2", FileType: ".py", Content: snakeCase},
+		{ID: "old-3", FileType: ".py", Content: snakeCase},
+	}}
+	profiles := &styleProfileStub{}
+	svc := NewStyleService(profiles, history, config.StyleConfig{Enabled: true, History: 50})
+
+	camelCase := &models.CodeSubmission{ID: "new", StudentID: "s1", FileType: ".py",
+		Content: "def addAll(values):\n  totalSum=0\n  for value in values:\n    totalSum=totalSum+value\n  return totalSum\n"}
+	deviation := svc.Assess(context.Background(), camelCase)
+
+	require.NotNil(t, deviation)
+	assert.Equal(t, 3, deviation.Submissions)
+	assert.Equal(t, 51, history.limit)
+	assert.Greater(t, deviation.Score, 0.2)
+	assert.Equal(t, 1.0, deviation.Features[0].Deviation)
+
+	svc.Record(context.Background(), camelCase)
+	require.Equal(t, 1, profiles.saved)
+	assert.Equal(t, 4, profiles.stored.Profile.Submissions)
+	assert.Equal(t, "s1", profiles.stored.StudentID)
+}
+
+func TestStyleService_SkipsWithoutStudentOrHistory(t *testing.T) {
+	profiles := &styleProfileStub{stored: &models.StyleProfile{StudentID: "s1", FileType: ".py"}}
+	profiles.stored.Profile.Add(style.Extract(snakeCase, ".py"))
+	svc := NewStyleService(profiles, &studentHistoryRepo{}, config.StyleConfig{Enabled: true, History: 50})
+	sub := &models.CodeSubmission{ID: "1", StudentID: "s1", FileType: ".py", Content: snakeCase}
+
+	assert.Nil(t, svc.Assess(context.Background(), sub))
+	assert.Nil(t, svc.Assess(context.Background(), &models.CodeSubmission{ID: "2", FileType: ".py", Content: snakeCase}))
+
+	disabled := NewStyleService(profiles, &studentHistoryRepo{}, config.StyleConfig{})
+	disabled.Record(context.Background(), sub)
+	assert.Equal(t, 0, profiles.saved)
+}
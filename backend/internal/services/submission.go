@@ -1,117 +1,271 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/lint"
+	"codegrader-backend/internal/metrics"
 	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/prompts"
+	"codegrader-backend/internal/redaction"
 	"codegrader-backend/internal/repositories"
+	"codegrader-backend/internal/resilience"
 
 	"github.com/google/uuid"
 )
 
 type SubmissionService interface {
-	CreateSubmission(req *models.SubmissionRequest) (*models.SubmissionResponse, error)
-	GetSubmission(id string) (*models.CodeSubmission, error)
-	GetAllSubmissions() ([]models.SubmissionListResponse, error)
-	DeleteSubmission(id string) error
+	CreateSubmission(ctx context.Context, req *models.SubmissionRequest) (*models.SubmissionResponse, error)
+	GetSubmission(ctx context.Context, id string) (*models.CodeSubmission, error)
+	GetAllSubmissions(ctx context.Context) ([]models.SubmissionListResponse, error)
+	GetReviewQueue(ctx context.Context) ([]models.SubmissionListResponse, error)
+	Practice(ctx context.Context, req *models.SubmissionRequest) (*models.PracticeResponse, error)
+	GetAnnotations(ctx context.Context, id string) ([]models.Annotation, error)
+	DeleteSubmission(ctx context.Context, id string) error
 }
 
+// ErrSecretsDetected rejects code with live-looking credentials when
+// REDACTION_REJECT_SECRETS is set.
+var ErrSecretsDetected = errors.New("submission contains credentials")
+
 type submissionService struct {
-	repo      repositories.SubmissionRepository
-	openaiSvc OpenAIService
+	repo           repositories.SubmissionRepository
+	annotationRepo repositories.AnnotationRepository
+	analysisSvc    AnalysisService
+	usageSvc       UsageService
+	plagiarismSvc  PlagiarismService
+	similaritySvc  SimilarityService
+	aiDetector     AIDetectionService
+	styleSvc       StyleService
+	scales         ScaleService
+	redactor       *redaction.Redactor
+	linter         *lint.Runner
+	practiceRepo   repositories.PracticeRepository
+	practice       config.PracticeConfig
+	rejectSecrets  bool
 }
 
-func NewSubmissionService(repo repositories.SubmissionRepository, openaiSvc OpenAIService) SubmissionService {
+func NewSubmissionService(repo repositories.SubmissionRepository, annotationRepo repositories.AnnotationRepository, analysisSvc AnalysisService, usageSvc UsageService, plagiarismSvc PlagiarismService, similaritySvc SimilarityService, aiDetector AIDetectionService, styleSvc StyleService, scales ScaleService, redactor *redaction.Redactor, linter *lint.Runner, practiceRepo repositories.PracticeRepository, practice config.PracticeConfig, rejectSecrets bool) SubmissionService {
 	return &submissionService{
-		repo:      repo,
-		openaiSvc: openaiSvc,
+		repo:           repo,
+		annotationRepo: annotationRepo,
+		analysisSvc:    analysisSvc,
+		usageSvc:       usageSvc,
+		plagiarismSvc:  plagiarismSvc,
+		similaritySvc:  similaritySvc,
+		aiDetector:     aiDetector,
+		styleSvc:       styleSvc,
+		scales:         scales,
+		redactor:       redactor,
+		linter:         linter,
+		practiceRepo:   practiceRepo,
+		practice:       practice,
+		rejectSecrets:  rejectSecrets,
 	}
 }
 
-func (s *submissionService) CreateSubmission(req *models.SubmissionRequest) (*models.SubmissionResponse, error) {
-	allowedTypes := []string{".cpp", ".java", ".js", ".kt", ".py"}
+func (s *submissionService) CreateSubmission(ctx context.Context, req *models.SubmissionRequest) (*models.SubmissionResponse, error) {
+	allowedTypes := []string{".c", ".cpp", ".java", ".js", ".kt", ".py", ".ts"}
 	if !contains(allowedTypes, req.FileType) {
 		return nil, fmt.Errorf("unsupported file type: %s", req.FileType)
 	}
 
 	submission := &models.CodeSubmission{
-		ID:        uuid.New().String(),
-		FileName:  req.FileName,
-		FileType:  req.FileType,
-		Content:   req.Content,
-		CreatedAt: time.Now(),
+		ID:           uuid.New().String(),
+		FileName:     req.FileName,
+		FileType:     req.FileType,
+		CourseID:     req.CourseID,
+		AssignmentID: req.AssignmentID,
+		StudentID:    req.StudentID,
+		Content:      req.Content,
+		CreatedAt:    time.Now(),
 	}
+	ensureContentHash(submission)
+	submission.Locale = s.analysisSvc.ResolveLocale(ctx, req.CourseID, req.Locale, req.AcceptLanguage)
 
-	existingSubmissions, err := s.repo.GetContentByFileType(req.FileType)
-	if err != nil {
-		log.Printf("Failed to get existing submissions for plagiarism check: %v", err)
-		existingSubmissions = []string{}
+	if err := s.analysisSvc.Validate(submission); err != nil {
+		return nil, err
+	}
+	submission.Metrics = metrics.Compute(submission.Content, submission.FileType)
+	submission.LintFindings = s.linter.Run(ctx, submission.FileName, submission.FileType, submission.Content)
+
+	// The stored code stays intact for the teacher; the provider only ever
+	// sees the masked version, see openAIService.
+	redacted := s.redactor.Redact(submission.Content)
+	if live := redacted.Live(); s.rejectSecrets && len(live) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSecretsDetected, strings.Join(live, ", "))
+	}
+	if len(redacted.Findings) > 0 {
+		log.Printf("Redacted %d values in submission %s: %v", len(redacted.Findings), submission.ID, redacted.Rules())
+		submission.Redactions = strings.Join(redacted.Rules(), ",")
+	}
+
+	ctx = withUsageScope(ctx, submission)
+	quotaErr := s.usageSvc.CheckQuota(ctx, submission.CourseID)
+	action := quotaAction(quotaErr)
+	if action == models.QuotaActionReject {
+		return nil, quotaErr
 	}
 
-	isPlagiarism := false
-	plagiarismExplanation := ""
-	if len(existingSubmissions) > 0 {
-		isPlagiarism, plagiarismExplanation, err = s.openaiSvc.CheckForPlagiarism(req.Content, req.FileType, existingSubmissions)
+	// With the budget spent nothing is sent to the LLM: the plagiarism check
+	// is left to a later recheck and grading to the grading queue.
+	var match *models.PlagiarismMatch
+	var err error
+	if action == "" {
+		match, err = s.plagiarismSvc.Check(ctx, submission)
 		if err != nil {
 			log.Printf("Plagiarism check failed: %v", err)
 		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 	}
 
-	grade, feedback, err := s.openaiSvc.AnalyzeCode(req.Content, req.FileType)
-	if err != nil {
+	var result *AnalysisResult
+	if action == "" {
+		result, err = s.analysisSvc.Analyze(ctx, submission)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	submission.GradingStatus = models.GradingStatusGraded
+	switch {
+	case action != "":
+		log.Printf("Submission %s queued for grading: %v", submission.ID, quotaErr)
+		result = &AnalysisResult{Feedback: prompts.Message(submission.Locale, prompts.MsgQuotaPending)}
+		submission.GradingStatus = models.GradingStatusPending
+	case resilience.Unavailable(err):
+		log.Printf("OpenAI unavailable, submission %s queued for grading: %v", submission.ID, err)
+		result = &AnalysisResult{Feedback: prompts.Message(submission.Locale, prompts.MsgGradingPending)}
+		submission.GradingStatus = models.GradingStatusPending
+	case err != nil:
 		log.Printf("OpenAI analysis failed: %v", err)
-		grade = 3
-		feedback = "Автоматический анализ недоступен. Код загружен для ручной проверки."
+		scale := s.scales.Resolve(ctx, submission.CourseID, submission.AssignmentID)
+		result = &AnalysisResult{
+			Grade:    scale.Fallback(),
+			Label:    scale.Label(scale.Fallback()),
+			Passed:   scale.Passed(scale.Fallback()),
+			Feedback: prompts.Message(submission.Locale, prompts.MsgAnalysisUnavailable),
+		}
 	}
 
-	if isPlagiarism {
-		grade = 3
-		feedback = fmt.Sprintf("⚠️ ОБНАРУЖЕН ПЛАГИАТ: Данное решение очень похоже на уже существующее.\n\n%s\n\nОригинальная оценка: %s", plagiarismExplanation, feedback)
+	applyAnalysis(submission, result)
+
+	if match != nil {
+		applyPlagiarismPenalty(submission, match, s.scales.Resolve(ctx, submission.CourseID, submission.AssignmentID))
 		log.Printf("Plagiarism detected for submission %s", submission.ID)
 	}
 
-	submission.Grade = grade
-	submission.Feedback = feedback
+	// The model is only asked while the course has budget left.
+	submission.AIDetection = s.aiDetector.Detect(ctx, submission, action == "")
+	if submission.AIDetection != nil {
+		submission.AILikelihood = submission.AIDetection.Likelihood
+	}
+	submission.StyleDeviation = s.styleSvc.Assess(ctx, submission)
 
-	if err := s.repo.Create(submission); err != nil {
+	if err := s.repo.Create(ctx, submission); err != nil {
 		return nil, err
 	}
+	s.styleSvc.Record(ctx, submission)
+
+	annotations := annotationsFor(submission, result)
+	if len(annotations) > 0 {
+		if err := s.annotationRepo.Replace(ctx, submission.ID, annotations); err != nil {
+			log.Printf("Failed to store annotations for submission %s: %v", submission.ID, err)
+		}
+	}
+
+	if err := s.similaritySvc.Index(ctx, submission); err != nil {
+		log.Printf("Failed to index submission %s: %v", submission.ID, err)
+	}
+
+	if match != nil {
+		if err := s.plagiarismSvc.Link(ctx, match); err != nil {
+			log.Printf("Failed to link plagiarism match for submission %s: %v", submission.ID, err)
+		}
+	}
 
 	return &models.SubmissionResponse{
-		ID:       submission.ID,
-		Grade:    submission.Grade,
-		Feedback: submission.Feedback,
+		ID:              submission.ID,
+		Grade:           submission.Grade,
+		GradeLabel:      submission.GradeLabel,
+		Passed:          submission.Passed,
+		Feedback:        submission.Feedback,
+		Annotations:     annotations,
+		GradeConfidence: submission.GradeConfidence,
+		GradeSamples:    submission.GradeSamples,
+		GradingStatus:   submission.GradingStatus,
+		NeedsReview:     submission.NeedsReview,
+		ReviewReasons:   submission.ReviewReasons,
+		Redactions:      submission.Redactions,
+		IsPlagiarism:    submission.IsPlagiarism,
+		PlagiarismMatch: match,
+		Metrics:         submission.Metrics,
 	}, nil
 }
 
-func (s *submissionService) GetSubmission(id string) (*models.CodeSubmission, error) {
-	return s.repo.GetByID(id)
+func (s *submissionService) GetSubmission(ctx context.Context, id string) (*models.CodeSubmission, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *submissionService) GetAllSubmissions() ([]models.SubmissionListResponse, error) {
-	submissions, err := s.repo.GetAll()
+func (s *submissionService) GetAllSubmissions(ctx context.Context) ([]models.SubmissionListResponse, error) {
+	submissions, err := s.repo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return s.listResponses(submissions, false), nil
+}
 
+// GetReviewQueue lists the submissions marked for review and those whose
+// advisory AI likelihood reaches the configured threshold.
+func (s *submissionService) GetReviewQueue(ctx context.Context) ([]models.SubmissionListResponse, error) {
+	submissions, err := s.repo.GetForReview(ctx, s.aiDetector.Threshold())
+	if err != nil {
+		return nil, err
+	}
+	return s.listResponses(submissions, true), nil
+}
+
+// listResponses summarizes submissions. The advisory AI likelihood is only
+// filled in for the teachers' review queue.
+func (s *submissionService) listResponses(submissions []models.CodeSubmission, review bool) []models.SubmissionListResponse {
 	result := make([]models.SubmissionListResponse, len(submissions))
 	for i, sub := range submissions {
 		result[i] = models.SubmissionListResponse{
-			ID:        sub.ID,
-			FileName:  sub.FileName,
-			FileType:  sub.FileType,
-			Grade:     sub.Grade,
-			CreatedAt: sub.CreatedAt,
+			ID:                 sub.ID,
+			FileName:           sub.FileName,
+			FileType:           sub.FileType,
+			CourseID:           sub.CourseID,
+			AssignmentID:       sub.AssignmentID,
+			StudentID:          sub.StudentID,
+			Grade:              sub.Grade,
+			GradeLabel:         sub.GradeLabel,
+			Passed:             sub.Passed,
+			GradingStatus:      sub.GradingStatus,
+			NeedsReview:        sub.NeedsReview,
+			PlagiarismInvolved: sub.PlagiarismInvolved,
+			CreatedAt:          sub.CreatedAt,
+		}
+		if review {
+			result[i].AILikelihood = sub.AILikelihood
+			result[i].AIFlagged = s.aiDetector.Flagged(sub.AILikelihood)
 		}
 	}
+	return result
+}
 
-	return result, nil
+func (s *submissionService) GetAnnotations(ctx context.Context, id string) ([]models.Annotation, error) {
+	return s.annotationRepo.GetBySubmissionID(ctx, id)
 }
 
-func (s *submissionService) DeleteSubmission(id string) error {
-	return s.repo.Delete(id)
+func (s *submissionService) DeleteSubmission(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
 }
 
 func contains(slice []string, item string) bool {
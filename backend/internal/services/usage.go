@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+const (
+	operationAnalysis      = "analysis"
+	operationAnalysisChunk = "analysis_chunk"
+	operationPlagiarism    = "plagiarism"
+	operationAIDetection   = "ai_detection"
+	operationPractice      = "practice"
+	operationConversation  = "conversation"
+)
+
+var ErrQuotaExceeded = errors.New("course LLM budget is exhausted")
+
+// QuotaError tells the caller what to do with a submission of a course whose
+// monthly budget has been spent.
+type QuotaError struct {
+	CourseID string
+	Spent    float64
+	Budget   float64
+	Action   string
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v: course %s spent $%.2f of $%.2f", ErrQuotaExceeded, e.CourseID, e.Spent, e.Budget)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// UsageRecorder receives token usage of every completed LLM call.
+type UsageRecorder interface {
+	Record(ctx context.Context, operation, model string, promptTokens, completionTokens int)
+}
+
+type UsageService interface {
+	UsageRecorder
+	Summary(ctx context.Context, filter models.UsageFilter, groupBy string) ([]models.UsageSummary, error)
+	CheckQuota(ctx context.Context, courseID string) error
+}
+
+// modelPrices are USD per million prompt and completion tokens.
+var modelPrices = map[string][2]float64{
+	"gpt-4o-mini":  {0.15, 0.60},
+	"gpt-4o":       {2.50, 10.00},
+	"gpt-4.1-mini": {0.40, 1.60},
+	"gpt-4.1":      {2.00, 8.00},
+}
+
+type usageService struct {
+	usageRepo  repositories.UsageRepository
+	courseRepo repositories.CourseRepository
+	cfg        config.UsageConfig
+}
+
+func NewUsageService(usageRepo repositories.UsageRepository, courseRepo repositories.CourseRepository, cfg config.UsageConfig) UsageService {
+	return &usageService{
+		usageRepo:  usageRepo,
+		courseRepo: courseRepo,
+		cfg:        cfg,
+	}
+}
+
+// Record stores the call with the submission found in ctx. Failures are only
+// logged: losing an accounting row must not fail grading.
+func (s *usageService) Record(ctx context.Context, operation, model string, promptTokens, completionTokens int) {
+	usage := &models.LLMUsage{
+		Operation:        operation,
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		CostUSD:          s.cost(model, promptTokens, completionTokens),
+	}
+	if scope, ok := ctx.Value(usageScopeKey{}).(usageScope); ok {
+		usage.SubmissionID = scope.submissionID
+		usage.CourseID = scope.courseID
+		usage.AssignmentID = scope.assignmentID
+		usage.StudentID = scope.studentID
+	}
+
+	if err := s.usageRepo.Create(context.WithoutCancel(ctx), usage); err != nil {
+		log.Printf("Failed to record LLM usage: %v", err)
+	}
+}
+
+func (s *usageService) Summary(ctx context.Context, filter models.UsageFilter, groupBy string) ([]models.UsageSummary, error) {
+	return s.usageRepo.Summarize(ctx, filter, groupBy)
+}
+
+// CheckQuota returns a *QuotaError once the course has spent its budget for
+// the current calendar month (UTC).
+func (s *usageService) CheckQuota(ctx context.Context, courseID string) error {
+	if courseID == "" {
+		return nil
+	}
+
+	budget, action := s.cfg.DefaultMonthlyBudget, s.cfg.DefaultQuotaAction
+	course, err := s.courseRepo.GetByID(ctx, courseID)
+	switch {
+	case err == nil:
+		budget, action = course.MonthlyBudgetUSD, course.QuotaAction
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("failed to load course %s: %w", courseID, err)
+	}
+	if budget <= 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	spent, err := s.usageRepo.CostSince(ctx, courseID, monthStart)
+	if err != nil {
+		return fmt.Errorf("failed to get course spending: %w", err)
+	}
+	if spent < budget {
+		return nil
+	}
+
+	return &QuotaError{CourseID: courseID, Spent: spent, Budget: budget, Action: action}
+}
+
+func (s *usageService) cost(model string, promptTokens, completionTokens int) float64 {
+	prices := modelPrices[model]
+	for name, p := range modelPrices {
+		// Dated snapshots such as gpt-4o-mini-2024-07-18 cost the same as the alias.
+		if prices == [2]float64{} && strings.HasPrefix(model, name+"-20") {
+			prices = p
+		}
+	}
+	if s.cfg.PromptPricePer1M > 0 {
+		prices[0] = s.cfg.PromptPricePer1M
+	}
+	if s.cfg.CompletionPricePer1M > 0 {
+		prices[1] = s.cfg.CompletionPricePer1M
+	}
+
+	return (float64(promptTokens)*prices[0] + float64(completionTokens)*prices[1]) / 1e6
+}
+
+type usageScopeKey struct{}
+
+type usageScope struct {
+	submissionID string
+	courseID     string
+	assignmentID string
+	studentID    string
+}
+
+// withUsageScope attributes the LLM calls made with the returned context to
+// the given submission.
+func withUsageScope(ctx context.Context, submission *models.CodeSubmission) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, usageScope{
+		submissionID: submission.ID,
+		courseID:     submission.CourseID,
+		assignmentID: submission.AssignmentID,
+		studentID:    submission.StudentID,
+	})
+}
+
+// quotaAction returns the action required by a CheckQuota error, or "" when
+// grading may proceed. Errors other than an exhausted quota do not block.
+func quotaAction(err error) string {
+	var quota *QuotaError
+	if errors.As(err, &quota) {
+		if quota.Action == models.QuotaActionReject {
+			return models.QuotaActionReject
+		}
+		return models.QuotaActionQueue
+	}
+	if err != nil {
+		log.Printf("Quota check failed: %v", err)
+	}
+	return ""
+}
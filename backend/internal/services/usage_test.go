@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"codegrader-backend/internal/config"
+	"codegrader-backend/internal/models"
+	"codegrader-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type courseStub struct {
+	repositories.CourseRepository
+	courses map[string]*models.Course
+}
+
+func (r *courseStub) GetByID(ctx context.Context, id string) (*models.Course, error) {
+	course, ok := r.courses[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return course, nil
+}
+
+type spendingStub struct {
+	repositories.UsageRepository
+	spent float64
+	since time.Time
+}
+
+func (r *spendingStub) CostSince(ctx context.Context, courseID string, since time.Time) (float64, error) {
+	r.since = since
+	return r.spent, nil
+}
+
+func TestUsageCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		cfg      config.UsageConfig
+		expected float64
+	}{
+		{"alias", "gpt-4o-mini", config.UsageConfig{}, 0.15 + 0.60},
+		{"dated snapshot", "gpt-4o-mini-2024-07-18", config.UsageConfig{}, 0.15 + 0.60},
+		{"snapshot of the longer name", "gpt-4o-2024-08-06", config.UsageConfig{}, 2.50 + 10.00},
+		{"unknown model", "llama-3-70b", config.UsageConfig{}, 0},
+		{"configured prices", "llama-3-70b", config.UsageConfig{PromptPricePer1M: 1, CompletionPricePer1M: 2}, 1 + 2},
+		{"configured prompt price only", "gpt-4.1", config.UsageConfig{PromptPricePer1M: 1}, 1 + 8.00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &usageService{cfg: tt.cfg}
+			assert.InDelta(t, tt.expected, s.cost(tt.model, 1_000_000, 1_000_000), 1e-9)
+		})
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	courses := &courseStub{courses: map[string]*models.Course{
+		"queued":   {ID: "queued", MonthlyBudgetUSD: 10, QuotaAction: models.QuotaActionQueue},
+		"rejected": {ID: "rejected", MonthlyBudgetUSD: 10, QuotaAction: models.QuotaActionReject},
+		"free":     {ID: "free"},
+	}}
+
+	tests := []struct {
+		name     string
+		courseID string
+		spent    float64
+		cfg      config.UsageConfig
+		action   string
+	}{
+		{"within budget", "queued", 9.99, config.UsageConfig{}, ""},
+		{"exhausted, queue", "queued", 10, config.UsageConfig{}, models.QuotaActionQueue},
+		{"exhausted, reject", "rejected", 12, config.UsageConfig{}, models.QuotaActionReject},
+		{"no course budget", "free", 1000, config.UsageConfig{DefaultMonthlyBudget: 1}, ""},
+		{"no budget configured", "unknown", 1000, config.UsageConfig{}, ""},
+		{"default budget", "unknown", 5, config.UsageConfig{DefaultMonthlyBudget: 5, DefaultQuotaAction: models.QuotaActionReject}, models.QuotaActionReject},
+		{"no course", "", 1000, config.UsageConfig{DefaultMonthlyBudget: 1}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := &spendingStub{spent: tt.spent}
+			s := NewUsageService(usage, courses, tt.cfg)
+
+			err := s.CheckQuota(context.Background(), tt.courseID)
+			assert.Equal(t, tt.action, quotaAction(err))
+			if tt.action == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var quota *QuotaError
+			require.ErrorAs(t, err, &quota)
+			assert.ErrorIs(t, err, ErrQuotaExceeded)
+			assert.Equal(t, tt.spent, quota.Spent)
+			assert.Equal(t, 1, usage.since.Day())
+		})
+	}
+}
+
+func TestQuotaAction_IgnoresOtherErrors(t *testing.T) {
+	assert.Empty(t, quotaAction(nil))
+	assert.Empty(t, quotaAction(errors.New("connection refused")))
+	assert.Equal(t, models.QuotaActionQueue, quotaAction(&QuotaError{}))
+}
@@ -0,0 +1,80 @@
+package similarity
+
+import (
+	"hash/fnv"
+)
+
+const (
+	kGramSize     = 5
+	winnowingSize = 4
+)
+
+// Fingerprint is a set of winnowed k-gram hashes of a token stream.
+type Fingerprint map[uint64]struct{}
+
+func NewFingerprint(tokens []string) Fingerprint {
+	fp := make(Fingerprint)
+	if len(tokens) == 0 {
+		return fp
+	}
+
+	if len(tokens) < kGramSize {
+		fp[hashTokens(tokens)] = struct{}{}
+		return fp
+	}
+
+	hashes := make([]uint64, 0, len(tokens)-kGramSize+1)
+	for i := 0; i+kGramSize <= len(tokens); i++ {
+		hashes = append(hashes, hashTokens(tokens[i:i+kGramSize]))
+	}
+
+	if len(hashes) <= winnowingSize {
+		for _, h := range hashes {
+			fp[h] = struct{}{}
+		}
+		return fp
+	}
+
+	for i := 0; i+winnowingSize <= len(hashes); i++ {
+		minHash := hashes[i]
+		for _, h := range hashes[i+1 : i+winnowingSize] {
+			if h < minHash {
+				minHash = h
+			}
+		}
+		fp[minHash] = struct{}{}
+	}
+
+	return fp
+}
+
+// TokenSimilarity returns the Jaccard similarity of the fingerprints of two
+// pieces of code written in the same language.
+func TokenSimilarity(a, b, fileType string) float64 {
+	return Jaccard(NewFingerprint(Tokenize(a, fileType)), NewFingerprint(Tokenize(b, fileType)))
+}
+
+func Jaccard(a, b Fingerprint) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for h := range a {
+		if _, ok := b[h]; ok {
+			intersection++
+		}
+	}
+
+	union := len(a) + len(b) - intersection
+	return float64(intersection) / float64(union)
+}
+
+func hashTokens(tokens []string) uint64 {
+	h := fnv.New64a()
+	for _, t := range tokens {
+		_, _ = h.Write([]byte(t))
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
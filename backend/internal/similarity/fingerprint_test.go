@@ -0,0 +1,68 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize_SkipsCommentsAndWhitespace(t *testing.T) {
+	code := "x = 1  # counter\ny = 'a # b'\n"
+
+	tokens := Tokenize(code, ".py")
+
+	assert.Equal(t, []string{"x", "=", "1", "y", "=", "'a # b'"}, tokens)
+}
+
+func TestTokenSimilarity_IgnoresFormatting(t *testing.T) {
+	original := "int sum(int a, int b) {\n    // add\n    return a + b;\n}\n"
+	reformatted := "int sum(int a,int b){return a+b; /* same */}"
+
+	assert.Equal(t, 1.0, TokenSimilarity(original, reformatted, ".cpp"))
+}
+
+func TestTokenSimilarity_DifferentCode(t *testing.T) {
+	a := "def add(a, b):\n    return a + b\n"
+	b := "for i in range(10):\n    print(i * i, end=' ')\n"
+
+	assert.Less(t, TokenSimilarity(a, b, ".py"), 0.2)
+}
+
+func TestScan_TracksLinesAcrossComments(t *testing.T) {
+	code := "a = 1\n/* one\ntwo */ b = 2\n  c = \"x\"\n"
+
+	tokens := Scan(code, ".js")
+
+	assert.Equal(t, Token{Text: "b", Line: 3, Column: 7}, tokens[3])
+	assert.Equal(t, Token{Text: "c", Line: 4, Column: 2}, tokens[6])
+}
+
+func TestContentHash_IgnoresFormattingAndComments(t *testing.T) {
+	a := "int main() {\n    // entry point\n    return 0;\n}\n"
+	b := "int main(){ return 0; } /* same */"
+	c := "int main() { return 1; }"
+
+	assert.Equal(t, ContentHash(a, ".c"), ContentHash(b, ".c"))
+	assert.NotEqual(t, ContentHash(a, ".c"), ContentHash(c, ".c"))
+}
+
+func TestContentHash_KeepsPythonIndentation(t *testing.T) {
+	inside := "for x in xs:\n    total += x\n    print(total)\n"
+	outside := "for x in xs:\n    total += x\nprint(total)\n"
+
+	assert.NotEqual(t, ContentHash(inside, ".py"), ContentHash(outside, ".py"))
+}
+
+func TestPythonLogicalLines_JoinsContinuations(t *testing.T) {
+	code := "x = (1,\n     2)\ny = 3 + \\\n    4\n\\\n"
+
+	lines := PythonLogicalLines(Scan(code, ".py"))
+
+	texts := make([][]string, len(lines))
+	for i, line := range lines {
+		for _, tok := range line {
+			texts[i] = append(texts[i], tok.Text)
+		}
+	}
+	assert.Equal(t, [][]string{{"x", "=", "(", "1", ",", "2", ")"}, {"y", "=", "3", "+", "4"}}, texts)
+}
@@ -0,0 +1,24 @@
+package similarity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// ContentHash identifies code up to formatting and comments: two files that
+// differ only in whitespace or comments hash to the same value. Python keeps
+// its indentation, which is part of the program there.
+func ContentHash(code, fileType string) string {
+	h := sha256.New()
+	line := 0
+	for _, t := range Scan(code, fileType) {
+		if fileType == ".py" && t.Line != line {
+			line = t.Line
+			h.Write([]byte("\n" + strconv.Itoa(t.Column) + ":"))
+		}
+		h.Write([]byte(t.Text))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,31 @@
+package similarity
+
+// PythonLogicalLines groups Python tokens into logical lines: physical lines
+// joined inside brackets and after a backslash. Backslashes are dropped and
+// never start a line, so every line has at least one token.
+func PythonLogicalLines(tokens []Token) [][]Token {
+	var lines [][]Token
+	depth := 0
+	continued := false
+	lastLine := 0
+	for _, t := range tokens {
+		if t.Text == "\\" {
+			continued = true
+			continue
+		}
+		if len(lines) == 0 || t.Line > lastLine && depth == 0 && !continued {
+			lines = append(lines, nil)
+		}
+		continued = false
+		lastLine = t.Line
+
+		switch t.Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth = max(depth-1, 0)
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], t)
+	}
+	return lines
+}
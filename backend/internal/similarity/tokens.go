@@ -0,0 +1,144 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a lexical token with its 1-based line and 0-based column.
+type Token struct {
+	Text   string
+	Line   int
+	Column int
+}
+
+// Tokenize splits source code into lexical tokens, dropping whitespace and
+// comments so that formatting changes do not affect comparison.
+func Tokenize(code, fileType string) []string {
+	scanned := Scan(code, fileType)
+	tokens := make([]string, len(scanned))
+	for i, t := range scanned {
+		tokens[i] = t.Text
+	}
+	return tokens
+}
+
+// Scan is Tokenize with source positions, for consumers that need line
+// structure (indentation, statement boundaries).
+func Scan(code, fileType string) []Token {
+	runes := []rune(code)
+	tokens := make([]Token, 0, len(runes)/3)
+	hashComments := fileType == ".py"
+
+	line, lineStart := 1, 0
+	emit := func(start, end int) {
+		tokens = append(tokens, Token{Text: string(runes[start:end]), Line: line, Column: start - lineStart})
+	}
+	advanceLines := func(from, to int) {
+		for j := from; j < to && j < len(runes); j++ {
+			if runes[j] == '\n' {
+				line++
+				lineStart = j + 1
+			}
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case r == '\n':
+			line++
+			i++
+			lineStart = i
+		case unicode.IsSpace(r):
+			i++
+		case hashComments && r == '#':
+			i = skipLine(runes, i)
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			i = skipLine(runes, i)
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := indexFrom(runes, i+2, "*/")
+			if end < 0 {
+				end = len(runes)
+			} else {
+				end += 2
+			}
+			advanceLines(i, end)
+			i = end
+		case r == '"' || r == '\'' || r == '`':
+			end := scanString(runes, i)
+			emit(i, end)
+			advanceLines(i, end)
+			i = end
+		case isIdentStart(r):
+			start := i
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			emit(start, i)
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.' || runes[i] == '_') {
+				i++
+			}
+			emit(start, i)
+		default:
+			emit(i, i+1)
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func skipLine(runes []rune, i int) int {
+	for i < len(runes) && runes[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+func indexFrom(runes []rune, from int, substr string) int {
+	pattern := []rune(substr)
+	for i := from; i+len(pattern) <= len(runes); i++ {
+		if string(runes[i:i+len(pattern)]) == substr {
+			return i
+		}
+	}
+	return -1
+}
+
+func scanString(runes []rune, start int) int {
+	quote := runes[start]
+
+	if quote != '`' && start+2 < len(runes) && runes[start+1] == quote && runes[start+2] == quote {
+		end := indexFrom(runes, start+3, strings.Repeat(string(quote), 3))
+		if end < 0 {
+			return len(runes)
+		}
+		return end + 3
+	}
+
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		case '\n':
+			if quote != '`' {
+				return i
+			}
+		}
+	}
+	return len(runes)
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
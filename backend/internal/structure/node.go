@@ -0,0 +1,117 @@
+package structure
+
+import (
+	"sort"
+	"strings"
+)
+
+// Node is a language-neutral structural tree: identifiers and literals are
+// dropped, loops of any flavour become "loop", conditionals become
+// "branch", and declarations are ordered canonically.
+type Node struct {
+	Kind     string
+	Children []*Node
+
+	key  string
+	size int
+}
+
+const (
+	KindProgram = "program"
+	KindClass   = "class"
+	KindFunc    = "func"
+	KindLoop    = "loop"
+	KindBranch  = "branch"
+	KindSwitch  = "switch"
+	KindTry     = "try"
+	KindHandler = "handler"
+	KindBlock   = "block"
+)
+
+// String returns the canonical serialized form of the tree.
+func (n *Node) String() string {
+	if n.key == "" {
+		n.canonicalize()
+	}
+	return n.key
+}
+
+func (n *Node) Size() int {
+	if n.key == "" {
+		n.canonicalize()
+	}
+	return n.size
+}
+
+func (n *Node) canonicalize() {
+	for _, child := range n.Children {
+		child.canonicalize()
+	}
+
+	if n.Kind == KindProgram || n.Kind == KindClass {
+		sortDeclarations(n.Children)
+	}
+
+	n.size = 1
+	var b strings.Builder
+	b.WriteString(n.Kind)
+	if len(n.Children) > 0 {
+		b.WriteByte('(')
+		for i, child := range n.Children {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(child.key)
+			n.size += child.size
+		}
+		b.WriteByte(')')
+	}
+	n.key = b.String()
+}
+
+// sortDeclarations moves function and class declarations behind the other
+// statements and orders them by shape, so reordering them has no effect.
+func sortDeclarations(children []*Node) {
+	sort.SliceStable(children, func(i, j int) bool {
+		di, dj := isDeclaration(children[i]), isDeclaration(children[j])
+		if di != dj {
+			return !di
+		}
+		if !di {
+			return false
+		}
+		return children[i].key < children[j].key
+	})
+}
+
+func isDeclaration(n *Node) bool {
+	return n.Kind == KindClass || strings.HasPrefix(n.Kind, KindFunc)
+}
+
+func (n *Node) walk(visit func(*Node)) {
+	visit(n)
+	for _, child := range n.Children {
+		child.walk(visit)
+	}
+}
+
+// Flatten returns a copy of the tree with class wrappers removed, so that a
+// Java solution written as static methods of a Main class compares equal to
+// the same functions declared at top level in Kotlin, C or JavaScript.
+func Flatten(n *Node) *Node {
+	flat := &Node{Kind: n.Kind, Children: flattenChildren(n.Children)}
+	flat.canonicalize()
+	return flat
+}
+
+func flattenChildren(children []*Node) []*Node {
+	result := make([]*Node, 0, len(children))
+	for _, child := range children {
+		if child.Kind == KindClass {
+			result = append(result, flattenChildren(child.Children)...)
+			continue
+		}
+		result = append(result, &Node{Kind: child.Kind, Children: flattenChildren(child.Children)})
+	}
+	return result
+}
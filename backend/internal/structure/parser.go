@@ -0,0 +1,475 @@
+package structure
+
+import (
+	"codegrader-backend/internal/similarity"
+)
+
+// Parse builds the normalized structural tree of a source file. Python is
+// parsed by indentation; C++, Java, JavaScript and Kotlin share a
+// brace-based parser. Parsing is best effort and never fails: unknown
+// constructs degrade to generic statements.
+func Parse(code, fileType string) *Node {
+	tokens := similarity.Scan(code, fileType)
+
+	var body []*Node
+	if fileType == ".py" {
+		body = parsePython(tokens)
+	} else {
+		p := &braceParser{tokens: tokens, fileType: fileType}
+		body = p.parseStatements(false)
+	}
+
+	root := &Node{Kind: KindProgram, Children: body}
+	root.canonicalize()
+	return root
+}
+
+type braceParser struct {
+	tokens   []similarity.Token
+	fileType string
+	pos      int
+}
+
+func (p *braceParser) cur() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].Text
+	}
+	return ""
+}
+
+func (p *braceParser) peek(offset int) string {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset].Text
+	}
+	return ""
+}
+
+func (p *braceParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *braceParser) parseStatements(untilBrace bool) []*Node {
+	var nodes []*Node
+	for !p.done() {
+		if p.cur() == "}" {
+			p.pos++
+			if untilBrace {
+				return nodes
+			}
+			continue
+		}
+		nodes = append(nodes, p.parseStatement()...)
+	}
+	return nodes
+}
+
+// parseBody parses either a braced block or a single statement.
+func (p *braceParser) parseBody() []*Node {
+	if p.cur() == "{" {
+		p.pos++
+		return p.parseStatements(true)
+	}
+	if p.done() || p.cur() == "}" {
+		return nil
+	}
+	return p.parseStatement()
+}
+
+func (p *braceParser) parseStatement() []*Node {
+	p.skipModifiers()
+	if p.done() {
+		return nil
+	}
+
+	switch tok := p.cur(); {
+	case tok == ";":
+		p.pos++
+		return nil
+	case tok == "{":
+		p.pos++
+		return nodes(&Node{Kind: KindBlock, Children: p.parseStatements(true)})
+	case tok == "#":
+		p.skipLine()
+		return nil
+	case tok == "case" || (tok == "default" && p.peek(1) == ":"):
+		p.skipLabel()
+		return nil
+	case tok == "for":
+		return p.parseFor()
+	case tok == "while":
+		p.pos++
+		p.skipGroup()
+		return nodes(&Node{Kind: KindLoop, Children: p.parseBody()})
+	case tok == "do":
+		p.pos++
+		body := p.parseBody()
+		if p.cur() == "while" {
+			p.pos++
+			p.skipGroup()
+		}
+		return nodes(&Node{Kind: KindLoop, Children: body})
+	case tok == "if":
+		return nodes(p.parseIf())
+	case switchKeywords[tok]:
+		p.pos++
+		p.skipGroup()
+		return nodes(&Node{Kind: KindSwitch, Children: p.parseBody()})
+	case tok == "try":
+		return nodes(p.parseTry())
+	case classKeywords[tok] && isIdentifier(p.peek(1)):
+		return nodes(p.parseClass())
+	case tok == "function" || tok == "fun":
+		return nodes(p.parseFunctionKeyword())
+	default:
+		return nodes(p.parseSimple())
+	}
+}
+
+// parseFor desugars a C-style `for (init; cond; update) body` into
+// `init; while (cond) { body; update }` so that both spellings of the same
+// loop produce the same tree.
+func (p *braceParser) parseFor() []*Node {
+	p.pos++
+	if p.cur() != "(" {
+		return nodes(&Node{Kind: KindLoop, Children: p.parseBody()})
+	}
+
+	open := p.pos
+	p.skipGroup()
+	header := p.texts(open+1, p.pos-1)
+	body := p.parseBody()
+
+	parts := splitTopLevel(header, ";")
+	if len(parts) != 3 {
+		return nodes(&Node{Kind: KindLoop, Children: body})
+	}
+
+	if update := leaf(parts[2]); update != nil {
+		body = append(body, update)
+	}
+	return nodes(leaf(parts[0]), &Node{Kind: KindLoop, Children: body})
+}
+
+func (p *braceParser) parseIf() *Node {
+	p.pos++
+	p.skipGroup()
+	node := &Node{Kind: KindBranch, Children: []*Node{{Kind: KindBlock, Children: p.parseBody()}}}
+	if p.cur() == "else" {
+		p.pos++
+		node.Children = append(node.Children, &Node{Kind: KindBlock, Children: p.parseBody()})
+	}
+	return node
+}
+
+func (p *braceParser) parseTry() *Node {
+	p.pos++
+	node := &Node{Kind: KindTry, Children: []*Node{{Kind: KindBlock, Children: p.parseBody()}}}
+	for p.cur() == "catch" || p.cur() == "finally" {
+		p.pos++
+		p.skipGroup()
+		node.Children = append(node.Children, &Node{Kind: KindHandler, Children: p.parseBody()})
+	}
+	return node
+}
+
+func (p *braceParser) parseClass() *Node {
+	for !p.done() && p.cur() != "{" && p.cur() != ";" {
+		p.pos++
+	}
+	if p.cur() != "{" {
+		p.pos++
+		return nil
+	}
+	p.pos++
+	return &Node{Kind: KindClass, Children: p.parseStatements(true)}
+}
+
+// parseFunctionKeyword handles `function name(...) {}` (JavaScript) and
+// `fun name(...): T {}` / `fun name(...) = expr` (Kotlin).
+func (p *braceParser) parseFunctionKeyword() *Node {
+	p.pos++
+	for !p.done() && p.cur() != "(" {
+		p.pos++
+	}
+
+	texts := p.texts(p.pos, len(p.tokens))
+	params := paramCount(texts, 0)
+	p.skipGroup()
+
+	for !p.done() && p.cur() != "{" && p.cur() != "=" && p.cur() != ";" && p.cur() != "}" {
+		if p.pos > 0 && p.tokens[p.pos].Line > p.tokens[p.pos-1].Line && !isContinuation(p.tokens[p.pos-1].Text) {
+			break
+		}
+		p.pos++
+	}
+
+	switch p.cur() {
+	case "{":
+		p.pos++
+		return funcNode(params, p.parseStatements(true))
+	case "=":
+		p.pos++
+		return funcNode(params, nodes(p.parseSimple()))
+	default:
+		return funcNode(params, nil)
+	}
+}
+
+// parseSimple collects a statement up to its terminator. If the statement
+// turns out to be the header of a braced construct (method declaration,
+// arrow function, Kotlin trailing lambda) the body is parsed as well.
+func (p *braceParser) parseSimple() *Node {
+	start := p.pos
+	depth := 0
+
+	for !p.done() {
+		tok := p.cur()
+
+		if depth == 0 {
+			if tok == ";" {
+				p.pos++
+				break
+			}
+			if tok == "}" {
+				break
+			}
+			if tok == "{" {
+				header := p.texts(start, p.pos)
+				if hasAssignment(header) && !isArrow(header) {
+					p.skipBraces()
+					if !p.done() && p.tokens[p.pos].Line > p.tokens[p.pos-1].Line && !startsContinuation(p.cur()) {
+						break
+					}
+					continue
+				}
+				p.pos++
+				body := p.parseStatements(true)
+				return p.headerNode(header, body)
+			}
+		}
+
+		switch tok {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth > 0 {
+				depth--
+			}
+		}
+		if depth == 0 && p.endsLine() {
+			p.pos++
+			break
+		}
+		p.pos++
+	}
+
+	return leaf(p.texts(start, p.pos))
+}
+
+func (p *braceParser) headerNode(header []string, body []*Node) *Node {
+	if isArrow(header) {
+		params := 1
+		if len(header) >= 3 && header[len(header)-3] == ")" {
+			params = paramCount(header, openingParen(header, len(header)-3))
+		}
+		return funcNode(params, body)
+	}
+
+	// Kotlin declares every function with `fun`; a header ending in a
+	// parenthesised list there is a call with a trailing lambda.
+	if p.fileType != ".kt" {
+		if open := declarationParen(header); open >= 0 {
+			return funcNode(paramCount(header, open), body)
+		}
+	}
+
+	children := body
+	if node := leaf(header); node != nil {
+		children = append([]*Node{node}, body...)
+	}
+	return &Node{Kind: KindBlock, Children: children}
+}
+
+// endsLine reports whether the current token is the last one of a
+// statement written without a semicolon.
+func (p *braceParser) endsLine() bool {
+	if p.pos+1 >= len(p.tokens) {
+		return true
+	}
+	cur, next := p.tokens[p.pos], p.tokens[p.pos+1]
+	return next.Line > cur.Line && !isContinuation(cur.Text) && !startsContinuation(next.Text)
+}
+
+func (p *braceParser) skipModifiers() {
+	for !p.done() {
+		tok := p.cur()
+		switch {
+		case modifierKeywords[tok]:
+			p.pos++
+		case tok == "@" && isIdentifier(p.peek(1)):
+			p.pos += 2
+			for p.cur() == "." && isIdentifier(p.peek(1)) {
+				p.pos += 2
+			}
+			if p.cur() == "(" {
+				p.skipGroup()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *braceParser) skipGroup() {
+	if p.cur() != "(" {
+		return
+	}
+	depth := 0
+	for !p.done() {
+		switch p.cur() {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				p.pos++
+				return
+			}
+		}
+		p.pos++
+	}
+}
+
+func (p *braceParser) skipBraces() {
+	depth := 0
+	for !p.done() {
+		switch p.cur() {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				p.pos++
+				return
+			}
+		}
+		p.pos++
+	}
+}
+
+func (p *braceParser) skipLine() {
+	line := p.tokens[p.pos].Line
+	for !p.done() && p.tokens[p.pos].Line == line {
+		p.pos++
+	}
+}
+
+func (p *braceParser) skipLabel() {
+	for !p.done() && p.cur() != ":" {
+		p.pos++
+	}
+	p.pos++
+}
+
+func (p *braceParser) texts(from, to int) []string {
+	texts := make([]string, 0, to-from)
+	for _, t := range p.tokens[from:to] {
+		texts = append(texts, t.Text)
+	}
+	return texts
+}
+
+func isArrow(header []string) bool {
+	n := len(header)
+	return n >= 2 && header[n-2] == "=" && header[n-1] == ">"
+}
+
+// declarationParen finds the parameter list of a method or function
+// declaration header such as `int sum(int a, int b) const`. It returns -1
+// for calls with a trailing block, anonymous classes and control
+// statements.
+func declarationParen(header []string) int {
+	if len(header) < 3 || controlKeywords[header[0]] {
+		return -1
+	}
+	for i := 1; i < len(header); i++ {
+		if header[i] != "(" {
+			continue
+		}
+		name := header[i-1]
+		if !isIdentifier(name) || controlKeywords[name] {
+			return -1
+		}
+		if i >= 2 && (header[i-2] == "." || header[i-2] == "new") {
+			return -1
+		}
+		if closing := matchingParen(header, i); closing < 0 || closing == len(header)-1 || isIdentifier(header[closing+1]) || header[closing+1] == ":" {
+			return i
+		}
+		return -1
+	}
+	return -1
+}
+
+func matchingParen(tokens []string, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func openingParen(tokens []string, closing int) int {
+	depth := 0
+	for i := closing; i >= 0; i-- {
+		switch tokens[i] {
+		case ")":
+			depth++
+		case "(":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// nodes drops nil entries so that statements which produce nothing (imports,
+// empty statements) can be returned uniformly.
+func nodes(list ...*Node) []*Node {
+	result := list[:0]
+	for _, n := range list {
+		if n != nil {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func splitTopLevel(tokens []string, sep string) [][]string {
+	var parts [][]string
+	depth, start := 0, 0
+	for i, t := range tokens {
+		switch t {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[start:])
+}
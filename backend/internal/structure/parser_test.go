@@ -0,0 +1,102 @@
+package structure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_IgnoresNamesAndFunctionOrder(t *testing.T) {
+	original := `
+def total(values):
+    result = 0
+    for v in values:
+        result += v
+    return result
+
+def main():
+    print(total([1, 2, 3]))
+`
+	refactored := `
+def main():
+    print(summa([1, 2, 3]))
+
+def summa(items):
+    acc = 0
+    for item in items:
+        acc += item
+    return acc
+`
+
+	assert.Equal(t, Parse(original, ".py").String(), Parse(refactored, ".py").String())
+}
+
+func TestParse_ForAndWhileAreBothLoops(t *testing.T) {
+	withFor := "int sum(int n) {\n  int s = 0;\n  for (int i = 0; i < n; i++) {\n    s += i;\n  }\n  return s;\n}\n"
+	withWhile := "int sum(int n) {\n  int s = 0;\n  int i = 0;\n  while (i < n) {\n    s += i;\n    i++;\n  }\n  return s;\n}\n"
+	unrelated := "int main() {\n  std::string name;\n  std::cin >> name;\n  if (name.empty()) {\n    return 1;\n  }\n  std::cout << name;\n  return 0;\n}\n"
+
+	similar := Compare(withFor, ".cpp", withWhile, ".cpp")
+	different := Compare(withFor, ".cpp", unrelated, ".cpp")
+
+	assert.Greater(t, similar, 0.5)
+	assert.Greater(t, similar, different)
+}
+
+func TestParse_BraceLanguages(t *testing.T) {
+	tests := []struct {
+		fileType string
+		code     string
+		expected string
+	}{
+		{".java", "class A {\n  int twice(int x) { return x * 2; }\n}\n", "program(class(func/1(return[*])))"},
+		{".js", "const twice = (x) => {\n  return x * 2\n}\n", "program(func/1(return[*]))"},
+		{".kt", "fun twice(x: Int): Int {\n    return x * 2\n}\n", "program(func/1(return[*]))"},
+		{".cpp", "#include <cstdio>\nint twice(int x)\n{\n    return x * 2;\n}\n", "program(func/1(return[*]))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileType, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Parse(tt.code, tt.fileType).String())
+		})
+	}
+}
+
+func TestFlatten_ComparesAcrossLanguages(t *testing.T) {
+	java := `
+public class Main {
+    static int sum(int[] values) {
+        int total = 0;
+        for (int i = 0; i < values.length; i++) {
+            total += values[i];
+        }
+        return total;
+    }
+}
+`
+	kotlin := `
+fun sum(values: IntArray): Int {
+    var total = 0
+    var i = 0
+    while (i < values.size) {
+        total += values[i]
+        i++
+    }
+    return total
+}
+`
+
+	a := Flatten(Parse(java, ".java"))
+	b := Flatten(Parse(kotlin, ".kt"))
+
+	assert.Equal(t, a.String(), b.String())
+}
+
+func TestParse_PythonBackslashes(t *testing.T) {
+	for _, code := range []string{"print(1)\n\\", "\\", "\\\nprint(1)\n"} {
+		assert.NotPanics(t, func() { Parse(code, ".py") }, "%q", code)
+	}
+
+	joined := "total = 1 + \\\n    2\nprint(total)\n"
+	assert.Equal(t, 1.0, Compare(joined, ".py", "total = 1 + 2\nprint(total)\n", ".py"))
+}
@@ -0,0 +1,166 @@
+package structure
+
+import (
+	"codegrader-backend/internal/similarity"
+)
+
+type pythonLine struct {
+	indent int
+	tokens []string
+}
+
+var pythonCompound = set("def", "class", "for", "while", "if", "elif", "else", "try", "except", "finally", "with")
+
+func parsePython(tokens []similarity.Token) []*Node {
+	lines := pythonLogicalLines(tokens)
+	nodes, _ := parsePythonBlock(lines, 0, -1)
+	return nodes
+}
+
+// pythonLogicalLines converts the logical lines of package similarity into
+// indentation and token texts.
+func pythonLogicalLines(tokens []similarity.Token) []pythonLine {
+	var lines []pythonLine
+	for _, line := range similarity.PythonLogicalLines(tokens) {
+		texts := make([]string, len(line))
+		for i, t := range line {
+			texts[i] = t.Text
+		}
+		lines = append(lines, pythonLine{indent: line[0].Column, tokens: texts})
+	}
+	return lines
+}
+
+// parsePythonBlock parses consecutive lines indented deeper than parent.
+func parsePythonBlock(lines []pythonLine, i, parent int) ([]*Node, int) {
+	var nodes []*Node
+	var lastBranch, lastTry *Node
+
+	blockIndent := -1
+	for i < len(lines) {
+		line := lines[i]
+		if line.indent <= parent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent > blockIndent {
+			// Stray deeper indentation; treat as part of the current block.
+			line.indent = blockIndent
+		}
+
+		toks := line.tokens
+		if len(toks) == 0 {
+			i++
+			continue
+		}
+		if toks[0] == "@" {
+			i++
+			continue
+		}
+		if toks[0] == "async" && len(toks) > 1 {
+			toks = toks[1:]
+		}
+
+		keyword := toks[0]
+		colon := headerColon(toks)
+		if !pythonCompound[keyword] || colon < 0 {
+			if node := leaf(toks); node != nil {
+				nodes = append(nodes, node)
+			}
+			lastBranch, lastTry = nil, nil
+			i++
+			continue
+		}
+
+		var body []*Node
+		if colon < len(toks)-1 {
+			if node := leaf(toks[colon+1:]); node != nil {
+				body = append(body, node)
+			}
+			i++
+		} else {
+			body, i = parsePythonBlock(lines, i+1, line.indent)
+		}
+
+		switch keyword {
+		case "def":
+			open := indexOf(toks, "(")
+			params := 0
+			if open >= 0 {
+				params = paramCount(toks, open)
+			}
+			nodes = append(nodes, funcNode(params, body))
+		case "class":
+			nodes = append(nodes, &Node{Kind: KindClass, Children: body})
+		case "for", "while":
+			nodes = append(nodes, &Node{Kind: KindLoop, Children: body})
+		case "if":
+			lastBranch = &Node{Kind: KindBranch, Children: []*Node{{Kind: KindBlock, Children: body}}}
+			lastTry = nil
+			nodes = append(nodes, lastBranch)
+			continue
+		case "elif":
+			branch := &Node{Kind: KindBranch, Children: []*Node{{Kind: KindBlock, Children: body}}}
+			if lastBranch != nil {
+				lastBranch.Children = append(lastBranch.Children, &Node{Kind: KindBlock, Children: []*Node{branch}})
+			} else {
+				nodes = append(nodes, branch)
+			}
+			lastBranch = branch
+			continue
+		case "else":
+			if lastBranch != nil {
+				lastBranch.Children = append(lastBranch.Children, &Node{Kind: KindBlock, Children: body})
+			} else {
+				nodes = append(nodes, &Node{Kind: KindBlock, Children: body})
+			}
+		case "try":
+			lastTry = &Node{Kind: KindTry, Children: []*Node{{Kind: KindBlock, Children: body}}}
+			lastBranch = nil
+			nodes = append(nodes, lastTry)
+			continue
+		case "except", "finally":
+			if lastTry != nil {
+				lastTry.Children = append(lastTry.Children, &Node{Kind: KindHandler, Children: body})
+				continue
+			}
+			nodes = append(nodes, &Node{Kind: KindHandler, Children: body})
+		default:
+			nodes = append(nodes, &Node{Kind: KindBlock, Children: body})
+		}
+		lastBranch, lastTry = nil, nil
+	}
+
+	return nodes, i
+}
+
+// headerColon returns the index of the colon that ends a compound
+// statement header, ignoring colons nested in brackets (slices, dicts,
+// lambdas in arguments).
+func headerColon(tokens []string) int {
+	depth := 0
+	for i, t := range tokens {
+		switch t {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ":":
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func indexOf(tokens []string, s string) int {
+	for i, t := range tokens {
+		if t == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,106 @@
+package structure
+
+import (
+	"strings"
+)
+
+const sequenceGramSize = 4
+
+// Similarity averages two views of the trees: shared subtrees weighted by
+// size, which rewards whole functions or loops that match exactly, and
+// shared k-grams of the pre-order traversal, which tolerates local edits.
+// Identical trees score 1.
+func Similarity(a, b *Node) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	return (subtreeSimilarity(a, b) + sequenceSimilarity(a, b)) / 2
+}
+
+func subtreeSimilarity(a, b *Node) float64 {
+	countsA, sizes := subtreeCounts(a)
+	countsB, sizesB := subtreeCounts(b)
+	for key, size := range sizesB {
+		sizes[key] = size
+	}
+
+	var common, total int
+	for key, n := range countsA {
+		total += n * sizes[key]
+		if m, ok := countsB[key]; ok {
+			common += min(n, m) * sizes[key]
+		}
+	}
+	for key, m := range countsB {
+		total += m * sizes[key]
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+// Compare parses both pieces of code and returns their structural
+// similarity. The file types may differ.
+func Compare(codeA, fileTypeA, codeB, fileTypeB string) float64 {
+	return Similarity(Parse(codeA, fileTypeA), Parse(codeB, fileTypeB))
+}
+
+func subtreeCounts(root *Node) (map[string]int, map[string]int) {
+	if root.key == "" {
+		root.canonicalize()
+	}
+
+	counts := make(map[string]int)
+	sizes := make(map[string]int)
+	root.walk(func(n *Node) {
+		counts[n.key]++
+		sizes[n.key] = n.size
+	})
+	return counts, sizes
+}
+
+func sequenceSimilarity(a, b *Node) float64 {
+	gramsA := preorderGrams(a)
+	gramsB := preorderGrams(b)
+
+	var common, total int
+	for gram, n := range gramsA {
+		total += n
+		common += min(n, gramsB[gram])
+	}
+	for _, m := range gramsB {
+		total += m
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+func preorderGrams(root *Node) map[string]int {
+	var sequence []string
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		sequence = append(sequence, n.Kind)
+		if len(n.Children) > 0 {
+			for _, child := range n.Children {
+				visit(child)
+			}
+			sequence = append(sequence, ")")
+		}
+	}
+	visit(root)
+
+	grams := make(map[string]int)
+	if len(sequence) < sequenceGramSize {
+		grams[strings.Join(sequence, " ")]++
+		return grams
+	}
+	for i := 0; i+sequenceGramSize <= len(sequence); i++ {
+		grams[strings.Join(sequence[i:i+sequenceGramSize], " ")]++
+	}
+	return grams
+}
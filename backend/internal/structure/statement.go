@@ -0,0 +1,165 @@
+package structure
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	loopKeywords     = set("for", "while", "do")
+	classKeywords    = set("class", "struct", "interface", "enum", "object", "union")
+	switchKeywords   = set("switch", "when", "match")
+	skippedKeywords  = set("import", "package", "using", "include", "from", "pass")
+	controlKeywords  = set("if", "else", "for", "while", "do", "switch", "when", "try", "catch", "finally", "return", "throw", "new")
+	modifierKeywords = set(
+		"public", "private", "protected", "internal", "static", "final", "abstract", "override", "open",
+		"virtual", "inline", "extern", "export", "async", "suspend", "data", "sealed", "const", "synchronized",
+		"lateinit", "operator", "infix", "tailrec", "constexpr", "explicit", "friend",
+	)
+)
+
+const operatorChars = "+-*/%<>!&|^~?"
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// leaf classifies a simple statement by what it does rather than by what it
+// names: return, jump, throw, assign, call or expr, suffixed with the set of
+// operators it uses.
+func leaf(tokens []string) *Node {
+	if len(tokens) == 0 || skippedKeywords[tokens[0]] {
+		return nil
+	}
+
+	var kind string
+	switch tokens[0] {
+	case "return", "yield":
+		kind = "return"
+	case "break", "continue":
+		kind = "jump"
+	case "throw", "raise":
+		kind = "throw"
+	default:
+		switch {
+		case hasAssignment(tokens):
+			kind = "assign"
+		case hasCall(tokens):
+			kind = "call"
+		default:
+			kind = "expr"
+		}
+	}
+
+	if ops := operators(tokens); ops != "" {
+		kind += "[" + ops + "]"
+	}
+	return &Node{Kind: kind}
+}
+
+func hasAssignment(tokens []string) bool {
+	for i, t := range tokens {
+		switch t {
+		case "=":
+			prevOK := i == 0 || !strings.Contains("=!<>", tokens[i-1])
+			nextOK := i+1 == len(tokens) || (tokens[i+1] != "=" && tokens[i+1] != ">")
+			if prevOK && nextOK {
+				return true
+			}
+		case "+", "-":
+			if i+1 < len(tokens) && tokens[i+1] == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasCall(tokens []string) bool {
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i+1] == "(" && isIdentifier(tokens[i]) && !controlKeywords[tokens[i]] {
+			return true
+		}
+	}
+	return false
+}
+
+func operators(tokens []string) string {
+	seen := make(map[rune]bool)
+	for _, t := range tokens {
+		if len(t) == 1 && strings.ContainsRune(operatorChars, rune(t[0])) {
+			seen[rune(t[0])] = true
+		}
+	}
+
+	ops := make([]string, 0, len(seen))
+	for r := range seen {
+		ops = append(ops, string(r))
+	}
+	sort.Strings(ops)
+	return strings.Join(ops, "")
+}
+
+func isIdentifier(token string) bool {
+	if token == "" {
+		return false
+	}
+	r := []rune(token)[0]
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isContinuation(token string) bool {
+	switch token {
+	case "=", "+", "-", "*", "/", "%", "&", "|", "^", "<", ",", "(", "[", ".", "?", ":", "!", "~", "\\":
+		return true
+	}
+	return false
+}
+
+func startsContinuation(token string) bool {
+	switch token {
+	case ".", "?", ":", "+", "-", "*", "/", "%", "&", "|", "^", "=", ")", "]", ",", "{":
+		return true
+	}
+	return false
+}
+
+// paramCount counts top-level parameters between an opening parenthesis at
+// tokens[open] and its matching close.
+func paramCount(tokens []string, open int) int {
+	depth, count, empty := 0, 1, true
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(", "[", "{", "<":
+			depth++
+		case ")", "]", "}", ">":
+			depth--
+			if depth == 0 {
+				if empty {
+					return 0
+				}
+				return count
+			}
+		case ",":
+			if depth == 1 {
+				count++
+			}
+		default:
+			empty = false
+		}
+	}
+	if empty {
+		return 0
+	}
+	return count
+}
+
+func funcNode(params int, body []*Node) *Node {
+	return &Node{Kind: KindFunc + "/" + strconv.Itoa(params), Children: body}
+}
@@ -0,0 +1,117 @@
+package style
+
+import (
+	"math"
+	"sort"
+)
+
+// MinSubmissions is the number of submissions a profile needs before new
+// submissions are compared with it.
+const MinSubmissions = 3
+
+// Profile accumulates the features of one student's submissions in one
+// language. Sums and Counts are kept per feature because not every
+// submission has every feature.
+type Profile struct {
+	Submissions int                `json:"submissions"`
+	Sums        map[string]float64 `json:"sums"`
+	Counts      map[string]int     `json:"counts"`
+}
+
+// Add includes the features of one more submission.
+func (p *Profile) Add(f Features) {
+	if p.Sums == nil {
+		p.Sums = make(map[string]float64)
+		p.Counts = make(map[string]int)
+	}
+	for name, value := range f {
+		p.Sums[name] += value
+		p.Counts[name]++
+	}
+	p.Submissions++
+}
+
+// Average returns the mean of every feature seen.
+func (p *Profile) Average() Features {
+	average := make(Features, len(p.Sums))
+	for name, sum := range p.Sums {
+		if p.Counts[name] > 0 {
+			average[name] = sum / float64(p.Counts[name])
+		}
+	}
+	return average
+}
+
+// FeatureDeviation compares one feature. Deviation is normalized to 0..1.
+type FeatureDeviation struct {
+	Name       string  `json:"name"`
+	Profile    float64 `json:"profile"`
+	Submission float64 `json:"submission"`
+	Deviation  float64 `json:"deviation"`
+}
+
+// Deviation is how far a submission is from the student's usual style:
+// Score is the mean deviation of the features both have, from 0 (as usual)
+// to 1 (nothing in common). Features lists them from the largest deviation.
+type Deviation struct {
+	Score       float64            `json:"score"`
+	Submissions int                `json:"profile_submissions"`
+	Features    []FeatureDeviation `json:"features"`
+}
+
+// Compare measures f against the profile. It returns nil while the profile
+// has fewer than MinSubmissions submissions.
+func (p *Profile) Compare(f Features) *Deviation {
+	if p == nil || p.Submissions < MinSubmissions {
+		return nil
+	}
+
+	average := p.Average()
+	d := &Deviation{Submissions: p.Submissions}
+	total := 0.0
+	for name, value := range f {
+		usual, ok := average[name]
+		if !ok {
+			continue
+		}
+		deviation := distance(name, usual, value)
+		d.Features = append(d.Features, FeatureDeviation{
+			Name: name, Profile: round(usual), Submission: round(value), Deviation: round(deviation),
+		})
+		total += deviation
+	}
+	if len(d.Features) == 0 {
+		return d
+	}
+	d.Score = round(total / float64(len(d.Features)))
+	sort.SliceStable(d.Features, func(i, j int) bool {
+		if d.Features[i].Deviation != d.Features[j].Deviation {
+			return d.Features[i].Deviation > d.Features[j].Deviation
+		}
+		return d.Features[i].Name < d.Features[j].Name
+	})
+	return d
+}
+
+// distance puts the difference of two values of a feature on a 0..1 scale.
+// Shares compare directly; indentation, line length and idiom rates compare
+// relative to the larger value.
+func distance(name string, a, b float64) float64 {
+	switch name {
+	case IndentWidth:
+		return math.Min(1, math.Abs(a-b)/4)
+	case LineLength, IdiomComprehension, IdiomFormatString, IdiomLambda, IdiomTernary, IdiomIncrement, IdiomCompound:
+		larger := math.Max(a, b)
+		// Idioms used less than once in 100 lines say little either way.
+		if larger < 1 {
+			return 0
+		}
+		return math.Abs(a-b) / larger
+	default:
+		return math.Min(1, math.Abs(a-b))
+	}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
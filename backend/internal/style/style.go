@@ -0,0 +1,375 @@
+// Package style describes how a student writes code: naming conventions,
+// formatting habits and the idioms they reach for. A profile averages these
+// over the student's submissions in one language, and every new submission
+// gets a deviation score against it. A large deviation is a reason for a
+// teacher to look closer, not a finding in itself.
+package style
+
+import (
+	"strings"
+	"unicode"
+
+	"codegrader-backend/internal/similarity"
+)
+
+// Features maps feature names to values. Shares are between 0 and 1,
+// idioms are uses per 100 code lines; a feature the code gives no evidence
+// for, such as brace placement in Python, is absent.
+type Features map[string]float64
+
+// Feature names.
+const (
+	SnakeCase       = "snake_case"
+	CamelCase       = "camel_case"
+	ShortNames      = "short_names"
+	IndentWidth     = "indent_width"
+	Tabs            = "tabs"
+	BraceNewLine    = "brace_new_line"
+	SingleQuotes    = "single_quotes"
+	LineLength      = "line_length"
+	OperatorSpacing = "operator_spacing"
+	BlankLines      = "blank_lines"
+	Comments        = "comments"
+
+	IdiomComprehension = "idiom_comprehension"
+	IdiomFormatString  = "idiom_format_string"
+	IdiomLambda        = "idiom_lambda"
+	IdiomTernary       = "idiom_ternary"
+	IdiomIncrement     = "idiom_increment"
+	IdiomCompound      = "idiom_compound_assignment"
+	IdiomForEach       = "idiom_for_each"
+	IdiomWhile         = "idiom_while"
+)
+
+var keywords = map[string]bool{
+	"and": true, "as": true, "break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "def": true, "do": true, "elif": true, "else": true, "except": true, "false": true,
+	"False": true, "for": true, "from": true, "fun": true, "function": true, "if": true, "import": true,
+	"in": true, "int": true, "is": true, "lambda": true, "let": true, "new": true, "None": true, "not": true,
+	"null": true, "or": true, "pass": true, "private": true, "public": true, "return": true, "self": true,
+	"static": true, "this": true, "true": true, "True": true, "try": true, "val": true, "var": true,
+	"void": true, "while": true, "with": true, "yield": true,
+}
+
+// Extract measures the style of code written in the language of fileType.
+func Extract(code, fileType string) Features {
+	tokens := similarity.Scan(code, fileType)
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	f := make(Features)
+
+	naming(f, tokens)
+	formatting(f, lines, tokens)
+	quotes(f, tokens)
+	spacing(f, tokens)
+	idioms(f, tokens, fileType, codeLines(tokens))
+	return f
+}
+
+func naming(f Features, tokens []similarity.Token) {
+	names := make(map[string]bool)
+	for _, t := range tokens {
+		if isIdentifier(t.Text) && !keywords[t.Text] {
+			names[t.Text] = true
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	snake, camel, short := 0, 0, 0
+	for name := range names {
+		trimmed := strings.Trim(name, "_")
+		switch {
+		case len([]rune(trimmed)) <= 2:
+			short++
+		case strings.ToUpper(trimmed) == trimmed || unicode.IsUpper([]rune(trimmed)[0]):
+			// Constants and type names follow conventions of their own.
+		case strings.Contains(trimmed, "_"):
+			snake++
+		case strings.ToLower(trimmed) != trimmed:
+			camel++
+		}
+	}
+	f[ShortNames] = float64(short) / float64(len(names))
+	if snake+camel > 0 {
+		f[SnakeCase] = float64(snake) / float64(snake+camel)
+		f[CamelCase] = float64(camel) / float64(snake+camel)
+	}
+}
+
+func formatting(f Features, lines []string, tokens []similarity.Token) {
+	firstOnLine := make(map[int]similarity.Token)
+	onlyOnLine := make(map[int]bool)
+	for _, t := range tokens {
+		if _, ok := firstOnLine[t.Line]; !ok {
+			firstOnLine[t.Line] = t
+			onlyOnLine[t.Line] = true
+		} else {
+			onlyOnLine[t.Line] = false
+		}
+	}
+
+	blank, code, comment, tabs, indented, length := 0, 0, 0, 0, 0, 0
+	increases := make(map[int]int)
+	previous := 0
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			blank++
+			continue
+		}
+		if _, ok := firstOnLine[i+1]; !ok {
+			comment++
+			continue
+		}
+		code++
+		length += len([]rune(strings.TrimSpace(line)))
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent > 0 {
+			indented++
+			if strings.HasPrefix(line, "\t") {
+				tabs++
+			}
+		}
+		width := indent + 3*strings.Count(line[:indent], "\t")
+		if width > previous {
+			increases[width-previous]++
+		}
+		previous = width
+	}
+
+	if total := blank + code + comment; total > 0 {
+		f[BlankLines] = float64(blank) / float64(total)
+	}
+	if code+comment > 0 {
+		f[Comments] = float64(comment) / float64(code+comment)
+	}
+	if code > 0 {
+		f[LineLength] = float64(length) / float64(code)
+	}
+	if indented > 0 {
+		f[Tabs] = float64(tabs) / float64(indented)
+	}
+	step, best := 0, 0
+	for increase, n := range increases {
+		if n > best || n == best && increase < step {
+			step, best = increase, n
+		}
+	}
+	if step > 0 {
+		f[IndentWidth] = float64(step)
+	}
+
+	braces, ownLine := 0, 0
+	for i, t := range tokens {
+		if t.Text != "{" || i == 0 || !strings.ContainsAny(tokens[i-1].Text, ")") && !isIdentifier(tokens[i-1].Text) {
+			continue
+		}
+		braces++
+		if firstOnLine[t.Line] == t && onlyOnLine[t.Line] {
+			ownLine++
+		}
+	}
+	if braces > 0 {
+		f[BraceNewLine] = float64(ownLine) / float64(braces)
+	}
+}
+
+func quotes(f Features, tokens []similarity.Token) {
+	single, total := 0, 0
+	for _, t := range tokens {
+		switch {
+		case strings.HasPrefix(t.Text, "'") && len([]rune(t.Text)) > 3:
+			// Longer than a character literal such as 'a'.
+			single++
+			total++
+		case strings.HasPrefix(t.Text, `"`):
+			total++
+		}
+	}
+	if total > 0 {
+		f[SingleQuotes] = float64(single) / float64(total)
+	}
+}
+
+// spacing measures spaces around "=" and the arithmetic operators the
+// scanner keeps as single characters.
+func spacing(f Features, tokens []similarity.Token) {
+	spaced, total := 0, 0
+	for i := 1; i+1 < len(tokens); i++ {
+		t, prev, next := tokens[i], tokens[i-1], tokens[i+1]
+		if !strings.Contains("=+/%", t.Text) || prev.Line != t.Line || next.Line != t.Line {
+			continue
+		}
+		// Parts of "==", "+=", "++" and the like.
+		if adjacent(prev, t) && strings.Contains("=!<>+-*/%&|", prev.Text) ||
+			adjacent(t, next) && strings.Contains("=+-*/%&|>", next.Text) {
+			continue
+		}
+		total++
+		if !adjacent(prev, t) && !adjacent(t, next) {
+			spaced++
+		}
+	}
+	if total > 0 {
+		f[OperatorSpacing] = float64(spaced) / float64(total)
+	}
+}
+
+func idioms(f Features, tokens []similarity.Token, fileType string, lines int) {
+	if lines == 0 {
+		return
+	}
+	counts := make(map[string]int)
+	loops := 0
+	for i, t := range tokens {
+		var prev, next similarity.Token
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		startsLine := i == 0 || prev.Line != t.Line
+
+		switch {
+		case t.Text == "for" && fileType == ".py" && !startsLine:
+			counts[IdiomComprehension]++
+		case t.Text == "for":
+			loops++
+			if fileType == ".py" || forEach(tokens[i:]) {
+				counts[IdiomForEach]++
+			}
+		case t.Text == "while":
+			loops++
+			counts[IdiomWhile]++
+		case t.Text == "lambda" || t.Text == ">" && adjacent(prev, t) && arrow(prev.Text, fileType):
+			counts[IdiomLambda]++
+		case t.Text == "?" && next.Text != "." && next.Text != ":" && !adjacent(prev, t):
+			counts[IdiomTernary]++
+		case t.Text == "if" && fileType == ".py" && !startsLine && prev.Text != "else":
+			counts[IdiomTernary]++
+		case (t.Text == "+" || t.Text == "-") && next.Text == t.Text && adjacent(t, next):
+			counts[IdiomIncrement]++
+		case strings.Contains("+-*/", t.Text) && next.Text == "=" && adjacent(t, next) && prev.Text != t.Text:
+			counts[IdiomCompound]++
+		case isFormatString(t, next, fileType):
+			counts[IdiomFormatString]++
+		}
+	}
+
+	// A Python conditional inside a comprehension is a filter, not a ternary.
+	if fileType == ".py" {
+		counts[IdiomTernary] = max(0, counts[IdiomTernary]-filters(tokens))
+	}
+	for _, idiom := range []string{IdiomComprehension, IdiomFormatString, IdiomLambda, IdiomTernary, IdiomIncrement, IdiomCompound} {
+		f[idiom] = 100 * float64(counts[idiom]) / float64(lines)
+	}
+	if loops > 0 {
+		f[IdiomForEach] = float64(counts[IdiomForEach]) / float64(loops)
+		f[IdiomWhile] = float64(counts[IdiomWhile]) / float64(loops)
+	}
+}
+
+// forEach reports whether the loop starting at tokens[0] iterates over a
+// collection: "for (x : xs)", "for (x of xs)", "for (x in xs)".
+func forEach(tokens []similarity.Token) bool {
+	depth := 0
+	for _, t := range tokens[1:] {
+		switch t.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth <= 0 {
+				return false
+			}
+		case ";":
+			return false
+		case ":", "of", "in":
+			if depth == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// arrow reports whether "<op>>" introduces a lambda in the language:
+// "=>" in JavaScript, "->" in Java and Kotlin. In C and C++ "->" is member
+// access.
+func arrow(op, fileType string) bool {
+	switch fileType {
+	case ".js", ".ts":
+		return op == "="
+	case ".java", ".kt":
+		return op == "-"
+	default:
+		return false
+	}
+}
+
+// filters counts the "if" clauses of Python comprehensions.
+func filters(tokens []similarity.Token) int {
+	n := 0
+	inComprehension := false
+	depth := 0
+	for i, t := range tokens {
+		switch t.Text {
+		case "[", "(", "{":
+			depth++
+		case "]", ")", "}":
+			depth--
+			if depth == 0 {
+				inComprehension = false
+			}
+		case "for":
+			if i > 0 && tokens[i-1].Line == t.Line && depth > 0 {
+				inComprehension = true
+			}
+		case "if":
+			if inComprehension {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func isFormatString(t, next similarity.Token, fileType string) bool {
+	switch fileType {
+	case ".py":
+		return (t.Text == "f" || t.Text == "F") && adjacent(t, next) && (strings.HasPrefix(next.Text, `"`) || strings.HasPrefix(next.Text, "'"))
+	case ".js", ".ts":
+		return strings.HasPrefix(t.Text, "`") && strings.Contains(t.Text, "${")
+	case ".kt":
+		return strings.HasPrefix(t.Text, `"`) && strings.Contains(t.Text, "$")
+	default:
+		return t.Text == "printf" || t.Text == "format"
+	}
+}
+
+func codeLines(tokens []similarity.Token) int {
+	lines := make(map[int]bool)
+	for _, t := range tokens {
+		lines[t.Line] = true
+	}
+	return len(lines)
+}
+
+func adjacent(a, b similarity.Token) bool {
+	return a.Line == b.Line && a.Column+len(a.Text) == b.Column
+}
+
+func isIdentifier(token string) bool {
+	if token == "" {
+		return false
+	}
+	for i, r := range token {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package style
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pythonHabits = `def mean(values):
+    total = 0
+    for v in values:
+        total += v
+    return total / len(values)
+
+
+squares = [x * x for x in range(10) if x % 2]
+label = 'odd' if len(squares) > 3 else 'even'
+print(f'{label}: {mean(squares)}')
+`
+
+const javaHabits = `public class Main
+{
+	public static void main(String[] args)
+	{
+		int count=0;
+		for(int i=0;i<args.length;i++)
+		{
+			count++;
+		}
+		String s = count > 0 ? "some" : "none";
+		Runnable r = () -> System.out.println(s);
+	}
+}
+`
+
+func TestExtract_Python(t *testing.T) {
+	f := Extract(pythonHabits, ".py")
+
+	assert.Equal(t, 4.0, f[IndentWidth])
+	assert.Equal(t, 0.0, f[Tabs])
+	assert.Equal(t, 1.0, f[SingleQuotes])
+	assert.Equal(t, 1.0, f[OperatorSpacing])
+	assert.Equal(t, 1.0, f[IdiomForEach])
+	assert.Equal(t, 0.0, f[IdiomWhile])
+	// 8 code lines: one comprehension, one conditional expression, one
+	// f-string, one "+=".
+	assert.InDelta(t, 12.5, f[IdiomComprehension], 1e-9)
+	assert.InDelta(t, 12.5, f[IdiomTernary], 1e-9)
+	assert.InDelta(t, 12.5, f[IdiomFormatString], 1e-9)
+	assert.InDelta(t, 12.5, f[IdiomCompound], 1e-9)
+	assert.NotContains(t, f, BraceNewLine)
+}
+
+func TestExtract_Java(t *testing.T) {
+	f := Extract(javaHabits, ".java")
+
+	assert.Equal(t, 1.0, f[BraceNewLine])
+	assert.Equal(t, 1.0, f[Tabs])
+	assert.Equal(t, 0.0, f[IdiomForEach])
+	assert.InDelta(t, 100.0/13, f[IdiomLambda], 1e-9)
+	assert.InDelta(t, 100.0/13, f[IdiomTernary], 1e-9)
+	assert.InDelta(t, 200.0/13, f[IdiomIncrement], 1e-9)
+	// "count=0" and "i=0" against "s = ..." and "r = ...".
+	assert.Equal(t, 0.5, f[OperatorSpacing])
+}
+
+func TestProfile_CompareNeedsHistory(t *testing.T) {
+	var p Profile
+	f := Extract(pythonHabits, ".py")
+
+	assert.Nil(t, p.Compare(f))
+	for i := 0; i < MinSubmissions; i++ {
+		p.Add(f)
+	}
+
+	d := p.Compare(f)
+	require.NotNil(t, d)
+	assert.Equal(t, 0.0, d.Score)
+	assert.Equal(t, MinSubmissions, d.Submissions)
+}
+
+func TestProfile_CompareFindsChangedHabits(t *testing.T) {
+	var p Profile
+	for i := 0; i < MinSubmissions; i++ {
+		p.Add(Features{SnakeCase: 1, IndentWidth: 4, SingleQuotes: 1, IdiomComprehension: 10})
+	}
+
+	d := p.Compare(Features{SnakeCase: 0, IndentWidth: 2, SingleQuotes: 1, IdiomComprehension: 0, Tabs: 1})
+
+	require.NotNil(t, d)
+	// Tabs are not in the profile; the rest deviate by 1, 0.5, 0 and 1.
+	assert.Equal(t, 0.63, d.Score)
+	require.Len(t, d.Features, 4)
+	assert.Equal(t, FeatureDeviation{Name: IdiomComprehension, Profile: 10, Submission: 0, Deviation: 1}, d.Features[0])
+	assert.Equal(t, SingleQuotes, d.Features[3].Name)
+}
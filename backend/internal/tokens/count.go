@@ -0,0 +1,73 @@
+// Package tokens estimates prompt sizes. It does not bundle the models' BPE
+// vocabularies: counts are a heuristic meant to come out above the real
+// tokenizer's, not equal to it. Budgets built on them should keep their own
+// headroom below the model context; the defaults use at most half of it.
+package tokens
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// encoding holds the average number of characters per token for word-like
+// runs of a BPE vocabulary. Counts are estimates tuned to err on the high
+// side, which is what a budget check needs.
+type encoding struct {
+	asciiWord float64
+	otherWord float64
+}
+
+var (
+	o200k    = encoding{asciiWord: 5, otherWord: 3}
+	cl100k   = encoding{asciiWord: 4, otherWord: 2}
+	fallback = encoding{asciiWord: 3, otherWord: 1.5}
+)
+
+func encodingFor(model string) encoding {
+	model = strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(model, "gpt-4o"), strings.HasPrefix(model, "gpt-4.1"),
+		strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+		return o200k
+	case strings.HasPrefix(model, "gpt-4"), strings.HasPrefix(model, "gpt-3.5"):
+		return cl100k
+	default:
+		return fallback
+	}
+}
+
+// Count estimates how many tokens text takes in the tokenizer of model.
+func Count(text, model string) int {
+	enc := encodingFor(model)
+	runes := []rune(text)
+	count := 0
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			// A run of whitespace (newline plus indentation) is usually one token.
+			for i < len(runes) && unicode.IsSpace(runes[i]) {
+				i++
+			}
+			count++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			ascii, other := 0, 0
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				if runes[i] < unicode.MaxASCII {
+					ascii++
+				} else {
+					other++
+				}
+				i++
+			}
+			count += int(math.Ceil(float64(ascii)/enc.asciiWord + float64(other)/enc.otherWord))
+		default:
+			count++
+			i++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,25 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCount_GrowsWithText(t *testing.T) {
+	line := "for (int i = 0; i < n; i++) { total += values[i]; }\n"
+
+	one := Count(line, "gpt-4o-mini")
+	ten := Count(strings.Repeat(line, 10), "gpt-4o-mini")
+
+	assert.Greater(t, one, 10)
+	assert.InDelta(t, one*10, ten, float64(one))
+}
+
+func TestCount_OlderModelsNeedMoreTokens(t *testing.T) {
+	text := "Проанализируй следующий код и оцени его читаемость"
+
+	assert.Greater(t, Count(text, "gpt-3.5-turbo"), Count(text, "gpt-4o"))
+	assert.Greater(t, Count(text, "unknown-model"), Count(text, "gpt-3.5-turbo"))
+}
@@ -2,11 +2,13 @@ import React, { useState } from 'react';
 import { submissionApi } from '../../shared/api';
 
 const FILE_TYPES = [
+  { value: '.c', label: 'C (.c)' },
   { value: '.cpp', label: 'C++ (.cpp)' },
   { value: '.java', label: 'Java (.java)' },
   { value: '.js', label: 'JavaScript (.js)' },
   { value: '.kt', label: 'Kotlin (.kt)' },
   { value: '.py', label: 'Python (.py)' },
+  { value: '.ts', label: 'TypeScript (.ts)' },
 ];
 
 export const SubmitCodeForm = ({ onSubmissionComplete, onLoadingChange }) => {
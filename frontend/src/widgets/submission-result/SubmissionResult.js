@@ -3,11 +3,7 @@ import React from 'react';
 export const SubmissionResult = ({ result, onReset }) => {
   if (!result) return null;
 
-  const getGradeColor = (grade) => {
-    if (grade >= 5) return '#28a745';
-    if (grade >= 4) return '#ffc107';
-    return '#dc3545';
-  };
+  const getGradeColor = (passed) => (passed ? '#28a745' : '#dc3545');
 
   return (
     <div className="card">
@@ -17,9 +13,27 @@ export const SubmissionResult = ({ result, onReset }) => {
         ✅ Задание успешно отправлено!
       </div>
 
-      <div className="grade" style={{ color: getGradeColor(result.grade) }}>
-        Оценка: {result.grade}/5
-      </div>
+      {result.grading_status === 'pending' ? (
+        <div className="grade">
+          Оценка: ожидает проверки
+        </div>
+      ) : (
+        <div className="grade" style={{ color: getGradeColor(result.passed) }}>
+          Оценка: {result.grade_label || result.grade}
+        </div>
+      )}
+
+      {result.grade_samples && (
+        <div style={{ marginBottom: '16px', color: '#6c757d' }}>
+          Оценки ансамбля: {result.grade_samples} (согласие {Math.round(result.grade_confidence * 100)}%)
+        </div>
+      )}
+
+      {result.needs_review && (
+        <div className="alert alert-warning">
+          ⚠️ Оценка будет подтверждена преподавателем
+        </div>
+      )}
 
       {result.feedback && (
         <div>
@@ -30,6 +44,40 @@ export const SubmissionResult = ({ result, onReset }) => {
         </div>
       )}
 
+      {result.annotations && result.annotations.length > 0 && (
+        <div>
+          <h3>Замечания к коду:</h3>
+          {result.annotations.map((annotation, index) => (
+            <div key={index} className={`annotation annotation-${annotation.severity}`}>
+              <div className="annotation-location">
+                {annotation.file}: {annotation.start_line === annotation.end_line
+                  ? `строка ${annotation.start_line}`
+                  : `строки ${annotation.start_line}–${annotation.end_line}`}
+                {' · '}{annotation.category}
+                {annotation.source && ` · ${annotation.source}`}
+              </div>
+              <div>{annotation.message}</div>
+              {annotation.suggestion && (
+                <pre className="annotation-suggestion">{annotation.suggestion}</pre>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+
+      {result.metrics && (
+        <div>
+          <h3>Метрики кода:</h3>
+          <ul className="metrics">
+            <li>Строк кода: {result.metrics.code_lines}, комментариев: {Math.round(result.metrics.comment_ratio * 100)}%</li>
+            <li>Функций: {result.metrics.function_count}, самая длинная: {result.metrics.max_function_length} строк</li>
+            <li>Сложность: цикломатическая {result.metrics.cyclomatic}, когнитивная {result.metrics.cognitive}</li>
+            <li>Вложенность: {result.metrics.max_nesting}</li>
+            <li>Повторяющийся код: {Math.round(result.metrics.duplication * 100)}%</li>
+          </ul>
+        </div>
+      )}
+
       <button
         className="btn btn-primary"
         onClick={onReset}